@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/service/account"
+	"github.com/akemoon/crowdfunding-app-auth/service/audit"
+	"github.com/akemoon/crowdfunding-app-auth/service/device"
+	"github.com/akemoon/crowdfunding-app-auth/service/token"
+)
+
+// @Summary Delete account
+// @Description Schedule the account for deletion and sign out of every session. The data is purged after a grace period.
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization header with access token"
+// @Param payload body domain.DeleteAccountRequest true "Password confirmation"
+// @Success 202 {object} domain.DeleteAccountResponse
+// @Failure 400 "Invalid request"
+// @Failure 401 "Unauthorized"
+// @Failure 403 "Invalid current password or deletion already requested"
+// @Failure 405 "Method not allowed"
+// @Failure 423 "Account locked"
+// @Failure 500 "Internal server error"
+// @Router /account [delete]
+func DeleteAccount(svc *account.Service, tokenSvc *token.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tc, err := authorize(r, tokenSvc)
+		if err != nil {
+			log.Printf("token service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		var req domain.DeleteAccountRequest
+
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		resp, err := svc.RequestDeletion(r.Context(), tc, req)
+		if err != nil {
+			log.Printf("account service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		writeJSON(w, http.StatusAccepted, resp)
+	}
+}
+
+// @Summary Login history
+// @Description List recent successful and failed sign-ins to the account, newest first
+// @Produce json
+// @Param Authorization header string true "Authorization header with access token"
+// @Param limit query int false "Page size, at most 100"
+// @Param cursor query string false "nextCursor of the previous page"
+// @Success 200 {object} domain.LoginHistoryResponse
+// @Failure 400 "Invalid query"
+// @Failure 401 "Unauthorized"
+// @Failure 405 "Method not allowed"
+// @Failure 500 "Internal server error"
+// @Router /account/login-history [get]
+func LoginHistory(svc *audit.Service, tokenSvc *token.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tc, err := authorize(r, tokenSvc)
+		if err != nil {
+			log.Printf("token service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		var limit int
+		if v := r.URL.Query().Get("limit"); v != "" {
+			limit, err = strconv.Atoi(v)
+			if err != nil || limit <= 0 {
+				http.Error(w, "invalid limit", http.StatusBadRequest)
+				return
+			}
+		}
+
+		resp, err := svc.LoginHistory(r.Context(), tc.UserID, limit, r.URL.Query().Get("cursor"))
+		if err != nil {
+			log.Printf("audit service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// @Summary Secure account
+// @Description Act on the link of a new-device email: revoke every session and passkey, refuse sign-in until the password is reset, and email a reset link
+// @Accept json
+// @Produce json
+// @Param payload body domain.SecureAccountRequest true "Token from the new-device email"
+// @Success 204 "Account secured"
+// @Failure 400 "Invalid or expired token"
+// @Failure 405 "Method not allowed"
+// @Failure 429 "Too many requests"
+// @Failure 500 "Internal server error"
+// @Router /account/secure [post]
+func SecureAccount(svc *device.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req domain.SecureAccountRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err = svc.SecureAccount(r.Context(), req.Token)
+		if err != nil {
+			log.Printf("device service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/service/account"
+	"github.com/akemoon/crowdfunding-app-auth/service/audit"
+	"github.com/google/uuid"
+)
+
+const adminKeyHeader = "X-Admin-Key"
+
+// @Summary Unlock account
+// @Description Lift a sign-in lockout immediately
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param userID path string true "User UUID"
+// @Success 204 "Unlocked"
+// @Failure 400 "Invalid user id"
+// @Failure 401 "Invalid admin key"
+// @Failure 500 "Internal server error"
+// @Router /admin/accounts/{userID}/unlock [post]
+func UnlockAccount(svc *account.Service, adminKey string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := authorizeAdmin(r, adminKey)
+		if err != nil {
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		userID, err := uuid.Parse(r.PathValue("userID"))
+		if err != nil {
+			http.Error(w, "invalid user id", http.StatusBadRequest)
+			return
+		}
+
+		err = svc.Unlock(r.Context(), userID)
+		if err != nil {
+			log.Printf("account service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		log.Printf("admin: unlocked account %s", userID)
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// @Summary Suspend account
+// @Description Suspend or disable an account, optionally until a given time, and revoke its sessions and access tokens
+// @Accept json
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param userID path string true "User UUID"
+// @Param payload body domain.SetAccountStatusRequest true "Status, reason and optional expiry"
+// @Success 204 "Suspended"
+// @Failure 400 "Invalid request"
+// @Failure 401 "Invalid admin key"
+// @Failure 404 "Account not found"
+// @Failure 500 "Internal server error"
+// @Router /admin/accounts/{userID}/suspend [post]
+func SuspendAccount(svc *account.Service, adminKey string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := authorizeAdmin(r, adminKey)
+		if err != nil {
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		userID, err := uuid.Parse(r.PathValue("userID"))
+		if err != nil {
+			http.Error(w, "invalid user id", http.StatusBadRequest)
+			return
+		}
+
+		var req domain.SetAccountStatusRequest
+
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err = svc.SetStatus(r.Context(), userID, req)
+		if err != nil {
+			log.Printf("account service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		log.Printf("admin: set account %s %s: %s", userID, req.Status, req.Reason)
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// @Summary Reinstate account
+// @Description Lift a suspension or disabling immediately
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param userID path string true "User UUID"
+// @Success 204 "Reinstated"
+// @Failure 400 "Invalid user id"
+// @Failure 401 "Invalid admin key"
+// @Failure 404 "Account not found"
+// @Failure 500 "Internal server error"
+// @Router /admin/accounts/{userID}/reinstate [post]
+func ReinstateAccount(svc *account.Service, adminKey string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := authorizeAdmin(r, adminKey)
+		if err != nil {
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		userID, err := uuid.Parse(r.PathValue("userID"))
+		if err != nil {
+			http.Error(w, "invalid user id", http.StatusBadRequest)
+			return
+		}
+
+		err = svc.Reinstate(r.Context(), userID)
+		if err != nil {
+			log.Printf("account service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		log.Printf("admin: reinstated account %s", userID)
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// @Summary List auth events
+// @Description Query the authentication audit log, newest first
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param userID query string false "User UUID"
+// @Param type query []string false "Event types, e.g. sign_in_failed" collectionFormat(multi)
+// @Param email query string false "Email of attempts that named no account"
+// @Param ip query string false "Client IP"
+// @Param from query string false "Earliest time, RFC 3339"
+// @Param to query string false "Latest time (exclusive), RFC 3339"
+// @Param limit query int false "Page size, at most 500"
+// @Param cursor query string false "nextCursor of the previous page"
+// @Success 200 {object} domain.AuthEventsResponse
+// @Failure 400 "Invalid query"
+// @Failure 401 "Invalid admin key"
+// @Failure 500 "Internal server error"
+// @Router /admin/auth-events [get]
+func ListAuthEvents(svc *audit.Service, adminKey string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := authorizeAdmin(r, adminKey)
+		if err != nil {
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		filter, err := parseAuthEventFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resp, err := svc.List(r.Context(), filter, r.URL.Query().Get("cursor"))
+		if err != nil {
+			log.Printf("audit service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func parseAuthEventFilter(q url.Values) (domain.AuthEventFilter, error) {
+	filter := domain.AuthEventFilter{
+		Email: q.Get("email"),
+		IP:    q.Get("ip"),
+	}
+
+	for _, t := range q["type"] {
+		filter.Types = append(filter.Types, domain.AuthEventType(t))
+	}
+
+	if v := q.Get("userID"); v != "" {
+		userID, err := uuid.Parse(v)
+		if err != nil {
+			return domain.AuthEventFilter{}, errors.New("invalid userID")
+		}
+		filter.UserID = &userID
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return domain.AuthEventFilter{}, fmt.Errorf("invalid %s", p.name)
+		}
+		*p.dst = &t
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return domain.AuthEventFilter{}, errors.New("invalid limit")
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+func authorizeAdmin(r *http.Request, adminKey string) error {
+	key := r.Header.Get(adminKeyHeader)
+	if adminKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) != 1 {
+		return domain.ErrInvalidAdminKey
+	}
+	return nil
+}
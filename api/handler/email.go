@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/service/email"
+	"github.com/akemoon/crowdfunding-app-auth/service/token"
+)
+
+// @Summary Request email change
+// @Description Send a confirmation link to the new email address
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization header with access token"
+// @Param payload body domain.ChangeEmailRequest true "Change email payload"
+// @Success 202 "Confirmation sent"
+// @Failure 400 "Invalid request"
+// @Failure 401 "Unauthorized"
+// @Failure 403 "Invalid current password"
+// @Failure 405 "Method not allowed"
+// @Failure 409 "Email already exists"
+// @Failure 423 "Account locked"
+// @Failure 500 "Internal server error"
+// @Router /email/change [post]
+func ChangeEmail(svc *email.Service, tokenSvc *token.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tc, err := authorize(r, tokenSvc)
+		if err != nil {
+			log.Printf("token service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		var req domain.ChangeEmailRequest
+
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err = svc.RequestChange(r.Context(), tc, req)
+		if err != nil {
+			log.Printf("email service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// @Summary Confirm email change
+// @Description Switch to the new email address with a token from the confirmation email
+// @Accept json
+// @Produce json
+// @Param payload body domain.EmailChangeTokenRequest true "Confirmation payload"
+// @Success 204 "Email changed"
+// @Failure 400 "Invalid or expired token"
+// @Failure 405 "Method not allowed"
+// @Failure 409 "Email already exists"
+// @Failure 500 "Internal server error"
+// @Router /email/change/confirm [post]
+func ConfirmEmailChange(svc *email.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req domain.EmailChangeTokenRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err = svc.Confirm(r.Context(), req.Token)
+		if err != nil {
+			log.Printf("email service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// @Summary Revert email change
+// @Description Restore the previous email address, revoke all sessions and refuse sign-in until the password is reset
+// @Accept json
+// @Produce json
+// @Param payload body domain.EmailChangeTokenRequest true "Revert payload"
+// @Success 204 "Email restored"
+// @Failure 400 "Invalid or expired token"
+// @Failure 405 "Method not allowed"
+// @Failure 409 "Email already exists"
+// @Failure 500 "Internal server error"
+// @Router /email/change/revert [post]
+func RevertEmailChange(svc *email.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req domain.EmailChangeTokenRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err = svc.Revert(r.Context(), req.Token)
+		if err != nil {
+			log.Printf("email service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/metrics"
+	"github.com/akemoon/crowdfunding-app-auth/service/auth"
+	"github.com/akemoon/crowdfunding-app-auth/service/emailotp"
+)
+
+// @Summary Request email sign-in code
+// @Description Email a 6-digit sign-in code. The response is the same whether or not the account exists.
+// @Accept json
+// @Produce json
+// @Param payload body domain.EmailOTPRequest true "Email payload"
+// @Success 202 "Code sent if the account exists"
+// @Failure 400 "Invalid request"
+// @Failure 405 "Method not allowed"
+// @Failure 429 "Too many requests"
+// @Failure 500 "Internal server error"
+// @Router /signin/email-otp [post]
+func RequestEmailOTP(svc *emailotp.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req domain.EmailOTPRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err = svc.Request(r.Context(), req.Email)
+		if err != nil {
+			log.Printf("email otp service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// @Summary Sign in with email code
+// @Description Exchange an emailed code for access/refresh tokens, or an MFA token when 2FA is enabled
+// @Accept json
+// @Produce json
+// @Param payload body domain.EmailOTPVerifyRequest true "Code payload"
+// @Success 200 {object} domain.SignInResponse "Tokens issued"
+// @Failure 400 "Invalid request"
+// @Failure 401 "Invalid or expired code"
+// @Failure 403 "Email not verified, password reset required, account suspended, disabled or scheduled for deletion"
+// @Failure 405 "Method not allowed"
+// @Failure 500 "Internal server error"
+// @Router /signin/email-otp/verify [post]
+func VerifyEmailOTP(svc *auth.Service, m *metrics.AuthMetrics) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req domain.EmailOTPVerifyRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		resp, err := svc.SignInWithEmailOTP(r.Context(), req)
+		if err != nil {
+			log.Printf("auth service: %s", err)
+
+			observeSignIn(m, resp, err)
+
+			status, errResp := mapErrToHTTP(err)
+			writeJSON(w, status, errResp)
+			return
+		}
+
+		observeSignIn(m, resp, nil)
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
@@ -4,23 +4,25 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/akemoon/crowdfunding-app-auth/domain"
 	"github.com/akemoon/crowdfunding-app-auth/metrics"
+	"github.com/akemoon/crowdfunding-app-auth/pkg/authverify"
 	"github.com/akemoon/crowdfunding-app-auth/service/auth"
 	"github.com/akemoon/crowdfunding-app-auth/service/token"
 )
 
 // @Summary Sign up
-// @Description Create credentials and user profile
+// @Description Create credentials and user profile. With the notify conflict policy a registered email is answered like a new account and its owner is emailed instead.
 // @Accept json
 // @Produce json
 // @Param payload body domain.SignUpRequest true "Sign up payload"
 // @Success 201 "User created"
 // @Failure 400 "Invalid request"
 // @Failure 405 "Method not allowed"
-// @Failure 409 "Conflict"
+// @Failure 409 "Username taken, or email registered with the reveal conflict policy"
 // @Failure 500 "Internal server error"
 // @Router /signup [post]
 func SignUp(svc *auth.Service) http.HandlerFunc {
@@ -52,13 +54,16 @@ func SignUp(svc *auth.Service) http.HandlerFunc {
 }
 
 // @Summary Sign in
-// @Description Authenticate and return access/refresh tokens
+// @Description Authenticate and return access/refresh tokens, or an MFA token to complete at /signin/mfa when 2FA is enabled
 // @Accept json
 // @Produce json
 // @Param payload body domain.SignInRequest true "Sign in payload"
 // @Success 200 {object} domain.SignInResponse "Tokens issued"
 // @Failure 400 "Invalid request"
+// @Failure 401 "Invalid email or password"
+// @Failure 403 "Email not verified, password reset required, account suspended, disabled or scheduled for deletion"
 // @Failure 405 "Method not allowed"
+// @Failure 423 "Account locked"
 // @Failure 500 "Internal server error"
 // @Router /signin [post]
 func SignIn(svc *auth.Service, m *metrics.AuthMetrics) http.HandlerFunc {
@@ -80,14 +85,14 @@ func SignIn(svc *auth.Service, m *metrics.AuthMetrics) http.HandlerFunc {
 		if err != nil {
 			log.Printf("auth service: %s", err)
 
-			m.AuthSignInTotal.WithLabelValues("failure").Inc()
+			observeSignIn(m, resp, err)
 
 			status, errResp := mapErrToHTTP(err)
 			writeJSON(w, status, errResp)
 			return
 		}
 
-		m.AuthSignInTotal.WithLabelValues("success").Inc()
+		observeSignIn(m, resp, nil)
 
 		writeJSON(w, http.StatusOK, resp)
 	}
@@ -138,6 +143,8 @@ func SignOut(svc *auth.Service) http.HandlerFunc {
 // @Param Authorization header string true "Authorization header with access token"
 // @Success 200 "Access token is valid"
 // @Header  200 {string} X-User-Id "Authenticated user UUID"
+// @Header  200 {string} X-Token-Restricted "true if the token is limited to X-Token-Scopes"
+// @Header  200 {string} X-Token-Scopes "Comma separated scopes of a restricted token"
 // @Failure 401 "Unauthorized"
 // @Failure 405 "Method not allowed"
 // @Failure 500 "Internal server error"
@@ -155,7 +162,7 @@ func CheckAccessToken(svc *token.Service) http.HandlerFunc {
 			return
 		}
 
-		userID, err := svc.ValidateAccessToken(authHeader)
+		tc, err := svc.ParseAccessToken(r.Context(), authHeader)
 		if err != nil {
 			log.Printf("token service: %s", err)
 
@@ -164,12 +171,83 @@ func CheckAccessToken(svc *token.Service) http.HandlerFunc {
 			return
 		}
 
-		w.Header().Set("X-User-Id", userID.String())
+		w.Header().Set(authverify.HeaderUserID, tc.UserID.String())
+		w.Header().Set(authverify.HeaderRestricted, strconv.FormatBool(tc.Restricted))
+		if len(tc.Scopes) > 0 {
+			w.Header().Set(authverify.HeaderScopes, strings.Join(tc.Scopes, ","))
+		}
 		w.WriteHeader(http.StatusOK)
 	}
 }
 
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access/refresh token pair
+// @Accept json
+// @Produce json
+// @Param payload body domain.RefreshRequest true "Refresh payload"
+// @Success 200 {object} domain.SignInResponse "Tokens issued"
+// @Failure 400 "Invalid request"
+// @Failure 401 "Invalid refresh token"
+// @Failure 403 "Password reset required, email not verified, account suspended, disabled or scheduled for deletion"
+// @Failure 405 "Method not allowed"
+// @Failure 500 "Internal server error"
+// @Router /refresh [post]
+func Refresh(svc *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req domain.RefreshRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		resp, err := svc.Refresh(r.Context(), req.RefreshToken)
+		if err != nil {
+			log.Printf("auth service: %s", err)
+
+			status, errResp := mapErrToHTTP(err)
+			writeJSON(w, status, errResp)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// observeSignIn counts a sign-in attempt by result and, for failures, by
+// reason.
+func observeSignIn(m *metrics.AuthMetrics, resp domain.SignInResponse, err error) {
+	switch {
+	case err != nil:
+		m.AuthSignInTotal.WithLabelValues("failure", domain.SignInFailureReason(err)).Inc()
+	case resp.MFARequired:
+		m.AuthSignInTotal.WithLabelValues("mfa_required", "").Inc()
+	default:
+		m.AuthSignInTotal.WithLabelValues("success", "").Inc()
+	}
+}
+
+// authorize returns the claims of the access token in the Authorization
+// header.
+func authorize(r *http.Request, svc *token.Service) (domain.TokenClaims, error) {
+	authHeader := r.Header.Get("Authorization")
+	if strings.TrimSpace(authHeader) == "" {
+		return domain.TokenClaims{}, domain.ErrInvalidAccessToken
+	}
+
+	return svc.ParseAccessToken(r.Context(), authHeader)
+}
+
 func writeJSON(w http.ResponseWriter, status int, v any) {
+	if resp, ok := v.(ErrResp); ok && resp.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(resp.RetryAfter))
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(v)
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/metrics"
+	"github.com/akemoon/crowdfunding-app-auth/service/auth"
+	"github.com/akemoon/crowdfunding-app-auth/service/magiclink"
+)
+
+const (
+	magicLinkNonceCookie = "magic_link_nonce"
+	magicLinkCookiePath  = "/signin/magic-link"
+)
+
+// @Summary Request magic link
+// @Description Email a single-use sign-in link. The response is the same whether or not the account exists. The link only works together with the returned nonce, which is also set as a cookie.
+// @Accept json
+// @Produce json
+// @Param payload body domain.MagicLinkRequest true "Magic link payload"
+// @Success 202 {object} domain.MagicLinkResponse "Link sent if the account exists"
+// @Failure 400 "Invalid request"
+// @Failure 405 "Method not allowed"
+// @Failure 429 "Too many requests"
+// @Failure 500 "Internal server error"
+// @Router /signin/magic-link [post]
+func RequestMagicLink(svc *magiclink.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req domain.MagicLinkRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		nonce, err := svc.Request(r.Context(), req.Email)
+		if err != nil {
+			log.Printf("magic link service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     magicLinkNonceCookie,
+			Value:    nonce,
+			Path:     magicLinkCookiePath,
+			MaxAge:   int(svc.TokenTTL().Seconds()),
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		writeJSON(w, http.StatusAccepted, domain.MagicLinkResponse{Nonce: nonce})
+	}
+}
+
+// @Summary Sign in with magic link
+// @Description Exchange a magic link token for access/refresh tokens, or an MFA token when 2FA is enabled. The nonce is taken from the body or the cookie set by /signin/magic-link.
+// @Accept json
+// @Produce json
+// @Param payload body domain.MagicLinkVerifyRequest true "Magic link token"
+// @Success 200 {object} domain.SignInResponse "Tokens issued"
+// @Failure 400 "Invalid or expired link"
+// @Failure 403 "Email not verified, password reset required, account suspended, disabled or scheduled for deletion"
+// @Failure 405 "Method not allowed"
+// @Failure 500 "Internal server error"
+// @Router /signin/magic-link/verify [post]
+func VerifyMagicLink(svc *auth.Service, m *metrics.AuthMetrics) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req domain.MagicLinkVerifyRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.Nonce == "" {
+			cookie, err := r.Cookie(magicLinkNonceCookie)
+			if err == nil {
+				req.Nonce = cookie.Value
+			}
+		}
+
+		resp, err := svc.SignInWithMagicLink(r.Context(), req)
+		if err != nil {
+			log.Printf("auth service: %s", err)
+
+			observeSignIn(m, resp, err)
+
+			status, errResp := mapErrToHTTP(err)
+			writeJSON(w, status, errResp)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     magicLinkNonceCookie,
+			Path:     magicLinkCookiePath,
+			MaxAge:   -1,
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		observeSignIn(m, resp, nil)
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"errors"
+	"math"
 	"net/http"
 
 	"github.com/akemoon/crowdfunding-app-auth/cluster/user"
@@ -9,20 +10,88 @@ import (
 )
 
 const (
-	HttpErrEmailExists        = "email_exists"
-	HttpErrUsernameExists     = "username_exists"
-	HttpErrUnknownConflict    = "unknown_conflict"
-	HttpInternalError         = "internal_error"
-	HttpErrInvalidAccessToken = "invalid_access_token"
+	HttpErrEmailExists         = "email_exists"
+	HttpErrUsernameExists      = "username_exists"
+	HttpErrUnknownConflict     = "unknown_conflict"
+	HttpInternalError          = "internal_error"
+	HttpErrInvalidAccessToken  = "invalid_access_token"
+	HttpErrInvalidRefreshToken = "invalid_refresh_token"
+	HttpErrInvalidCredentials  = "invalid_credentials"
+
+	HttpErrInvalidVerificationToken = "invalid_verification_token"
+	HttpErrEmailNotVerified         = "email_not_verified"
+	HttpErrInvalidResetToken        = "invalid_reset_token"
+	HttpErrInvalidRequest           = "invalid_request"
+	HttpErrInvalidCurrentPassword   = "invalid_current_password"
+	HttpErrInvalidEmailChangeToken  = "invalid_email_change_token"
+	HttpErrValidationFailed         = "validation_failed"
+	HttpErrAccountLocked            = "account_locked"
+	HttpErrInvalidAdminKey          = "invalid_admin_key"
+	HttpErrRateLimited              = "rate_limited"
+	HttpErrMFAUnavailable           = "mfa_unavailable"
+	HttpErrMFANotEnrolled           = "mfa_not_enrolled"
+	HttpErrMFAAlreadyEnabled        = "mfa_already_enabled"
+	HttpErrInvalidMFACode           = "invalid_mfa_code"
+	HttpErrInvalidMFAToken          = "invalid_mfa_token"
+	HttpErrInvalidMagicLink         = "invalid_magic_link"
+	HttpErrInvalidEmailOTP          = "invalid_email_otp"
+	HttpErrAccountPendingDeletion   = "account_pending_deletion"
+	HttpErrAccountSuspended         = "account_suspended"
+	HttpErrAccountDisabled          = "account_disabled"
+	HttpErrAccountNotFound          = "account_not_found"
+	HttpErrPasskeysUnavailable      = "passkeys_unavailable"
+	HttpErrInvalidPasskey           = "invalid_passkey"
+	HttpErrPasskeyNotFound          = "passkey_not_found"
+	HttpErrPasskeyExists            = "passkey_exists"
+	HttpErrPasswordResetRequired    = "password_reset_required"
+	HttpErrInvalidSecureToken       = "invalid_secure_token"
 )
 
 type ErrResp struct {
-	Error   string `json:"error"`
-	Details string `json:"details"`
+	Error   string              `json:"error"`
+	Details string              `json:"details"`
+	Fields  []domain.FieldError `json:"fields,omitempty"`
+	// RetryAfter is in seconds; writeJSON mirrors it in the Retry-After header.
+	RetryAfter int `json:"retryAfter,omitempty"`
 }
 
 func mapErrToHTTP(err error) (int, ErrResp) {
 
+	var lockedErr *domain.AccountLockedError
+	if errors.As(err, &lockedErr) {
+		return http.StatusLocked, ErrResp{
+			Error:      HttpErrAccountLocked,
+			Details:    domain.ErrAccountLocked.Error(),
+			RetryAfter: int(math.Ceil(lockedErr.RetryAfter.Seconds())),
+		}
+	}
+
+	if errors.Is(err, domain.ErrInvalidAdminKey) {
+		return http.StatusUnauthorized, ErrResp{
+			Error:   HttpErrInvalidAdminKey,
+			Details: domain.ErrInvalidAdminKey.Error(),
+		}
+	}
+
+	var validationErr *domain.ValidationError
+	if errors.As(err, &validationErr) {
+		return http.StatusBadRequest, ErrResp{
+			Error:   HttpErrValidationFailed,
+			Details: domain.ErrInvalidRequest.Error(),
+			Fields:  validationErr.Fields,
+		}
+	}
+
+	// sign-in does not tell an unknown account from a wrong password
+	if errors.Is(err, domain.ErrInvalidCredentials) ||
+		errors.Is(err, domain.ErrCredsNotFound) ||
+		errors.Is(err, domain.ErrInvalidPassrord) {
+		return http.StatusUnauthorized, ErrResp{
+			Error:   HttpErrInvalidCredentials,
+			Details: domain.ErrInvalidCredentials.Error(),
+		}
+	}
+
 	if errors.Is(err, domain.ErrEmailExists) {
 		return http.StatusConflict, ErrResp{
 			Error:   HttpErrEmailExists,
@@ -51,6 +120,172 @@ func mapErrToHTTP(err error) (int, ErrResp) {
 		}
 	}
 
+	if errors.Is(err, domain.ErrInvlaidRefreshToken) {
+		return http.StatusUnauthorized, ErrResp{
+			Error:   HttpErrInvalidRefreshToken,
+			Details: domain.ErrInvlaidRefreshToken.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrInvalidVerificationToken) {
+		return http.StatusBadRequest, ErrResp{
+			Error:   HttpErrInvalidVerificationToken,
+			Details: domain.ErrInvalidVerificationToken.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrEmailNotVerified) {
+		return http.StatusForbidden, ErrResp{
+			Error:   HttpErrEmailNotVerified,
+			Details: domain.ErrEmailNotVerified.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrInvalidResetToken) {
+		return http.StatusBadRequest, ErrResp{
+			Error:   HttpErrInvalidResetToken,
+			Details: domain.ErrInvalidResetToken.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrInvalidRequest) {
+		return http.StatusBadRequest, ErrResp{
+			Error:   HttpErrInvalidRequest,
+			Details: domain.ErrInvalidRequest.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrInvalidCurrentPassword) {
+		return http.StatusForbidden, ErrResp{
+			Error:   HttpErrInvalidCurrentPassword,
+			Details: domain.ErrInvalidCurrentPassword.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrInvalidEmailChangeToken) {
+		return http.StatusBadRequest, ErrResp{
+			Error:   HttpErrInvalidEmailChangeToken,
+			Details: domain.ErrInvalidEmailChangeToken.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrMFAUnavailable) {
+		return http.StatusNotImplemented, ErrResp{
+			Error:   HttpErrMFAUnavailable,
+			Details: domain.ErrMFAUnavailable.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrMFANotEnrolled) {
+		return http.StatusConflict, ErrResp{
+			Error:   HttpErrMFANotEnrolled,
+			Details: domain.ErrMFANotEnrolled.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrMFAAlreadyEnabled) {
+		return http.StatusConflict, ErrResp{
+			Error:   HttpErrMFAAlreadyEnabled,
+			Details: domain.ErrMFAAlreadyEnabled.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrInvalidMFACode) {
+		return http.StatusUnauthorized, ErrResp{
+			Error:   HttpErrInvalidMFACode,
+			Details: domain.ErrInvalidMFACode.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrInvalidMFAToken) {
+		return http.StatusUnauthorized, ErrResp{
+			Error:   HttpErrInvalidMFAToken,
+			Details: domain.ErrInvalidMFAToken.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrInvalidMagicLink) {
+		return http.StatusBadRequest, ErrResp{
+			Error:   HttpErrInvalidMagicLink,
+			Details: domain.ErrInvalidMagicLink.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrInvalidEmailOTP) {
+		return http.StatusUnauthorized, ErrResp{
+			Error:   HttpErrInvalidEmailOTP,
+			Details: domain.ErrInvalidEmailOTP.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrAccountPendingDeletion) {
+		return http.StatusForbidden, ErrResp{
+			Error:   HttpErrAccountPendingDeletion,
+			Details: domain.ErrAccountPendingDeletion.Error(),
+		}
+	}
+
+	var statusErr *domain.AccountStatusError
+	if errors.As(err, &statusErr) {
+		code := HttpErrAccountDisabled
+		if errors.Is(statusErr, domain.ErrAccountSuspended) {
+			code = HttpErrAccountSuspended
+		}
+		return http.StatusForbidden, ErrResp{
+			Error:   code,
+			Details: statusErr.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrPasswordResetRequired) {
+		return http.StatusForbidden, ErrResp{
+			Error:   HttpErrPasswordResetRequired,
+			Details: domain.ErrPasswordResetRequired.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrInvalidSecureToken) {
+		return http.StatusBadRequest, ErrResp{
+			Error:   HttpErrInvalidSecureToken,
+			Details: domain.ErrInvalidSecureToken.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		return http.StatusNotFound, ErrResp{
+			Error:   HttpErrAccountNotFound,
+			Details: domain.ErrAccountNotFound.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrPasskeysUnavailable) {
+		return http.StatusNotImplemented, ErrResp{
+			Error:   HttpErrPasskeysUnavailable,
+			Details: domain.ErrPasskeysUnavailable.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrInvalidPasskey) {
+		return http.StatusBadRequest, ErrResp{
+			Error:   HttpErrInvalidPasskey,
+			Details: domain.ErrInvalidPasskey.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrPasskeyNotFound) {
+		return http.StatusNotFound, ErrResp{
+			Error:   HttpErrPasskeyNotFound,
+			Details: domain.ErrPasskeyNotFound.Error(),
+		}
+	}
+
+	if errors.Is(err, domain.ErrPasskeyExists) {
+		return http.StatusConflict, ErrResp{
+			Error:   HttpErrPasskeyExists,
+			Details: domain.ErrPasskeyExists.Error(),
+		}
+	}
+
 	return http.StatusInternalServerError, ErrResp{
 		Error:   HttpInternalError,
 		Details: domain.ErrInternal.Error(),
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/metrics"
+	"github.com/akemoon/crowdfunding-app-auth/service/auth"
+	"github.com/akemoon/crowdfunding-app-auth/service/mfa"
+	"github.com/akemoon/crowdfunding-app-auth/service/token"
+)
+
+// @Summary Start TOTP enrollment
+// @Description Generate a TOTP secret and the otpauth URI to show as a QR code. 2FA stays off until confirmed.
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization header with access token"
+// @Success 200 {object} domain.TOTPEnrollResponse "Secret generated"
+// @Failure 401 "Unauthorized"
+// @Failure 405 "Method not allowed"
+// @Failure 409 "2FA already enabled"
+// @Failure 500 "Internal server error"
+// @Failure 501 "2FA unavailable"
+// @Router /mfa/totp/enroll [post]
+func EnrollTOTP(svc *mfa.Service, tokenSvc *token.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tc, err := authorize(r, tokenSvc)
+		if err != nil {
+			log.Printf("token service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		resp, err := svc.EnrollTOTP(r.Context(), tc.UserID)
+		if err != nil {
+			log.Printf("mfa service: %s", err)
+
+			status, errResp := mapErrToHTTP(err)
+			writeJSON(w, status, errResp)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// @Summary Confirm TOTP enrollment
+// @Description Enable 2FA with a first code from the authenticator and return one-time recovery codes
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization header with access token"
+// @Param payload body domain.TOTPConfirmRequest true "Confirmation payload"
+// @Success 200 {object} domain.RecoveryCodesResponse "2FA enabled"
+// @Failure 400 "Invalid request"
+// @Failure 401 "Unauthorized or invalid code"
+// @Failure 405 "Method not allowed"
+// @Failure 409 "Not enrolled or already enabled"
+// @Failure 500 "Internal server error"
+// @Router /mfa/totp/confirm [post]
+func ConfirmTOTP(svc *mfa.Service, tokenSvc *token.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tc, err := authorize(r, tokenSvc)
+		if err != nil {
+			log.Printf("token service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		var req domain.TOTPConfirmRequest
+
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		resp, err := svc.ConfirmTOTP(r.Context(), tc.UserID, req.Code)
+		if err != nil {
+			log.Printf("mfa service: %s", err)
+
+			status, errResp := mapErrToHTTP(err)
+			writeJSON(w, status, errResp)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// @Summary Complete sign in with 2FA
+// @Description Exchange the MFA token from /signin and a TOTP code, recovery code or passkey assertion for access/refresh tokens
+// @Accept json
+// @Produce json
+// @Param payload body domain.SignInMFARequest true "MFA payload"
+// @Success 200 {object} domain.SignInResponse "Tokens issued"
+// @Failure 400 "Invalid request or passkey response"
+// @Failure 401 "Invalid code or MFA token"
+// @Failure 403 "Password reset required, account suspended, disabled or scheduled for deletion"
+// @Failure 405 "Method not allowed"
+// @Failure 423 "Account locked"
+// @Failure 500 "Internal server error"
+// @Router /signin/mfa [post]
+func SignInMFA(svc *auth.Service, m *metrics.AuthMetrics) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req domain.SignInMFARequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		resp, err := svc.CompleteMFA(r.Context(), req)
+		if err != nil {
+			log.Printf("auth service: %s", err)
+
+			observeSignIn(m, resp, err)
+
+			status, errResp := mapErrToHTTP(err)
+			writeJSON(w, status, errResp)
+			return
+		}
+
+		observeSignIn(m, resp, nil)
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
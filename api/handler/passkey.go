@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/metrics"
+	"github.com/akemoon/crowdfunding-app-auth/service/auth"
+	"github.com/akemoon/crowdfunding-app-auth/service/mfa"
+	"github.com/akemoon/crowdfunding-app-auth/service/passkey"
+	"github.com/akemoon/crowdfunding-app-auth/service/token"
+)
+
+// @Summary Start passkey registration
+// @Description Return options for navigator.credentials.create()
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization header with access token"
+// @Success 200 {object} domain.PasskeyCreationOptions "Creation options"
+// @Failure 401 "Unauthorized"
+// @Failure 405 "Method not allowed"
+// @Failure 500 "Internal server error"
+// @Failure 501 "Passkeys unavailable"
+// @Router /passkeys/register/options [post]
+func PasskeyRegisterOptions(svc *passkey.Service, tokenSvc *token.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tc, err := authorize(r, tokenSvc)
+		if err != nil {
+			log.Printf("token service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		resp, err := svc.BeginRegistration(r.Context(), tc.UserID)
+		if err != nil {
+			log.Printf("passkey service: %s", err)
+
+			status, errResp := mapErrToHTTP(err)
+			writeJSON(w, status, errResp)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// @Summary Register passkey
+// @Description Verify the response of navigator.credentials.create() and store the passkey
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization header with access token"
+// @Param payload body domain.PasskeyRegisterRequest true "Registration payload"
+// @Success 201 {object} domain.Passkey "Passkey registered"
+// @Failure 400 "Invalid request or passkey response"
+// @Failure 401 "Unauthorized"
+// @Failure 405 "Method not allowed"
+// @Failure 409 "Passkey already registered"
+// @Failure 500 "Internal server error"
+// @Failure 501 "Passkeys unavailable"
+// @Router /passkeys/register [post]
+func RegisterPasskey(svc *passkey.Service, tokenSvc *token.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tc, err := authorize(r, tokenSvc)
+		if err != nil {
+			log.Printf("token service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		var req domain.PasskeyRegisterRequest
+
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		resp, err := svc.FinishRegistration(r.Context(), tc.UserID, req)
+		if err != nil {
+			log.Printf("passkey service: %s", err)
+
+			status, errResp := mapErrToHTTP(err)
+			writeJSON(w, status, errResp)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, resp)
+	}
+}
+
+// @Summary List passkeys
+// @Description List the passkeys registered by the user
+// @Produce json
+// @Param Authorization header string true "Authorization header with access token"
+// @Success 200 {object} domain.PasskeysResponse "Passkeys"
+// @Failure 401 "Unauthorized"
+// @Failure 405 "Method not allowed"
+// @Failure 500 "Internal server error"
+// @Router /passkeys [get]
+func ListPasskeys(svc *passkey.Service, tokenSvc *token.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tc, err := authorize(r, tokenSvc)
+		if err != nil {
+			log.Printf("token service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		passkeys, err := svc.List(r.Context(), tc.UserID)
+		if err != nil {
+			log.Printf("passkey service: %s", err)
+
+			status, errResp := mapErrToHTTP(err)
+			writeJSON(w, status, errResp)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, domain.PasskeysResponse{Passkeys: passkeys})
+	}
+}
+
+// @Summary Remove passkey
+// @Description Remove a passkey of the user
+// @Produce json
+// @Param Authorization header string true "Authorization header with access token"
+// @Param id path string true "Credential ID (base64url)"
+// @Success 204 "Passkey removed"
+// @Failure 401 "Unauthorized"
+// @Failure 404 "Passkey not found"
+// @Failure 405 "Method not allowed"
+// @Failure 500 "Internal server error"
+// @Router /passkeys/{id} [delete]
+func DeletePasskey(svc *passkey.Service, tokenSvc *token.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tc, err := authorize(r, tokenSvc)
+		if err != nil {
+			log.Printf("token service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		err = svc.Delete(r.Context(), tc.UserID, r.PathValue("id"))
+		if err != nil {
+			log.Printf("passkey service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// @Summary Start passkey sign in
+// @Description Return options for a passwordless navigator.credentials.get()
+// @Produce json
+// @Success 200 {object} domain.PasskeyRequestOptions "Request options"
+// @Failure 405 "Method not allowed"
+// @Failure 500 "Internal server error"
+// @Failure 501 "Passkeys unavailable"
+// @Router /signin/passkey/options [post]
+func PasskeySignInOptions(svc *passkey.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		resp, err := svc.BeginLogin(r.Context())
+		if err != nil {
+			log.Printf("passkey service: %s", err)
+
+			status, errResp := mapErrToHTTP(err)
+			writeJSON(w, status, errResp)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// @Summary Sign in with passkey
+// @Description Verify a passwordless assertion and return access/refresh tokens
+// @Accept json
+// @Produce json
+// @Param payload body domain.SignInPasskeyRequest true "Assertion payload"
+// @Success 200 {object} domain.SignInResponse "Tokens issued"
+// @Failure 400 "Invalid passkey response"
+// @Failure 403 "Email not verified, password reset required, account suspended, disabled or scheduled for deletion"
+// @Failure 405 "Method not allowed"
+// @Failure 500 "Internal server error"
+// @Failure 501 "Passkeys unavailable"
+// @Router /signin/passkey [post]
+func SignInPasskey(svc *auth.Service, m *metrics.AuthMetrics) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req domain.SignInPasskeyRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		resp, err := svc.SignInWithPasskey(r.Context(), req)
+		if err != nil {
+			log.Printf("auth service: %s", err)
+
+			observeSignIn(m, resp, err)
+
+			status, errResp := mapErrToHTTP(err)
+			writeJSON(w, status, errResp)
+			return
+		}
+
+		observeSignIn(m, resp, nil)
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// @Summary Start passkey second factor
+// @Description Return options for completing an MFA challenge with a passkey
+// @Accept json
+// @Produce json
+// @Param payload body domain.MFAPasskeyOptionsRequest true "MFA token"
+// @Success 200 {object} domain.PasskeyRequestOptions "Request options"
+// @Failure 401 "Invalid MFA token"
+// @Failure 404 "No passkey registered"
+// @Failure 405 "Method not allowed"
+// @Failure 500 "Internal server error"
+// @Failure 501 "Passkeys unavailable"
+// @Router /signin/mfa/passkey/options [post]
+func MFAPasskeyOptions(svc *mfa.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req domain.MFAPasskeyOptionsRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		resp, err := svc.PasskeyOptions(r.Context(), req.MFAToken)
+		if err != nil {
+			log.Printf("mfa service: %s", err)
+
+			status, errResp := mapErrToHTTP(err)
+			writeJSON(w, status, errResp)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
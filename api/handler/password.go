@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/service/password"
+	"github.com/akemoon/crowdfunding-app-auth/service/token"
+)
+
+// @Summary Forgot password
+// @Description Email a password reset link. Always accepted to avoid disclosing registered emails
+// @Accept json
+// @Produce json
+// @Param payload body domain.ForgotPasswordRequest true "Forgot password payload"
+// @Success 202 "Accepted"
+// @Failure 400 "Invalid request"
+// @Failure 405 "Method not allowed"
+// @Router /password/forgot [post]
+func ForgotPassword(svc *password.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req domain.ForgotPasswordRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		// failures are only logged: the response must not depend on
+		// whether the email is registered
+		err = svc.Forgot(r.Context(), req.Email)
+		if err != nil {
+			log.Printf("password service: %s", err)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// @Summary Change password
+// @Description Change password of the signed-in user and revoke all other sessions
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Authorization header with access token"
+// @Param payload body domain.ChangePasswordRequest true "Change password payload"
+// @Success 204 "Password changed"
+// @Failure 400 "Invalid request"
+// @Failure 401 "Unauthorized"
+// @Failure 403 "Invalid current password"
+// @Failure 405 "Method not allowed"
+// @Failure 423 "Account locked"
+// @Failure 500 "Internal server error"
+// @Router /password/change [post]
+func ChangePassword(svc *password.Service, tokenSvc *token.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tc, err := authorize(r, tokenSvc)
+		if err != nil {
+			log.Printf("token service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		var req domain.ChangePasswordRequest
+
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err = svc.Change(r.Context(), tc, req)
+		if err != nil {
+			log.Printf("password service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// @Summary Reset password
+// @Description Set a new password with a reset token and revoke all sessions
+// @Accept json
+// @Produce json
+// @Param payload body domain.ResetPasswordRequest true "Reset password payload"
+// @Success 204 "Password changed"
+// @Failure 400 "Invalid or expired token"
+// @Failure 405 "Method not allowed"
+// @Failure 500 "Internal server error"
+// @Router /password/reset [post]
+func ResetPassword(svc *password.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req domain.ResetPasswordRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err = svc.Reset(r.Context(), req)
+		if err != nil {
+			log.Printf("password service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/service/verification"
+)
+
+// @Summary Verify email
+// @Description Confirm email address with a token from the verification email
+// @Accept json
+// @Produce json
+// @Param payload body domain.VerifyEmailRequest true "Verify email payload"
+// @Success 204 "Email verified"
+// @Failure 400 "Invalid or expired token"
+// @Failure 405 "Method not allowed"
+// @Failure 500 "Internal server error"
+// @Router /email/verify [post]
+func VerifyEmail(svc *verification.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req domain.VerifyEmailRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err = svc.Verify(r.Context(), req.Token)
+		if err != nil {
+			log.Printf("verification service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// @Summary Resend verification email
+// @Description Send a new verification link. Always accepted to avoid disclosing registered emails
+// @Accept json
+// @Produce json
+// @Param payload body domain.ResendVerificationRequest true "Resend payload"
+// @Success 202 "Accepted"
+// @Failure 400 "Invalid request"
+// @Failure 405 "Method not allowed"
+// @Failure 500 "Internal server error"
+// @Router /email/verify/resend [post]
+func ResendVerification(svc *verification.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req domain.ResendVerificationRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err = svc.Resend(r.Context(), req.Email)
+		if err != nil {
+			log.Printf("verification service: %s", err)
+
+			status, resp := mapErrToHTTP(err)
+			writeJSON(w, status, resp)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/akemoon/crowdfunding-app-auth/api/handler"
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/metrics"
+	"github.com/akemoon/crowdfunding-app-auth/tool/emailnorm"
+	"github.com/akemoon/crowdfunding-app-auth/tool/ratelimit"
+	"github.com/akemoon/golib/myhttp/middleware"
+)
+
+const (
+	scopeGlobal = "global"
+	scopeIP     = "ip"
+	scopeEmail  = "email"
+
+	maxEmailBodyBytes = 1 << 20
+)
+
+type limitCheck struct {
+	scope string
+	key   string
+	limit config.Limit
+}
+
+// RateLimit returns a middleware enforcing cfg.Rules for the routes they
+// name. Limits are shared by all replicas through the limiter. If the
+// limiter fails, requests are let through.
+func RateLimit(l ratelimit.Limiter, cfg config.RateLimit, m *metrics.RateLimitMetrics) (middleware.Midddleware, error) {
+	trusted, err := parseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make(map[string]config.RateLimitRule, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		rules[rule.Pattern] = rule
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rule, ok := rules[r.Pattern]
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Checks stop at the first rejection, so the shared global
+			// bucket comes last: a single client over its own limit must
+			// not use up the budget of everyone else.
+			checks := []limitCheck{
+				{scopeIP, clientIP(r, trusted), rule.PerIP},
+			}
+			if rule.PerEmail.Requests > 0 {
+				checks = append(checks, limitCheck{scopeEmail, requestEmail(r), rule.PerEmail})
+			}
+			checks = append(checks, limitCheck{scopeGlobal, "", rule.Global})
+
+			var tightest *ratelimit.Result
+
+			for _, c := range checks {
+				if c.limit.Requests <= 0 || (c.scope != scopeGlobal && c.key == "") {
+					continue
+				}
+
+				res, err := l.Allow(r.Context(), rule.Pattern+":"+c.scope+":"+c.key, c.limit.Requests, c.limit.Window)
+				if err != nil {
+					log.Printf("rate limiter: %s", err)
+					continue
+				}
+
+				if tightest == nil || res.Remaining < tightest.Remaining {
+					tightest = &res
+				}
+
+				if !res.Allowed {
+					m.RateLimitRejectedTotal.WithLabelValues(rule.Pattern, c.scope).Inc()
+					writeRateLimitHeaders(w, res)
+					writeRateLimited(w, res)
+					return
+				}
+			}
+
+			if tightest != nil {
+				writeRateLimitHeaders(w, *tightest)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func parseTrustedProxies(cidrs []string) ([]netip.Prefix, error) {
+	trusted := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		p, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", cidr, err)
+		}
+		trusted = append(trusted, p)
+	}
+	return trusted, nil
+}
+
+// clientIP returns the address of the client. X-Forwarded-For is only
+// honored when the direct peer is a trusted proxy; the right-most address
+// that is not a trusted proxy wins.
+func clientIP(r *http.Request, trusted []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	peer, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+
+	if !isTrusted(peer, trusted) {
+		return peer.String()
+	}
+
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		if !isTrusted(addr, trusted) {
+			return addr.String()
+		}
+	}
+
+	return peer.String()
+}
+
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, p := range trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestEmail reads the "email" field of a JSON body and restores the body
+// for the next handler.
+func requestEmail(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxEmailBodyBytes))
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return ""
+	}
+
+	// Count every spelling of an address against the same account.
+	email, err := emailnorm.Normalize(payload.Email)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(payload.Email))
+	}
+
+	return email
+}
+
+func writeRateLimitHeaders(w http.ResponseWriter, res ratelimit.Result) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(res)))
+}
+
+func writeRateLimited(w http.ResponseWriter, res ratelimit.Result) {
+	w.Header().Set("Retry-After", strconv.Itoa(seconds(res)))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	_ = json.NewEncoder(w).Encode(handler.ErrResp{
+		Error:      handler.HttpErrRateLimited,
+		Details:    "too many requests",
+		RetryAfter: seconds(res),
+	})
+}
+
+func seconds(res ratelimit.Result) int {
+	return max(1, int(math.Ceil(res.ResetAfter.Seconds())))
+}
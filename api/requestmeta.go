@@ -0,0 +1,114 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/tool/randtoken"
+	"github.com/akemoon/golib/myhttp/middleware"
+	"github.com/google/uuid"
+)
+
+const (
+	requestIDHeader    = "X-Request-Id"
+	maxRequestIDLength = 128
+
+	// Browsers keep the device id in a cookie, other clients send it in
+	// the header.
+	deviceIDCookie    = "device_id"
+	deviceIDHeader    = "X-Device-Id"
+	deviceIDMaxAge    = 2 * 365 * 24 * time.Hour
+	maxDeviceIDLength = 64
+)
+
+// RequestMeta returns a middleware storing the client address, user agent,
+// request id and device id in the request context. A request id sent by a
+// proxy is kept; otherwise one is generated. It is echoed in the response.
+// A client without a device id is given a new one in a long-lived cookie.
+func RequestMeta(trustedProxies []string) (middleware.Midddleware, error) {
+	trusted, err := parseTrustedProxies(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(requestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = uuid.NewString()
+			}
+
+			w.Header().Set(requestIDHeader, requestID)
+
+			deviceID, err := deviceID(w, r)
+			if err != nil {
+				log.Printf("generate device id: %s", err)
+			}
+
+			ctx := domain.WithRequestMeta(r.Context(), domain.RequestMeta{
+				IP:        clientIP(r, trusted),
+				UserAgent: r.UserAgent(),
+				RequestID: requestID,
+				DeviceID:  deviceID,
+			})
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}, nil
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func deviceID(w http.ResponseWriter, r *http.Request) (string, error) {
+	id := r.Header.Get(deviceIDHeader)
+	if validDeviceID(id) {
+		return id, nil
+	}
+
+	cookie, err := r.Cookie(deviceIDCookie)
+	if err == nil && validDeviceID(cookie.Value) {
+		return cookie.Value, nil
+	}
+
+	id, err = randtoken.New()
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     deviceIDCookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(deviceIDMaxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return id, nil
+}
+
+func validDeviceID(id string) bool {
+	if id == "" || len(id) > maxDeviceIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -5,8 +5,18 @@ import (
 
 	"github.com/akemoon/crowdfunding-app-auth/api/handler"
 	"github.com/akemoon/crowdfunding-app-auth/metrics"
+	"github.com/akemoon/crowdfunding-app-auth/service/account"
+	"github.com/akemoon/crowdfunding-app-auth/service/audit"
 	"github.com/akemoon/crowdfunding-app-auth/service/auth"
+	"github.com/akemoon/crowdfunding-app-auth/service/device"
+	"github.com/akemoon/crowdfunding-app-auth/service/email"
+	"github.com/akemoon/crowdfunding-app-auth/service/emailotp"
+	"github.com/akemoon/crowdfunding-app-auth/service/magiclink"
+	"github.com/akemoon/crowdfunding-app-auth/service/mfa"
+	"github.com/akemoon/crowdfunding-app-auth/service/passkey"
+	"github.com/akemoon/crowdfunding-app-auth/service/password"
 	"github.com/akemoon/crowdfunding-app-auth/service/token"
+	"github.com/akemoon/crowdfunding-app-auth/service/verification"
 	"github.com/akemoon/golib/myhttp/middleware"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -24,14 +34,76 @@ func NewServer() *Server {
 	}
 }
 
+func (s *Server) Use(mws ...middleware.Midddleware) {
+	s.r.Use(mws...)
+}
+
 func (s *Server) AddAuthHandlers(svc *auth.Service, m *metrics.AuthMetrics) {
 	s.r.HandleFunc("POST /signup", handler.SignUp(svc))
 	s.r.HandleFunc("POST /signin", handler.SignIn(svc, m))
 	s.r.HandleFunc("POST /signout", handler.SignOut(svc))
 }
 
-func (s *Server) AddTokenHandlers(svc *token.Service) {
+func (s *Server) AddMFAHandlers(svc *mfa.Service, authSvc *auth.Service, tokenSvc *token.Service, m *metrics.AuthMetrics) {
+	s.r.HandleFunc("POST /signin/mfa", handler.SignInMFA(authSvc, m))
+	s.r.HandleFunc("POST /mfa/totp/enroll", handler.EnrollTOTP(svc, tokenSvc))
+	s.r.HandleFunc("POST /mfa/totp/confirm", handler.ConfirmTOTP(svc, tokenSvc))
+	s.r.HandleFunc("POST /signin/mfa/passkey/options", handler.MFAPasskeyOptions(svc))
+}
+
+func (s *Server) AddMagicLinkHandlers(svc *magiclink.Service, authSvc *auth.Service, m *metrics.AuthMetrics) {
+	s.r.HandleFunc("POST /signin/magic-link", handler.RequestMagicLink(svc))
+	s.r.HandleFunc("POST /signin/magic-link/verify", handler.VerifyMagicLink(authSvc, m))
+}
+
+func (s *Server) AddEmailOTPHandlers(svc *emailotp.Service, authSvc *auth.Service, m *metrics.AuthMetrics) {
+	s.r.HandleFunc("POST /signin/email-otp", handler.RequestEmailOTP(svc))
+	s.r.HandleFunc("POST /signin/email-otp/verify", handler.VerifyEmailOTP(authSvc, m))
+}
+
+func (s *Server) AddPasskeyHandlers(svc *passkey.Service, authSvc *auth.Service, tokenSvc *token.Service, m *metrics.AuthMetrics) {
+	s.r.HandleFunc("POST /signin/passkey/options", handler.PasskeySignInOptions(svc))
+	s.r.HandleFunc("POST /signin/passkey", handler.SignInPasskey(authSvc, m))
+	s.r.HandleFunc("POST /passkeys/register/options", handler.PasskeyRegisterOptions(svc, tokenSvc))
+	s.r.HandleFunc("POST /passkeys/register", handler.RegisterPasskey(svc, tokenSvc))
+	s.r.HandleFunc("GET /passkeys", handler.ListPasskeys(svc, tokenSvc))
+	s.r.HandleFunc("DELETE /passkeys/{id}", handler.DeletePasskey(svc, tokenSvc))
+}
+
+func (s *Server) AddTokenHandlers(svc *token.Service, authSvc *auth.Service) {
 	s.r.HandleFunc("GET /check", handler.CheckAccessToken(svc))
+	s.r.HandleFunc("POST /refresh", handler.Refresh(authSvc))
+}
+
+func (s *Server) AddVerificationHandlers(svc *verification.Service) {
+	s.r.HandleFunc("POST /email/verify", handler.VerifyEmail(svc))
+	s.r.HandleFunc("POST /email/verify/resend", handler.ResendVerification(svc))
+}
+
+func (s *Server) AddEmailHandlers(svc *email.Service, tokenSvc *token.Service) {
+	s.r.HandleFunc("POST /email/change", handler.ChangeEmail(svc, tokenSvc))
+	s.r.HandleFunc("POST /email/change/confirm", handler.ConfirmEmailChange(svc))
+	s.r.HandleFunc("POST /email/change/revert", handler.RevertEmailChange(svc))
+}
+
+func (s *Server) AddAccountHandlers(svc *account.Service, auditSvc *audit.Service, deviceSvc *device.Service, tokenSvc *token.Service) {
+	s.r.HandleFunc("DELETE /account", handler.DeleteAccount(svc, tokenSvc))
+	s.r.HandleFunc("GET /account/login-history", handler.LoginHistory(auditSvc, tokenSvc))
+	s.r.HandleFunc("POST /account/secure", handler.SecureAccount(deviceSvc))
+}
+
+func (s *Server) AddPasswordHandlers(svc *password.Service, tokenSvc *token.Service) {
+	s.r.HandleFunc("POST /password/change", handler.ChangePassword(svc, tokenSvc))
+	s.r.HandleFunc("POST /password/forgot", handler.ForgotPassword(svc))
+	s.r.HandleFunc("POST /password/reset", handler.ResetPassword(svc))
+}
+
+// AddAdminHandlers registers admin endpoints guarded by adminKey.
+func (s *Server) AddAdminHandlers(accountSvc *account.Service, auditSvc *audit.Service, adminKey string) {
+	s.r.HandleFunc("POST /admin/accounts/{userID}/unlock", handler.UnlockAccount(accountSvc, adminKey))
+	s.r.HandleFunc("POST /admin/accounts/{userID}/suspend", handler.SuspendAccount(accountSvc, adminKey))
+	s.r.HandleFunc("POST /admin/accounts/{userID}/reinstate", handler.ReinstateAccount(accountSvc, adminKey))
+	s.r.HandleFunc("GET /admin/auth-events", handler.ListAuthEvents(auditSvc, adminKey))
 }
 
 func (s *Server) AddSwaggerUI() {
@@ -44,6 +116,10 @@ func (s *Server) AddMetrics() {
 	s.r.Handle("/metrics", promhttp.Handler())
 }
 
+func (s *Server) Handler() http.Handler {
+	return s.r.Handler()
+}
+
 func (s *Server) ListenAndServe(addr string) error {
 	return http.ListenAndServe(addr, s.r.Handler())
 }
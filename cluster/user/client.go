@@ -1,7 +1,14 @@
 package user
 
-import "context"
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
 
 type Client interface {
 	CreateUser(ctx context.Context, in CreateUserReq) error
+	// DeleteUser removes the user's profile. Deleting a user that does not
+	// exist succeeds, so the call can be retried.
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/akemoon/crowdfunding-app-auth/cluster/user"
 	"github.com/akemoon/crowdfunding-app-auth/domain"
 	"github.com/go-resty/resty/v2"
+	"github.com/google/uuid"
 )
 
 type Client struct {
@@ -40,3 +41,21 @@ func (c *Client) CreateUser(ctx context.Context, in user.CreateUserReq) error {
 
 	return nil
 }
+
+func (c *Client) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetPathParam("userID", userID.String()).
+		Delete("/user/{userID}")
+
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return fmt.Errorf("%w: unexpected status code: %d", domain.ErrInternal, resp.StatusCode())
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	userClient "github.com/akemoon/crowdfunding-app-auth/cluster/user/resty"
+	"github.com/akemoon/crowdfunding-app-auth/repo/creds/postgres"
+	"github.com/akemoon/crowdfunding-app-auth/tool/credimport"
+	"github.com/akemoon/crowdfunding-app-auth/tool/emailaudit"
+)
+
+// runCommand runs a maintenance subcommand instead of the server.
+func runCommand(ctx context.Context, name string, args []string) error {
+	switch name {
+	case "email-conflicts":
+		return runEmailConflicts(ctx, args)
+	case "import-creds":
+		return runImportCreds(ctx, args)
+	default:
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
+
+// runEmailConflicts applies pending migrations and reports accounts whose
+// emails collide once normalized. Conflicts are reported, never fatal: they
+// need a manual merge, after which a new run clears their flag.
+func runEmailConflicts(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("email-conflicts", flag.ContinueOnError)
+	apply := fs.Bool("apply", false, "flag conflicts and rewrite emails to their normalized form")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	pg, err := initPostgres(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := pg.Close(); err != nil {
+			log.Printf("close db err: %s", err)
+		}
+	}()
+
+	report, err := emailaudit.Run(ctx, postgres.NewCredsRepo(pg), *apply)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	err = enc.Encode(report)
+	if err != nil {
+		return err
+	}
+
+	if len(report.Conflicts) > 0 {
+		log.Printf("%d emails are shared by several accounts and need a manual merge", len(report.Conflicts))
+	}
+
+	if len(report.Unreachable) > 0 && !*apply {
+		log.Printf("%d accounts cannot sign in until their emails are normalized, run with -apply", len(report.Unreachable))
+	}
+
+	return nil
+}
+
+// runImportCreds creates accounts from a CSV or JSONL file. One result per
+// row is appended to the report file and the number of processed rows is
+// kept in the progress file, so running the same command again continues
+// where an interrupted import stopped.
+func runImportCreds(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("import-creds", flag.ContinueOnError)
+	format := fs.String("format", "", "input format: csv or jsonl (default: from the file extension)")
+	batchSize := fs.Int("batch", 500, "rows inserted per statement")
+	verified := fs.Bool("verified", false, "mark imported emails as verified")
+	reportPath := fs.String("report", "", "per-row report file (default: <file>.report.jsonl)")
+	progressPath := fs.String("progress", "", "progress file (default: <file>.progress)")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: import-creds [flags] <file>")
+	}
+
+	path := fs.Arg(0)
+	if *reportPath == "" {
+		*reportPath = path + ".report.jsonl"
+	}
+	if *progressPath == "" {
+		*progressPath = path + ".progress"
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	skip, err := readProgress(*progressPath)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var rows credimport.Reader
+	switch *format {
+	case "csv":
+		rows, err = credimport.NewCSVReader(in)
+		if err != nil {
+			return err
+		}
+	case "jsonl", "ndjson":
+		rows = credimport.NewJSONLReader(in)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	userServiceURL := strings.TrimSpace(os.Getenv(envUserServiceURL))
+	if userServiceURL == "" {
+		return fmt.Errorf("env %s is empty", envUserServiceURL)
+	}
+
+	passwordHasher, err := initHasher()
+	if err != nil {
+		return err
+	}
+
+	pg, err := initPostgres(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := pg.Close(); err != nil {
+			log.Printf("close db err: %s", err)
+		}
+	}()
+
+	out, err := os.OpenFile(*reportPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	reportEnc := json.NewEncoder(out)
+
+	importer := credimport.NewImporter(postgres.NewCredsRepo(pg), userClient.NewClient(userServiceURL), passwordHasher, credimport.Config{
+		BatchSize: *batchSize,
+		Verified:  *verified,
+		Skip:      skip,
+	})
+
+	if skip > 0 {
+		log.Printf("continuing after row %d", skip)
+	}
+
+	summary, runErr := importer.Run(ctx, rows,
+		func(res credimport.Result) error {
+			return reportEnc.Encode(res)
+		},
+		func(processed int) error {
+			return writeProgress(*progressPath, processed)
+		},
+	)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	err = enc.Encode(summary)
+	if err != nil {
+		return err
+	}
+
+	if runErr != nil {
+		return fmt.Errorf("stopped after row %d, run again to continue: %w", summary.Processed, runErr)
+	}
+
+	return nil
+}
+
+type importProgress struct {
+	Processed int `json:"processed"`
+}
+
+func readProgress(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var p importProgress
+	err = json.Unmarshal(data, &p)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	return p.Processed, nil
+}
+
+// writeProgress replaces the progress file atomically, so a crash never
+// leaves it half written.
+func writeProgress(path string, processed int) error {
+	data, err := json.Marshal(importProgress{Processed: processed})
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+
+	err = os.WriteFile(tmp, data, 0o600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 type Config struct {
 	Port           int      `json:"port"`
 	JwtSecret      string   `json:"jwt_secret"`
@@ -21,3 +23,173 @@ type Redis struct {
 type Cluster struct {
 	UserService string `json:"user_service_url"`
 }
+
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+type EmailVerificationPolicy string
+
+const (
+	// EmailVerificationOff lets unverified users sign in without limits.
+	EmailVerificationOff EmailVerificationPolicy = "off"
+	// EmailVerificationBlock rejects sign-in until the email is verified.
+	EmailVerificationBlock EmailVerificationPolicy = "block"
+	// EmailVerificationRestrict issues tokens limited to UnverifiedScopes.
+	EmailVerificationRestrict EmailVerificationPolicy = "restrict"
+)
+
+type SignUpConflictPolicy string
+
+const (
+	// SignUpConflictReveal answers a sign-up with a registered email with
+	// email_exists.
+	SignUpConflictReveal SignUpConflictPolicy = "reveal"
+	// SignUpConflictNotify answers it like a successful sign-up and emails
+	// the owner of the existing account instead, so the endpoint cannot be
+	// used to probe emails.
+	SignUpConflictNotify SignUpConflictPolicy = "notify"
+)
+
+type SignUp struct {
+	ConflictPolicy SignUpConflictPolicy
+	// PasswordResetURL is mentioned to owners notified of a sign-up with
+	// their email.
+	PasswordResetURL string
+}
+
+type EmailVerification struct {
+	Policy           EmailVerificationPolicy
+	UnverifiedScopes []string
+	LinkURL          string
+	TokenTTL         time.Duration
+}
+
+type PasswordReset struct {
+	LinkURL  string
+	TokenTTL time.Duration
+}
+
+type EmailChange struct {
+	ConfirmURL string
+	RevertURL  string
+	ConfirmTTL time.Duration
+	RevertTTL  time.Duration
+}
+
+type PasswordPolicy struct {
+	MinLength      int
+	MaxLength      int
+	MaxBytes       int
+	MinEntropyBits float64
+	DenylistPath   string
+}
+
+type Argon2 struct {
+	// Memory is in KiB.
+	Memory      uint32
+	Time        uint32
+	Parallelism uint8
+}
+
+type Pepper struct {
+	// Keys maps key versions to HMAC keys. Keys of old versions stay
+	// configured until no hash uses them.
+	Keys map[int][]byte
+	// Current is the version new hashes use; 0 means the highest.
+	Current int
+}
+
+type Lockout struct {
+	// Threshold is the number of consecutive failures that lock an account.
+	Threshold     int
+	BaseDuration  time.Duration
+	MaxDuration   time.Duration
+	FailureWindow time.Duration
+}
+
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// RateLimitRule limits one route. Zero limits are not enforced.
+type RateLimitRule struct {
+	// Pattern is the route pattern as registered, e.g. "POST /signin".
+	Pattern  string
+	PerIP    Limit
+	PerEmail Limit
+	Global   Limit
+}
+
+type RateLimit struct {
+	// TrustedProxies are CIDRs allowed to set X-Forwarded-For.
+	TrustedProxies []string
+	Rules          []RateLimitRule
+}
+
+type MFA struct {
+	// Issuer is shown next to the account in authenticator apps.
+	Issuer string
+	// EncryptionKey encrypts TOTP secrets at rest. MFA enrollment is
+	// unavailable without it.
+	EncryptionKey []byte
+	ChallengeTTL  time.Duration
+	RecoveryCodes int
+}
+
+type WebAuthn struct {
+	// RPID is the relying party id, usually the registrable domain. Passkeys
+	// are unavailable without it.
+	RPID   string
+	RPName string
+	// Origins are the web and app origins allowed to run ceremonies.
+	Origins      []string
+	ChallengeTTL time.Duration
+}
+
+type MagicLink struct {
+	LinkURL  string
+	TokenTTL time.Duration
+}
+
+type EmailOTP struct {
+	CodeTTL time.Duration
+	// MaxAttempts is the number of wrong guesses that invalidate a code.
+	MaxAttempts int
+}
+
+type AccountDeletion struct {
+	// GracePeriod is how long data is kept after a deletion request.
+	GracePeriod time.Duration
+	// Anonymize keeps an anonymized credentials row instead of deleting it.
+	Anonymize    bool
+	PollInterval time.Duration
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
+}
+
+type DeviceNotice struct {
+	// SecureURL is the "secure my account" link of new-device emails; the
+	// token is appended to it.
+	SecureURL      string
+	SecureTokenTTL time.Duration
+	// IgnoreNewNetworks only notifies about new devices, not about known
+	// devices on a new network.
+	IgnoreNewNetworks bool
+}
+
+type Audit struct {
+	// BufferSize is the number of events queued for writing. Events
+	// recorded while the queue is full are dropped.
+	BufferSize    int
+	BatchSize     int
+	FlushInterval time.Duration
+	// Retention is how long events, and so the login history, are kept.
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
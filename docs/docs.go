@@ -15,16 +15,69 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/check": {
-            "get": {
-                "description": "Validate access token from Authorization header",
+        "/account": {
+            "delete": {
+                "description": "Schedule the account for deletion and sign out of every session. The data is purged after a grace period.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Check access token",
+                "summary": "Delete account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization header with access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Password confirmation",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.DeleteAccountRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/domain.DeleteAccountResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Invalid current password or deletion already requested"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "423": {
+                        "description": "Account locked"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/account/login-history": {
+            "get": {
+                "description": "List recent successful and failed sign-ins to the account, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Login history",
                 "parameters": [
                     {
                         "type": "string",
@@ -32,18 +85,30 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "nextCursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Access token is valid",
-                        "headers": {
-                            "X-User-Id": {
-                                "type": "string",
-                                "description": "Authenticated user UUID"
-                            }
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.LoginHistoryResponse"
                         }
                     },
+                    "400": {
+                        "description": "Invalid query"
+                    },
                     "401": {
                         "description": "Unauthorized"
                     },
@@ -56,74 +121,308 @@ const docTemplate = `{
                 }
             }
         },
-        "/signin": {
+        "/account/secure": {
             "post": {
-                "description": "Authenticate and return access/refresh tokens",
+                "description": "Act on the link of a new-device email: revoke every session and passkey, refuse sign-in until the password is reset, and email a reset link",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Sign in",
+                "summary": "Secure account",
                 "parameters": [
                     {
-                        "description": "Sign in payload",
+                        "description": "Token from the new-device email",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/domain.SignInRequest"
+                            "$ref": "#/definitions/domain.SecureAccountRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Tokens issued",
-                        "schema": {
-                            "$ref": "#/definitions/domain.SignInResponse"
-                        }
+                    "204": {
+                        "description": "Account secured"
                     },
                     "400": {
-                        "description": "Invalid request"
+                        "description": "Invalid or expired token"
                     },
                     "405": {
                         "description": "Method not allowed"
                     },
+                    "429": {
+                        "description": "Too many requests"
+                    },
                     "500": {
                         "description": "Internal server error"
                     }
                 }
             }
         },
-        "/signout": {
+        "/admin/accounts/{userID}/reinstate": {
             "post": {
-                "description": "Revoke refresh token",
+                "description": "Lift a suspension or disabling immediately",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Reinstate account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Reinstated"
+                    },
+                    "400": {
+                        "description": "Invalid user id"
+                    },
+                    "401": {
+                        "description": "Invalid admin key"
+                    },
+                    "404": {
+                        "description": "Account not found"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/admin/accounts/{userID}/suspend": {
+            "post": {
+                "description": "Suspend or disable an account, optionally until a given time, and revoke its sessions and access tokens",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Sign out",
+                "summary": "Suspend account",
                 "parameters": [
                     {
-                        "description": "Sign out payload",
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Status, reason and optional expiry",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/domain.SignOutRequest"
+                            "$ref": "#/definitions/domain.SetAccountStatusRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Signed out"
+                    "204": {
+                        "description": "Suspended"
                     },
                     "400": {
                         "description": "Invalid request"
                     },
+                    "401": {
+                        "description": "Invalid admin key"
+                    },
+                    "404": {
+                        "description": "Account not found"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/admin/accounts/{userID}/unlock": {
+            "post": {
+                "description": "Lift a sign-in lockout immediately",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Unlock account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Unlocked"
+                    },
+                    "400": {
+                        "description": "Invalid user id"
+                    },
+                    "401": {
+                        "description": "Invalid admin key"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/admin/auth-events": {
+            "get": {
+                "description": "Query the authentication audit log, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List auth events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "userID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Event types, e.g. sign_in_failed",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Email of attempts that named no account",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client IP",
+                        "name": "ip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Earliest time, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Latest time (exclusive), RFC 3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "nextCursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.AuthEventsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query"
+                    },
+                    "401": {
+                        "description": "Invalid admin key"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/check": {
+            "get": {
+                "description": "Validate access token from Authorization header",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Check access token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization header with access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Access token is valid",
+                        "headers": {
+                            "X-Token-Restricted": {
+                                "type": "string",
+                                "description": "true if the token is limited to X-Token-Scopes"
+                            },
+                            "X-Token-Scopes": {
+                                "type": "string",
+                                "description": "Comma separated scopes of a restricted token"
+                            },
+                            "X-User-Id": {
+                                "type": "string",
+                                "description": "Authenticated user UUID"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
                     "405": {
                         "description": "Method not allowed"
                     },
@@ -133,51 +432,1671 @@ const docTemplate = `{
                 }
             }
         },
-        "/signup": {
+        "/email/change": {
             "post": {
-                "description": "Create credentials and user profile",
+                "description": "Send a confirmation link to the new email address",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Sign up",
+                "summary": "Request email change",
                 "parameters": [
                     {
-                        "description": "Sign up payload",
+                        "type": "string",
+                        "description": "Authorization header with access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Change email payload",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/domain.SignUpRequest"
+                            "$ref": "#/definitions/domain.ChangeEmailRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "User created"
+                    "202": {
+                        "description": "Confirmation sent"
                     },
                     "400": {
                         "description": "Invalid request"
                     },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Invalid current password"
+                    },
                     "405": {
                         "description": "Method not allowed"
                     },
                     "409": {
-                        "description": "Conflict"
+                        "description": "Email already exists"
+                    },
+                    "423": {
+                        "description": "Account locked"
                     },
                     "500": {
                         "description": "Internal server error"
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "domain.SignInRequest": {
-            "type": "object",
-            "properties": {
+        },
+        "/email/change/confirm": {
+            "post": {
+                "description": "Switch to the new email address with a token from the confirmation email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Confirm email change",
+                "parameters": [
+                    {
+                        "description": "Confirmation payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.EmailChangeTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Email changed"
+                    },
+                    "400": {
+                        "description": "Invalid or expired token"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "409": {
+                        "description": "Email already exists"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/email/change/revert": {
+            "post": {
+                "description": "Restore the previous email address, revoke all sessions and refuse sign-in until the password is reset",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Revert email change",
+                "parameters": [
+                    {
+                        "description": "Revert payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.EmailChangeTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Email restored"
+                    },
+                    "400": {
+                        "description": "Invalid or expired token"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "409": {
+                        "description": "Email already exists"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/email/verify": {
+            "post": {
+                "description": "Confirm email address with a token from the verification email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Verify email",
+                "parameters": [
+                    {
+                        "description": "Verify email payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.VerifyEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Email verified"
+                    },
+                    "400": {
+                        "description": "Invalid or expired token"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/email/verify/resend": {
+            "post": {
+                "description": "Send a new verification link. Always accepted to avoid disclosing registered emails",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Resend verification email",
+                "parameters": [
+                    {
+                        "description": "Resend payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.ResendVerificationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted"
+                    },
+                    "400": {
+                        "description": "Invalid request"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/mfa/totp/confirm": {
+            "post": {
+                "description": "Enable 2FA with a first code from the authenticator and return one-time recovery codes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Confirm TOTP enrollment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization header with access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Confirmation payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.TOTPConfirmRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "2FA enabled",
+                        "schema": {
+                            "$ref": "#/definitions/domain.RecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request"
+                    },
+                    "401": {
+                        "description": "Unauthorized or invalid code"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "409": {
+                        "description": "Not enrolled or already enabled"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/mfa/totp/enroll": {
+            "post": {
+                "description": "Generate a TOTP secret and the otpauth URI to show as a QR code. 2FA stays off until confirmed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Start TOTP enrollment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization header with access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Secret generated",
+                        "schema": {
+                            "$ref": "#/definitions/domain.TOTPEnrollResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "409": {
+                        "description": "2FA already enabled"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    },
+                    "501": {
+                        "description": "2FA unavailable"
+                    }
+                }
+            }
+        },
+        "/passkeys": {
+            "get": {
+                "description": "List the passkeys registered by the user",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List passkeys",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization header with access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Passkeys",
+                        "schema": {
+                            "$ref": "#/definitions/domain.PasskeysResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/passkeys/register": {
+            "post": {
+                "description": "Verify the response of navigator.credentials.create() and store the passkey",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Register passkey",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization header with access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Registration payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.PasskeyRegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Passkey registered",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Passkey"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or passkey response"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "409": {
+                        "description": "Passkey already registered"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    },
+                    "501": {
+                        "description": "Passkeys unavailable"
+                    }
+                }
+            }
+        },
+        "/passkeys/register/options": {
+            "post": {
+                "description": "Return options for navigator.credentials.create()",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Start passkey registration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization header with access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Creation options",
+                        "schema": {
+                            "$ref": "#/definitions/domain.PasskeyCreationOptions"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    },
+                    "501": {
+                        "description": "Passkeys unavailable"
+                    }
+                }
+            }
+        },
+        "/passkeys/{id}": {
+            "delete": {
+                "description": "Remove a passkey of the user",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Remove passkey",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization header with access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Credential ID (base64url)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Passkey removed"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Passkey not found"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/password/change": {
+            "post": {
+                "description": "Change password of the signed-in user and revoke all other sessions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Change password",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization header with access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Change password payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Password changed"
+                    },
+                    "400": {
+                        "description": "Invalid request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Invalid current password"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "423": {
+                        "description": "Account locked"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/password/forgot": {
+            "post": {
+                "description": "Email a password reset link. Always accepted to avoid disclosing registered emails",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Forgot password",
+                "parameters": [
+                    {
+                        "description": "Forgot password payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.ForgotPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted"
+                    },
+                    "400": {
+                        "description": "Invalid request"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    }
+                }
+            }
+        },
+        "/password/reset": {
+            "post": {
+                "description": "Set a new password with a reset token and revoke all sessions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Reset password",
+                "parameters": [
+                    {
+                        "description": "Reset password payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Password changed"
+                    },
+                    "400": {
+                        "description": "Invalid or expired token"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for a new access/refresh token pair",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Refresh tokens",
+                "parameters": [
+                    {
+                        "description": "Refresh payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tokens issued",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SignInResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request"
+                    },
+                    "401": {
+                        "description": "Invalid refresh token"
+                    },
+                    "403": {
+                        "description": "Password reset required, email not verified, account suspended, disabled or scheduled for deletion"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/signin": {
+            "post": {
+                "description": "Authenticate and return access/refresh tokens, or an MFA token to complete at /signin/mfa when 2FA is enabled",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Sign in",
+                "parameters": [
+                    {
+                        "description": "Sign in payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.SignInRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tokens issued",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SignInResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request"
+                    },
+                    "401": {
+                        "description": "Invalid email or password"
+                    },
+                    "403": {
+                        "description": "Email not verified, password reset required, account suspended, disabled or scheduled for deletion"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "423": {
+                        "description": "Account locked"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/signin/email-otp": {
+            "post": {
+                "description": "Email a 6-digit sign-in code. The response is the same whether or not the account exists.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Request email sign-in code",
+                "parameters": [
+                    {
+                        "description": "Email payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.EmailOTPRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Code sent if the account exists"
+                    },
+                    "400": {
+                        "description": "Invalid request"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "429": {
+                        "description": "Too many requests"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/signin/email-otp/verify": {
+            "post": {
+                "description": "Exchange an emailed code for access/refresh tokens, or an MFA token when 2FA is enabled",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Sign in with email code",
+                "parameters": [
+                    {
+                        "description": "Code payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.EmailOTPVerifyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tokens issued",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SignInResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request"
+                    },
+                    "401": {
+                        "description": "Invalid or expired code"
+                    },
+                    "403": {
+                        "description": "Email not verified, password reset required, account suspended, disabled or scheduled for deletion"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/signin/magic-link": {
+            "post": {
+                "description": "Email a single-use sign-in link. The response is the same whether or not the account exists. The link only works together with the returned nonce, which is also set as a cookie.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Request magic link",
+                "parameters": [
+                    {
+                        "description": "Magic link payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.MagicLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Link sent if the account exists",
+                        "schema": {
+                            "$ref": "#/definitions/domain.MagicLinkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "429": {
+                        "description": "Too many requests"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/signin/magic-link/verify": {
+            "post": {
+                "description": "Exchange a magic link token for access/refresh tokens, or an MFA token when 2FA is enabled. The nonce is taken from the body or the cookie set by /signin/magic-link.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Sign in with magic link",
+                "parameters": [
+                    {
+                        "description": "Magic link token",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.MagicLinkVerifyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tokens issued",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SignInResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid or expired link"
+                    },
+                    "403": {
+                        "description": "Email not verified, password reset required, account suspended, disabled or scheduled for deletion"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/signin/mfa": {
+            "post": {
+                "description": "Exchange the MFA token from /signin and a TOTP code, recovery code or passkey assertion for access/refresh tokens",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Complete sign in with 2FA",
+                "parameters": [
+                    {
+                        "description": "MFA payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.SignInMFARequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tokens issued",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SignInResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or passkey response"
+                    },
+                    "401": {
+                        "description": "Invalid code or MFA token"
+                    },
+                    "403": {
+                        "description": "Password reset required, account suspended, disabled or scheduled for deletion"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "423": {
+                        "description": "Account locked"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/signin/mfa/passkey/options": {
+            "post": {
+                "description": "Return options for completing an MFA challenge with a passkey",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Start passkey second factor",
+                "parameters": [
+                    {
+                        "description": "MFA token",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.MFAPasskeyOptionsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Request options",
+                        "schema": {
+                            "$ref": "#/definitions/domain.PasskeyRequestOptions"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid MFA token"
+                    },
+                    "404": {
+                        "description": "No passkey registered"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    },
+                    "501": {
+                        "description": "Passkeys unavailable"
+                    }
+                }
+            }
+        },
+        "/signin/passkey": {
+            "post": {
+                "description": "Verify a passwordless assertion and return access/refresh tokens",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Sign in with passkey",
+                "parameters": [
+                    {
+                        "description": "Assertion payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.SignInPasskeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tokens issued",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SignInResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid passkey response"
+                    },
+                    "403": {
+                        "description": "Email not verified, password reset required, account suspended, disabled or scheduled for deletion"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    },
+                    "501": {
+                        "description": "Passkeys unavailable"
+                    }
+                }
+            }
+        },
+        "/signin/passkey/options": {
+            "post": {
+                "description": "Return options for a passwordless navigator.credentials.get()",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Start passkey sign in",
+                "responses": {
+                    "200": {
+                        "description": "Request options",
+                        "schema": {
+                            "$ref": "#/definitions/domain.PasskeyRequestOptions"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    },
+                    "501": {
+                        "description": "Passkeys unavailable"
+                    }
+                }
+            }
+        },
+        "/signout": {
+            "post": {
+                "description": "Revoke refresh token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Sign out",
+                "parameters": [
+                    {
+                        "description": "Sign out payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.SignOutRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Signed out"
+                    },
+                    "400": {
+                        "description": "Invalid request"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/signup": {
+            "post": {
+                "description": "Create credentials and user profile. With the notify conflict policy a registered email is answered like a new account and its owner is emailed instead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Sign up",
+                "parameters": [
+                    {
+                        "description": "Sign up payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.SignUpRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User created"
+                    },
+                    "400": {
+                        "description": "Invalid request"
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "409": {
+                        "description": "Username taken, or email registered with the reveal conflict policy"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "domain.AccountStatus": {
+            "type": "string",
+            "enum": [
+                "active",
+                "suspended",
+                "disabled"
+            ],
+            "x-enum-varnames": [
+                "AccountStatusActive",
+                "AccountStatusSuspended",
+                "AccountStatusDisabled"
+            ]
+        },
+        "domain.AuthEvent": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "description": "Email is kept for failures that cannot be tied to an account.",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "occurredAt": {
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "Reason says why an attempt failed or why an admin acted.",
+                    "type": "string"
+                },
+                "requestID": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/domain.AuthEventType"
+                },
+                "userAgent": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.AuthEventType": {
+            "type": "string",
+            "enum": [
+                "sign_up",
+                "sign_up_conflict",
+                "sign_in",
+                "sign_in_failed",
+                "mfa_challenge",
+                "sign_out",
+                "token_refresh",
+                "token_refresh_failed",
+                "password_change",
+                "password_reset",
+                "admin_unlock",
+                "admin_suspend",
+                "admin_reinstate",
+                "new_device",
+                "account_secured"
+            ],
+            "x-enum-varnames": [
+                "AuthEventSignUp",
+                "AuthEventSignUpConflict",
+                "AuthEventSignIn",
+                "AuthEventSignInFailed",
+                "AuthEventMFAChallenge",
+                "AuthEventSignOut",
+                "AuthEventTokenRefresh",
+                "AuthEventTokenRefreshFailed",
+                "AuthEventPasswordChange",
+                "AuthEventPasswordReset",
+                "AuthEventAdminUnlock",
+                "AuthEventAdminSuspend",
+                "AuthEventAdminReinstate",
+                "AuthEventNewDevice",
+                "AuthEventAccountSecured"
+            ]
+        },
+        "domain.AuthEventsResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.AuthEvent"
+                    }
+                },
+                "nextCursor": {
+                    "description": "NextCursor is empty on the last page.",
+                    "type": "string"
+                }
+            }
+        },
+        "domain.ChangeEmailRequest": {
+            "type": "object",
+            "properties": {
+                "newEmail": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.ChangePasswordRequest": {
+            "type": "object",
+            "properties": {
+                "currentPassword": {
+                    "type": "string"
+                },
+                "newPassword": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.DeleteAccountRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.DeleteAccountResponse": {
+            "type": "object",
+            "properties": {
+                "purgeAfter": {
+                    "description": "PurgeAfter is when the account data is deleted for good.",
+                    "type": "string"
+                }
+            }
+        },
+        "domain.EmailChangeTokenRequest": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.EmailOTPRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.EmailOTPVerifyRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.ForgotPasswordRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.LoginHistoryEntry": {
+            "type": "object",
+            "properties": {
+                "device": {
+                    "description": "Device is a coarse description of the user agent, e.g. \"Firefox on\nWindows\".",
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "occurredAt": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "domain.LoginHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.LoginHistoryEntry"
+                    }
+                },
+                "nextCursor": {
+                    "description": "NextCursor is empty on the last page.",
+                    "type": "string"
+                }
+            }
+        },
+        "domain.MFAPasskeyOptionsRequest": {
+            "type": "object",
+            "properties": {
+                "mfaToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.MagicLinkRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.MagicLinkResponse": {
+            "type": "object",
+            "properties": {
+                "nonce": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.MagicLinkVerifyRequest": {
+            "type": "object",
+            "properties": {
+                "nonce": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.Passkey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID is the base64url encoded credential id.",
+                    "type": "string"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.PasskeyAssertion": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "response": {
+                    "type": "object",
+                    "properties": {
+                        "authenticatorData": {
+                            "type": "string"
+                        },
+                        "clientDataJSON": {
+                            "type": "string"
+                        },
+                        "signature": {
+                            "type": "string"
+                        },
+                        "userHandle": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.PasskeyAttestation": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "response": {
+                    "type": "object",
+                    "properties": {
+                        "attestationObject": {
+                            "type": "string"
+                        },
+                        "clientDataJSON": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.PasskeyAuthenticatorSelection": {
+            "type": "object",
+            "properties": {
+                "residentKey": {
+                    "type": "string"
+                },
+                "userVerification": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.PasskeyCreationOptions": {
+            "type": "object",
+            "properties": {
+                "attestation": {
+                    "type": "string"
+                },
+                "authenticatorSelection": {
+                    "$ref": "#/definitions/domain.PasskeyAuthenticatorSelection"
+                },
+                "challenge": {
+                    "type": "string"
+                },
+                "excludeCredentials": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.PasskeyDescriptor"
+                    }
+                },
+                "pubKeyCredParams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.PasskeyCredParam"
+                    }
+                },
+                "rp": {
+                    "$ref": "#/definitions/domain.PasskeyRelyingParty"
+                },
+                "timeout": {
+                    "type": "integer"
+                },
+                "user": {
+                    "$ref": "#/definitions/domain.PasskeyUser"
+                }
+            }
+        },
+        "domain.PasskeyCredParam": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.PasskeyDescriptor": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.PasskeyRegisterRequest": {
+            "type": "object",
+            "properties": {
+                "credential": {
+                    "$ref": "#/definitions/domain.PasskeyAttestation"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.PasskeyRelyingParty": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.PasskeyRequestOptions": {
+            "type": "object",
+            "properties": {
+                "allowCredentials": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.PasskeyDescriptor"
+                    }
+                },
+                "challenge": {
+                    "type": "string"
+                },
+                "rpId": {
+                    "type": "string"
+                },
+                "timeout": {
+                    "type": "integer"
+                },
+                "userVerification": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.PasskeyUser": {
+            "type": "object",
+            "properties": {
+                "displayName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.PasskeysResponse": {
+            "type": "object",
+            "properties": {
+                "passkeys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Passkey"
+                    }
+                }
+            }
+        },
+        "domain.RecoveryCodesResponse": {
+            "type": "object",
+            "properties": {
+                "recoveryCodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "domain.RefreshRequest": {
+            "type": "object",
+            "properties": {
+                "refreshToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.ResendVerificationRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.ResetPasswordRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.SecureAccountRequest": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.SetAccountStatusRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status is \"suspended\" or \"disabled\".",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.AccountStatus"
+                        }
+                    ]
+                },
+                "until": {
+                    "description": "Until lifts the restriction automatically; omit it to keep the\naccount restricted until it is reinstated.",
+                    "type": "string"
+                }
+            }
+        },
+        "domain.SignInMFARequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "mfaToken": {
+                    "type": "string"
+                },
+                "passkey": {
+                    "$ref": "#/definitions/domain.PasskeyAssertion"
+                },
+                "recoveryCode": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.SignInPasskeyRequest": {
+            "type": "object",
+            "properties": {
+                "credential": {
+                    "$ref": "#/definitions/domain.PasskeyAssertion"
+                }
+            }
+        },
+        "domain.SignInRequest": {
+            "type": "object",
+            "properties": {
                 "email": {
                     "type": "string"
                 },
@@ -192,6 +2111,19 @@ const docTemplate = `{
                 "accessToken": {
                     "type": "string"
                 },
+                "mfaMethods": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "mfaRequired": {
+                    "description": "MFARequired is set instead of tokens when the user has 2FA enabled.\nMFAToken is then exchanged for tokens at /signin/mfa.",
+                    "type": "boolean"
+                },
+                "mfaToken": {
+                    "type": "string"
+                },
                 "refreshToken": {
                     "type": "string"
                 }
@@ -218,6 +2150,34 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "domain.TOTPConfirmRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.TOTPEnrollResponse": {
+            "type": "object",
+            "properties": {
+                "otpauthUri": {
+                    "description": "OTPAuthURI is the payload to render as a QR code.",
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.VerifyEmailRequest": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+type DeleteAccountResponse struct {
+	// PurgeAfter is when the account data is deleted for good.
+	PurgeAfter time.Time `json:"purgeAfter"`
+}
+
+type AccountDeletion struct {
+	UserID        uuid.UUID
+	RequestedAt   time.Time
+	PurgeAfter    time.Time
+	Attempts      int
+	UserDeletedAt *time.Time
+}
+
+type AccountStatus string
+
+const (
+	AccountStatusActive    AccountStatus = "active"
+	AccountStatusSuspended AccountStatus = "suspended"
+	AccountStatusDisabled  AccountStatus = "disabled"
+)
+
+// AccountStatusError is returned when a suspended or disabled account tries
+// to sign in. The moderation reason is internal and not part of it.
+type AccountStatusError struct {
+	Status AccountStatus
+	Until  *time.Time
+}
+
+func (e *AccountStatusError) Error() string {
+	if e.Until != nil {
+		return fmt.Sprintf("%s until %s", e.Unwrap(), e.Until.UTC().Format(time.RFC3339))
+	}
+	return e.Unwrap().Error()
+}
+
+func (e *AccountStatusError) Unwrap() error {
+	if e.Status == AccountStatusSuspended {
+		return ErrAccountSuspended
+	}
+	return ErrAccountDisabled
+}
+
+type SetAccountStatusRequest struct {
+	// Status is "suspended" or "disabled".
+	Status AccountStatus `json:"status"`
+	Reason string        `json:"reason"`
+	// Until lifts the restriction automatically; omit it to keep the
+	// account restricted until it is reinstated.
+	Until *time.Time `json:"until,omitempty"`
+}
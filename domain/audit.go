@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuthEventType string
+
+const (
+	AuthEventSignUp             AuthEventType = "sign_up"
+	AuthEventSignUpConflict     AuthEventType = "sign_up_conflict"
+	AuthEventSignIn             AuthEventType = "sign_in"
+	AuthEventSignInFailed       AuthEventType = "sign_in_failed"
+	AuthEventMFAChallenge       AuthEventType = "mfa_challenge"
+	AuthEventSignOut            AuthEventType = "sign_out"
+	AuthEventTokenRefresh       AuthEventType = "token_refresh"
+	AuthEventTokenRefreshFailed AuthEventType = "token_refresh_failed"
+	AuthEventPasswordChange     AuthEventType = "password_change"
+	AuthEventPasswordReset      AuthEventType = "password_reset"
+	AuthEventAdminUnlock        AuthEventType = "admin_unlock"
+	AuthEventAdminSuspend       AuthEventType = "admin_suspend"
+	AuthEventAdminReinstate     AuthEventType = "admin_reinstate"
+	AuthEventNewDevice          AuthEventType = "new_device"
+	AuthEventAccountSecured     AuthEventType = "account_secured"
+)
+
+// Sign-in methods recorded with sign-in events.
+const (
+	SignInMethodPassword  = "password"
+	SignInMethodMagicLink = "magic_link"
+	SignInMethodEmailOTP  = "email_otp"
+	SignInMethodPasskey   = "passkey"
+	SignInMethodMFA       = "mfa"
+)
+
+type AuthEvent struct {
+	ID     int64         `json:"id"`
+	Type   AuthEventType `json:"type"`
+	UserID *uuid.UUID    `json:"userID,omitempty"`
+	// Email is kept for failures that cannot be tied to an account.
+	Email  string `json:"email,omitempty"`
+	Method string `json:"method,omitempty"`
+	// Reason says why an attempt failed or why an admin acted.
+	Reason     string    `json:"reason,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	UserAgent  string    `json:"userAgent,omitempty"`
+	RequestID  string    `json:"requestID,omitempty"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+type AuthEventFilter struct {
+	UserID *uuid.UUID
+	// Types matches any of the listed types; empty matches every type.
+	Types []AuthEventType
+	Email string
+	IP    string
+	From  *time.Time
+	To    *time.Time
+	// BeforeID continues a listing after the event with this id.
+	BeforeID int64
+	Limit    int
+}
+
+type AuthEventsResponse struct {
+	Events []AuthEvent `json:"events"`
+	// NextCursor is empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type LoginHistoryEntry struct {
+	Success bool   `json:"success"`
+	Method  string `json:"method,omitempty"`
+	IP      string `json:"ip,omitempty"`
+	// Device is a coarse description of the user agent, e.g. "Firefox on
+	// Windows".
+	Device     string    `json:"device"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+type LoginHistoryResponse struct {
+	Entries []LoginHistoryEntry `json:"entries"`
+	// NextCursor is empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// SignInFailureReason names the cause of a failed sign-in for audit records
+// and metrics.
+func SignInFailureReason(err error) string {
+	var validationErr *ValidationError
+
+	switch {
+	case errors.Is(err, ErrInvalidCredentials), errors.Is(err, ErrCredsNotFound), errors.Is(err, ErrInvalidPassrord):
+		return "invalid_credentials"
+	case errors.Is(err, ErrAccountLocked):
+		return "account_locked"
+	case errors.Is(err, ErrAccountSuspended):
+		return "account_suspended"
+	case errors.Is(err, ErrAccountDisabled):
+		return "account_disabled"
+	case errors.Is(err, ErrAccountPendingDeletion):
+		return "account_pending_deletion"
+	case errors.Is(err, ErrPasswordResetRequired):
+		return "password_reset_required"
+	case errors.Is(err, ErrEmailNotVerified):
+		return "email_not_verified"
+	case errors.Is(err, ErrInvalidMFACode):
+		return "invalid_mfa_code"
+	case errors.Is(err, ErrInvalidMFAToken):
+		return "invalid_mfa_token"
+	case errors.Is(err, ErrInvalidMagicLink):
+		return "invalid_magic_link"
+	case errors.Is(err, ErrInvalidEmailOTP):
+		return "invalid_email_otp"
+	case errors.Is(err, ErrInvalidPasskey), errors.Is(err, ErrPasskeyNotFound):
+		return "invalid_passkey"
+	case errors.Is(err, ErrInvlaidRefreshToken):
+		return "invalid_refresh_token"
+	case errors.Is(err, ErrInvalidRequest), errors.As(err, &validationErr):
+		return "invalid_request"
+	default:
+		return "internal_error"
+	}
+}
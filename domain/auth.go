@@ -12,10 +12,19 @@ type SignInRequest struct {
 }
 
 type SignInResponse struct {
-	AccessToken  string `json:"accessToken"`
-	RefreshToken string `json:"refreshToken"`
+	AccessToken  string `json:"accessToken,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	// MFARequired is set instead of tokens when the user has 2FA enabled.
+	// MFAToken is then exchanged for tokens at /signin/mfa.
+	MFARequired bool     `json:"mfaRequired,omitempty"`
+	MFAToken    string   `json:"mfaToken,omitempty"`
+	MFAMethods  []string `json:"mfaMethods,omitempty"`
 }
 
 type SignOutRequest struct {
 	RefreshToken string `json:"refreshToken"`
 }
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
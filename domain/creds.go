@@ -1,6 +1,10 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type CreateCredsReq struct {
 	Email        string
@@ -8,7 +12,57 @@ type CreateCredsReq struct {
 }
 
 type Creds struct {
-	UserID       uuid.UUID
-	Email        string
-	PasswordHash string
+	UserID          uuid.UUID
+	Email           string
+	PasswordHash    string
+	EmailVerifiedAt *time.Time
+	// DeletionRequestedAt is set while the account waits to be deleted.
+	DeletionRequestedAt *time.Time
+	Status              AccountStatus
+	StatusReason        string
+	// StatusUntil is when a suspension lifts by itself.
+	StatusUntil *time.Time
+	// PasswordResetRequiredAt is set when the owner reported a sign-in as
+	// not theirs. Password sign-in is refused until the password changes.
+	PasswordResetRequiredAt *time.Time
+	// PasswordChangedAt is when the password was last set. Rehashing it
+	// does not count as a change.
+	PasswordChangedAt time.Time
+}
+
+func (c Creds) EmailVerified() bool {
+	return c.EmailVerifiedAt != nil
+}
+
+func (c Creds) DeletionRequested() bool {
+	return c.DeletionRequestedAt != nil
+}
+
+func (c Creds) PasswordResetRequired() bool {
+	return c.PasswordResetRequiredAt != nil
+}
+
+// CheckStatus returns an *AccountStatusError unless the account may sign
+// in at now. Suspensions past their expiry no longer apply.
+func (c Creds) CheckStatus(now time.Time) error {
+	if c.Status == "" || c.Status == AccountStatusActive {
+		return nil
+	}
+	if c.StatusUntil != nil && !now.Before(*c.StatusUntil) {
+		return nil
+	}
+
+	return &AccountStatusError{
+		Status: c.Status,
+		Until:  c.StatusUntil,
+	}
+}
+
+// EmailRecord is an account email as seen by the email-conflicts tool.
+// Conflict marks accounts whose emails collide once normalized.
+type EmailRecord struct {
+	UserID    uuid.UUID `json:"userID"`
+	Email     string    `json:"email"`
+	Conflict  bool      `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
 }
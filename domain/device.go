@@ -0,0 +1,17 @@
+package domain
+
+import "github.com/google/uuid"
+
+// KnownDevice is a device a user has signed in from. Fingerprint combines
+// the device id with a coarse user agent, so the same id presented by a
+// different browser counts as a new device.
+type KnownDevice struct {
+	UserID      uuid.UUID
+	Fingerprint string
+	Description string
+	IP          string
+}
+
+type SecureAccountRequest struct {
+	Token string `json:"token"`
+}
@@ -0,0 +1,18 @@
+package domain
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+type ChangeEmailRequest struct {
+	Password string `json:"password"`
+	NewEmail string `json:"newEmail"`
+}
+
+type EmailChangeTokenRequest struct {
+	Token string `json:"token"`
+}
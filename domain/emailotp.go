@@ -0,0 +1,10 @@
+package domain
+
+type EmailOTPRequest struct {
+	Email string `json:"email"`
+}
+
+type EmailOTPVerifyRequest struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
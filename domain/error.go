@@ -7,8 +7,36 @@ var (
 	ErrUnknownConflict     = errors.New("unknown conflict")
 	ErrCredsNotFound       = errors.New("creds not found")
 	ErrInvalidPassrord     = errors.New("invalid password")
+	ErrInvalidCredentials  = errors.New("invalid email or password")
 	ErrInvalidAccessToken  = errors.New("invalid access token")
 	ErrInvlaidRefreshToken = errors.New("invalid refresh token")
 
+	ErrOneTimeTokenNotFound     = errors.New("one-time token not found")
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrEmailNotVerified         = errors.New("email not verified")
+	ErrInvalidResetToken        = errors.New("invalid or expired reset token")
+	ErrInvalidRequest           = errors.New("invalid request")
+	ErrInvalidCurrentPassword   = errors.New("invalid current password")
+	ErrInvalidEmailChangeToken  = errors.New("invalid or expired email change token")
+	ErrAccountLocked            = errors.New("account locked")
+	ErrInvalidAdminKey          = errors.New("invalid admin key")
+	ErrMFAUnavailable           = errors.New("mfa unavailable")
+	ErrMFANotEnrolled           = errors.New("mfa not enrolled")
+	ErrMFAAlreadyEnabled        = errors.New("mfa already enabled")
+	ErrInvalidMFACode           = errors.New("invalid mfa code")
+	ErrInvalidMFAToken          = errors.New("invalid or expired mfa token")
+	ErrInvalidMagicLink         = errors.New("invalid or expired magic link")
+	ErrInvalidEmailOTP          = errors.New("invalid or expired email code")
+	ErrAccountPendingDeletion   = errors.New("account is scheduled for deletion")
+	ErrAccountSuspended         = errors.New("account suspended")
+	ErrAccountDisabled          = errors.New("account disabled")
+	ErrAccountNotFound          = errors.New("account not found")
+	ErrPasskeysUnavailable      = errors.New("passkeys unavailable")
+	ErrInvalidPasskey           = errors.New("invalid passkey response")
+	ErrPasskeyNotFound          = errors.New("passkey not found")
+	ErrPasskeyExists            = errors.New("passkey already registered")
+	ErrPasswordResetRequired    = errors.New("password reset required")
+	ErrInvalidSecureToken       = errors.New("invalid or expired secure account token")
+
 	ErrInternal = errors.New("internal error")
 )
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// AccountLockedError is returned while an account is locked after too many
+// failed sign-ins.
+type AccountLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("%s: retry after %s", ErrAccountLocked, e.RetryAfter.Round(time.Second))
+}
+
+func (e *AccountLockedError) Unwrap() error {
+	return ErrAccountLocked
+}
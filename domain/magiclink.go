@@ -0,0 +1,16 @@
+package domain
+
+type MagicLinkRequest struct {
+	Email string `json:"email"`
+}
+
+// MagicLinkResponse carries the nonce the link is bound to. Browsers also
+// receive it as a cookie; native apps pass it back explicitly.
+type MagicLinkResponse struct {
+	Nonce string `json:"nonce"`
+}
+
+type MagicLinkVerifyRequest struct {
+	Token string `json:"token"`
+	Nonce string `json:"nonce,omitempty"`
+}
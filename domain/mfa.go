@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TOTP struct {
+	UserID       uuid.UUID
+	SecretEnc    []byte
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+}
+
+func (t TOTP) Enabled() bool {
+	return t.ConfirmedAt != nil
+}
+
+type TOTPEnrollResponse struct {
+	Secret string `json:"secret"`
+	// OTPAuthURI is the payload to render as a QR code.
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+type TOTPConfirmRequest struct {
+	Code string `json:"code"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+const (
+	MFAMethodTOTP    = "totp"
+	MFAMethodPasskey = "passkey"
+)
+
+// SignInMFARequest completes a sign-in with exactly one of Code,
+// RecoveryCode or Passkey.
+type SignInMFARequest struct {
+	MFAToken     string            `json:"mfaToken"`
+	Code         string            `json:"code,omitempty"`
+	RecoveryCode string            `json:"recoveryCode,omitempty"`
+	Passkey      *PasskeyAssertion `json:"passkey,omitempty"`
+}
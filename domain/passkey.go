@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Passkey struct {
+	// ID is the base64url encoded credential id.
+	ID         string     `json:"id"`
+	UserID     uuid.UUID  `json:"-"`
+	PublicKey  []byte     `json:"-"`
+	SignCount  uint32     `json:"-"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+type PasskeysResponse struct {
+	Passkeys []Passkey `json:"passkeys"`
+}
+
+// The option types mirror PublicKeyCredentialCreationOptions and
+// PublicKeyCredentialRequestOptions in their JSON form; binary fields are
+// base64url encoded.
+
+type PasskeyRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type PasskeyUser struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type PasskeyCredParam struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type PasskeyDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type PasskeyAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+type PasskeyCreationOptions struct {
+	Challenge              string                        `json:"challenge"`
+	RP                     PasskeyRelyingParty           `json:"rp"`
+	User                   PasskeyUser                   `json:"user"`
+	PubKeyCredParams       []PasskeyCredParam            `json:"pubKeyCredParams"`
+	Timeout                int64                         `json:"timeout"`
+	ExcludeCredentials     []PasskeyDescriptor           `json:"excludeCredentials"`
+	AuthenticatorSelection PasskeyAuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                        `json:"attestation"`
+}
+
+type PasskeyRequestOptions struct {
+	Challenge        string              `json:"challenge"`
+	Timeout          int64               `json:"timeout"`
+	RPID             string              `json:"rpId"`
+	AllowCredentials []PasskeyDescriptor `json:"allowCredentials,omitempty"`
+	UserVerification string              `json:"userVerification"`
+}
+
+// PasskeyAttestation is the JSON form of a PublicKeyCredential returned by
+// navigator.credentials.create().
+type PasskeyAttestation struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AttestationObject string `json:"attestationObject"`
+	} `json:"response"`
+}
+
+// PasskeyAssertion is the JSON form of a PublicKeyCredential returned by
+// navigator.credentials.get().
+type PasskeyAssertion struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+type PasskeyRegisterRequest struct {
+	Name       string             `json:"name"`
+	Credential PasskeyAttestation `json:"credential"`
+}
+
+type SignInPasskeyRequest struct {
+	Credential PasskeyAssertion `json:"credential"`
+}
+
+type MFAPasskeyOptionsRequest struct {
+	MFAToken string `json:"mfaToken"`
+}
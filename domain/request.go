@@ -0,0 +1,24 @@
+package domain
+
+import "context"
+
+// RequestMeta describes the HTTP request a call is made for.
+type RequestMeta struct {
+	IP        string
+	UserAgent string
+	RequestID string
+	// DeviceID identifies the browser or app across sessions.
+	DeviceID string
+}
+
+type requestMetaKey struct{}
+
+func WithRequestMeta(ctx context.Context, m RequestMeta) context.Context {
+	return context.WithValue(ctx, requestMetaKey{}, m)
+}
+
+// RequestMetaFromContext returns the zero RequestMeta outside of a request.
+func RequestMetaFromContext(ctx context.Context) RequestMeta {
+	m, _ := ctx.Value(requestMetaKey{}).(RequestMeta)
+	return m
+}
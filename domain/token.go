@@ -3,5 +3,8 @@ package domain
 import "github.com/google/uuid"
 
 type TokenClaims struct {
-	UserID uuid.UUID
+	UserID     uuid.UUID
+	SessionID  string
+	Restricted bool
+	Scopes     []string
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"fmt"
 	"log"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/akemoon/crowdfunding-app-auth/api"
 	userClient "github.com/akemoon/crowdfunding-app-auth/cluster/user/resty"
@@ -17,12 +19,43 @@ import (
 	_ "github.com/akemoon/crowdfunding-app-auth/docs"
 	infraRedis "github.com/akemoon/crowdfunding-app-auth/infra/redis"
 	"github.com/akemoon/crowdfunding-app-auth/metrics"
+	auditRepo "github.com/akemoon/crowdfunding-app-auth/repo/audit/postgres"
 	"github.com/akemoon/crowdfunding-app-auth/repo/creds/postgres"
+	deletionRepo "github.com/akemoon/crowdfunding-app-auth/repo/deletion/postgres"
+	deviceRepo "github.com/akemoon/crowdfunding-app-auth/repo/device/postgres"
+	emailOTPRepo "github.com/akemoon/crowdfunding-app-auth/repo/emailotp/redis"
+	lockoutRepo "github.com/akemoon/crowdfunding-app-auth/repo/lockout/redis"
+	mfaRepo "github.com/akemoon/crowdfunding-app-auth/repo/mfa/postgres"
+	onetimeRepo "github.com/akemoon/crowdfunding-app-auth/repo/onetime/redis"
+	passkeyRepo "github.com/akemoon/crowdfunding-app-auth/repo/passkey/postgres"
 	redisRepo "github.com/akemoon/crowdfunding-app-auth/repo/token/redis"
+	"github.com/akemoon/crowdfunding-app-auth/service/account"
+	"github.com/akemoon/crowdfunding-app-auth/service/audit"
 	authService "github.com/akemoon/crowdfunding-app-auth/service/auth"
 	"github.com/akemoon/crowdfunding-app-auth/service/creds"
+	"github.com/akemoon/crowdfunding-app-auth/service/device"
+	"github.com/akemoon/crowdfunding-app-auth/service/email"
+	"github.com/akemoon/crowdfunding-app-auth/service/emailotp"
+	"github.com/akemoon/crowdfunding-app-auth/service/lockout"
+	"github.com/akemoon/crowdfunding-app-auth/service/magiclink"
+	"github.com/akemoon/crowdfunding-app-auth/service/mfa"
+	"github.com/akemoon/crowdfunding-app-auth/service/passkey"
+	"github.com/akemoon/crowdfunding-app-auth/service/password"
 	"github.com/akemoon/crowdfunding-app-auth/service/token"
+	"github.com/akemoon/crowdfunding-app-auth/service/verification"
+	"github.com/akemoon/crowdfunding-app-auth/tool/hasher"
+	"github.com/akemoon/crowdfunding-app-auth/tool/hasher/argon2"
 	"github.com/akemoon/crowdfunding-app-auth/tool/hasher/bcrypt"
+	"github.com/akemoon/crowdfunding-app-auth/tool/hasher/pbkdf2"
+	"github.com/akemoon/crowdfunding-app-auth/tool/hasher/pepper"
+	"github.com/akemoon/crowdfunding-app-auth/tool/hasher/scrypt"
+	"github.com/akemoon/crowdfunding-app-auth/tool/hasher/sha1"
+	"github.com/akemoon/crowdfunding-app-auth/tool/mailer"
+	fileMailer "github.com/akemoon/crowdfunding-app-auth/tool/mailer/file"
+	queueMailer "github.com/akemoon/crowdfunding-app-auth/tool/mailer/queue"
+	smtpMailer "github.com/akemoon/crowdfunding-app-auth/tool/mailer/smtp"
+	"github.com/akemoon/crowdfunding-app-auth/tool/passpolicy"
+	redisLimiter "github.com/akemoon/crowdfunding-app-auth/tool/ratelimit/redis"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/redis/go-redis/v9"
 )
@@ -37,6 +70,61 @@ const (
 
 	envJWTSecret      = "JWT_SECRET"
 	envUserServiceURL = "USER_SERVICE_URL"
+
+	envMailer       = "MAILER"
+	envMailFile     = "MAIL_FILE"
+	envSMTPHost     = "SMTP_HOST"
+	envSMTPPort     = "SMTP_PORT"
+	envSMTPUsername = "SMTP_USERNAME"
+	envSMTPPassword = "SMTP_PASSWORD"
+	envSMTPFrom     = "SMTP_FROM"
+
+	envEmailVerificationPolicy = "EMAIL_VERIFICATION_POLICY"
+	envEmailVerificationURL    = "EMAIL_VERIFICATION_URL"
+	envEmailUnverifiedScopes   = "EMAIL_UNVERIFIED_SCOPES"
+
+	envPasswordResetURL = "PASSWORD_RESET_URL"
+	envMagicLinkURL     = "MAGIC_LINK_URL"
+
+	envEmailChangeConfirmURL = "EMAIL_CHANGE_CONFIRM_URL"
+	envEmailChangeRevertURL  = "EMAIL_CHANGE_REVERT_URL"
+
+	envPasswordMinLength    = "PASSWORD_MIN_LENGTH"
+	envPasswordMaxLength    = "PASSWORD_MAX_LENGTH"
+	envPasswordMinEntropy   = "PASSWORD_MIN_ENTROPY_BITS"
+	envPasswordDenylistPath = "PASSWORD_DENYLIST_PATH"
+
+	envHasher            = "PASSWORD_HASHER"
+	envBcryptCost        = "BCRYPT_COST"
+	envArgon2Memory      = "ARGON2_MEMORY"
+	envArgon2Time        = "ARGON2_TIME"
+	envArgon2Parallelism = "ARGON2_PARALLELISM"
+	envLegacySHA1Hashes  = "PASSWORD_LEGACY_SHA1_ENABLED"
+	envPepperKeys        = "PASSWORD_PEPPER_KEYS"
+	envPepperVersion     = "PASSWORD_PEPPER_VERSION"
+
+	envLockoutThreshold = "LOCKOUT_THRESHOLD"
+	envAdminAPIKey      = "ADMIN_API_KEY"
+
+	envTrustedProxies = "TRUSTED_PROXIES"
+
+	envMFAIssuer        = "MFA_ISSUER"
+	envMFAEncryptionKey = "MFA_ENCRYPTION_KEY"
+
+	envWebAuthnRPID    = "WEBAUTHN_RP_ID"
+	envWebAuthnRPName  = "WEBAUTHN_RP_NAME"
+	envWebAuthnOrigins = "WEBAUTHN_ORIGINS"
+
+	envAccountDeletionGracePeriod = "ACCOUNT_DELETION_GRACE_PERIOD"
+	envAccountDeletionAnonymize   = "ACCOUNT_DELETION_ANONYMIZE"
+
+	envAuditRetention = "AUDIT_RETENTION"
+
+	envSignUpConflictPolicy   = "SIGNUP_CONFLICT_POLICY"
+	envSignUpPasswordResetURL = "SIGNUP_PASSWORD_RESET_URL"
+
+	envSecureAccountURL        = "SECURE_ACCOUNT_URL"
+	envNewDeviceIgnoreNetworks = "NEW_DEVICE_IGNORE_NETWORKS"
 )
 
 // @title Auth Service API
@@ -45,6 +133,14 @@ func main() {
 	mainCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	if len(os.Args) > 1 {
+		err := runCommand(mainCtx, os.Args[1], os.Args[2:])
+		if err != nil {
+			log.Fatalf("%s err: %s", os.Args[1], err)
+		}
+		return
+	}
+
 	pg, err := initPostgres(mainCtx)
 	if err != nil {
 		log.Fatalf("init db err: %s", err)
@@ -76,22 +172,147 @@ func main() {
 	}
 
 	tokenRepo := redisRepo.NewRefreshTokenRepository(redisClient)
-	tokenSvc := token.NewService(tokenRepo, jwtSecret)
+	tokenSvc := token.NewService(tokenRepo, redisRepo.NewRevocationRepo(redisClient), jwtSecret)
 
 	credsRepo := postgres.NewCredsRepo(pg)
-	hasher := bcrypt.NewHasher(0)
-	credsSvc := creds.NewService(credsRepo, hasher)
+	passwordHasher, err := initHasher()
+	if err != nil {
+		log.Fatalf("init hasher err: %s", err)
+	}
+
+	policy, err := initPasswordPolicy()
+	if err != nil {
+		log.Fatalf("init password policy err: %s", err)
+	}
+
+	lockoutThreshold, err := envInt(envLockoutThreshold)
+	if err != nil {
+		log.Fatalf("init lockout err: %s", err)
+	}
+
+	lockoutSvc := lockout.NewService(lockoutRepo.NewLockoutRepo(redisClient), config.Lockout{
+		Threshold: lockoutThreshold,
+	})
+
+	credsSvc := creds.NewService(credsRepo, passwordHasher, policy, lockoutSvc)
+
+	mail, err := initMailer()
+	if err != nil {
+		log.Fatalf("init mailer err: %s", err)
+	}
+
+	// Mail that is only sent for registered addresses goes through the
+	// queue, so the response time does not tell whether one is registered.
+	mailQueue := queueMailer.NewMailer(mail, 0)
+	go mailQueue.Run(mainCtx)
+
+	verificationCfg, err := initEmailVerification()
+	if err != nil {
+		log.Fatalf("init email verification err: %s", err)
+	}
+
+	oneTimeTokenRepo := onetimeRepo.NewOneTimeTokenRepo(redisClient)
+	verificationSvc := verification.NewService(credsRepo, oneTimeTokenRepo, mailQueue, verificationCfg)
+
+	auditCfg, err := initAudit()
+	if err != nil {
+		log.Fatalf("init audit err: %s", err)
+	}
+
+	auditSvc := audit.NewService(auditRepo.NewAuditRepo(pg), auditCfg)
+	go auditSvc.Run(mainCtx)
+
+	passwordSvc := password.NewService(credsSvc, tokenSvc, lockoutSvc, oneTimeTokenRepo, mailQueue, auditSvc, config.PasswordReset{
+		LinkURL: strings.TrimSpace(os.Getenv(envPasswordResetURL)),
+	})
+
+	emailSvc := email.NewService(credsSvc, tokenSvc, oneTimeTokenRepo, mail, config.EmailChange{
+		ConfirmURL: strings.TrimSpace(os.Getenv(envEmailChangeConfirmURL)),
+		RevertURL:  strings.TrimSpace(os.Getenv(envEmailChangeRevertURL)),
+	})
+
+	mfaCfg, err := initMFA()
+	if err != nil {
+		log.Fatalf("init mfa err: %s", err)
+	}
+
+	passkeySvc := passkey.NewService(passkeyRepo.NewPasskeyRepo(pg), credsSvc, oneTimeTokenRepo, config.WebAuthn{
+		RPID:    strings.TrimSpace(os.Getenv(envWebAuthnRPID)),
+		RPName:  strings.TrimSpace(os.Getenv(envWebAuthnRPName)),
+		Origins: splitList(os.Getenv(envWebAuthnOrigins)),
+	})
+
+	mfaSvc, err := mfa.NewService(mfaRepo.NewMFARepo(pg), credsSvc, oneTimeTokenRepo, lockoutSvc, passkeySvc, mfaCfg)
+	if err != nil {
+		log.Fatalf("init mfa err: %s", err)
+	}
+
+	magicLinkSvc := magiclink.NewService(credsSvc, oneTimeTokenRepo, mailQueue, config.MagicLink{
+		LinkURL: strings.TrimSpace(os.Getenv(envMagicLinkURL)),
+	})
+
+	emailOTPSvc := emailotp.NewService(emailOTPRepo.NewEmailOTPRepo(redisClient), credsSvc, mailQueue, config.EmailOTP{})
+
+	deviceCfg, err := initDeviceNotice()
+	if err != nil {
+		log.Fatalf("init device notice err: %s", err)
+	}
+
+	deviceSvc := device.NewService(deviceRepo.NewDeviceRepo(pg), credsSvc, tokenSvc, passwordSvc, passkeySvc, oneTimeTokenRepo, mail, auditSvc, deviceCfg)
+
+	signUpCfg, err := initSignUp()
+	if err != nil {
+		log.Fatalf("init sign-up err: %s", err)
+	}
 
 	userSvc := userClient.NewClient(userServiceURL)
-	authSvc := authService.NewService(userSvc, credsSvc, tokenSvc)
+	authSvc := authService.NewService(userSvc, credsSvc, tokenSvc, verificationSvc, mfaSvc, passkeySvc, magicLinkSvc, emailOTPSvc, auditSvc, deviceSvc, mailQueue, signUpCfg)
+
+	accountDeletionCfg, err := initAccountDeletion()
+	if err != nil {
+		log.Fatalf("init account deletion err: %s", err)
+	}
+
+	accountSvc := account.NewService(deletionRepo.NewDeletionRepo(pg), credsSvc, tokenSvc, lockoutSvc, auditSvc, userSvc, accountDeletionCfg)
+	go accountSvc.Run(mainCtx)
 
 	reg := prometheus.DefaultRegisterer
 
 	m := metrics.NewAuthMetrics(reg)
 
 	srv := api.NewServer()
+
+	requestMeta, err := api.RequestMeta(splitList(os.Getenv(envTrustedProxies)))
+	if err != nil {
+		log.Fatalf("init request meta err: %s", err)
+	}
+	srv.Use(requestMeta)
+
+	rateLimit, err := api.RateLimit(
+		redisLimiter.NewLimiter(redisClient),
+		rateLimitConfig(),
+		metrics.NewRateLimitMetrics(reg),
+	)
+	if err != nil {
+		log.Fatalf("init rate limit err: %s", err)
+	}
+	srv.Use(rateLimit)
+
 	srv.AddAuthHandlers(authSvc, m)
-	srv.AddTokenHandlers(tokenSvc)
+	srv.AddMFAHandlers(mfaSvc, authSvc, tokenSvc, m)
+	srv.AddPasskeyHandlers(passkeySvc, authSvc, tokenSvc, m)
+	srv.AddMagicLinkHandlers(magicLinkSvc, authSvc, m)
+	srv.AddEmailOTPHandlers(emailOTPSvc, authSvc, m)
+	srv.AddTokenHandlers(tokenSvc, authSvc)
+	srv.AddVerificationHandlers(verificationSvc)
+	srv.AddPasswordHandlers(passwordSvc, tokenSvc)
+	srv.AddEmailHandlers(emailSvc, tokenSvc)
+	srv.AddAccountHandlers(accountSvc, auditSvc, deviceSvc, tokenSvc)
+	adminAPIKey := strings.TrimSpace(os.Getenv(envAdminAPIKey))
+	if adminAPIKey != "" {
+		srv.AddAdminHandlers(accountSvc, auditSvc, adminAPIKey)
+	}
+
 	srv.AddSwaggerUI()
 	srv.AddMetrics()
 
@@ -141,3 +362,409 @@ func initRedis(ctx context.Context) (*redis.Client, error) {
 
 	return infraRedis.NewRedisClient(ctx, cfg)
 }
+
+func initMailer() (mailer.Mailer, error) {
+	switch strings.TrimSpace(os.Getenv(envMailer)) {
+	case "", "file":
+		return fileMailer.NewMailer(strings.TrimSpace(os.Getenv(envMailFile))), nil
+	case "smtp":
+		for _, key := range []string{
+			envSMTPHost,
+			envSMTPPort,
+			envSMTPFrom,
+		} {
+			value := strings.TrimSpace(os.Getenv(key))
+			if value == "" {
+				return nil, fmt.Errorf("missing required env var: %s", key)
+			}
+		}
+
+		port, err := strconv.Atoi(strings.TrimSpace(os.Getenv(envSMTPPort)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envSMTPPort, err)
+		}
+
+		return smtpMailer.NewMailer(config.SMTP{
+			Host:     strings.TrimSpace(os.Getenv(envSMTPHost)),
+			Port:     port,
+			Username: os.Getenv(envSMTPUsername),
+			Password: os.Getenv(envSMTPPassword),
+			From:     strings.TrimSpace(os.Getenv(envSMTPFrom)),
+		}), nil
+	default:
+		return nil, fmt.Errorf("invalid %s: %s", envMailer, os.Getenv(envMailer))
+	}
+}
+
+func initEmailVerification() (config.EmailVerification, error) {
+	cfg := config.EmailVerification{
+		Policy:  config.EmailVerificationPolicy(strings.TrimSpace(os.Getenv(envEmailVerificationPolicy))),
+		LinkURL: strings.TrimSpace(os.Getenv(envEmailVerificationURL)),
+	}
+
+	switch cfg.Policy {
+	case "":
+		cfg.Policy = config.EmailVerificationOff
+	case config.EmailVerificationOff, config.EmailVerificationBlock, config.EmailVerificationRestrict:
+	default:
+		return config.EmailVerification{}, fmt.Errorf("invalid %s: %s", envEmailVerificationPolicy, cfg.Policy)
+	}
+
+	cfg.UnverifiedScopes = splitList(os.Getenv(envEmailUnverifiedScopes))
+
+	return cfg, nil
+}
+
+// initHasher returns a registry that hashes with the configured algorithm
+// and verifies every supported format, so imported hashes keep working until
+// they are upgraded on login.
+func initHasher() (hasher.Algorithm, error) {
+	current, err := initCurrentHasher()
+	if err != nil {
+		return nil, err
+	}
+
+	verifiers := []hasher.Verifier{
+		bcrypt.NewHasher(0),
+		argon2.NewHasher(config.Argon2{}),
+		scrypt.NewVerifier(),
+		pbkdf2.NewVerifier(),
+	}
+
+	legacy, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(envLegacySHA1Hashes)))
+	if err == nil && legacy {
+		log.Printf("legacy salted SHA-1 password hashes are accepted")
+		verifiers = append(verifiers, sha1.NewVerifier())
+	}
+
+	registry := hasher.NewRegistry(current, verifiers...)
+
+	pepperCfg, err := initPepper()
+	if err != nil {
+		return nil, err
+	}
+	if len(pepperCfg.Keys) == 0 {
+		log.Printf("env %s is empty, passwords are hashed without a pepper", envPepperKeys)
+		return registry, nil
+	}
+
+	return pepper.NewHasher(registry, pepperCfg)
+}
+
+// initPepper reads keys given as "<version>:<base64 key>,...".
+func initPepper() (config.Pepper, error) {
+	var cfg config.Pepper
+
+	for _, entry := range splitList(os.Getenv(envPepperKeys)) {
+		version, value, found := strings.Cut(entry, ":")
+		if !found {
+			return config.Pepper{}, fmt.Errorf("invalid %s: expected <version>:<key>", envPepperKeys)
+		}
+
+		v, err := strconv.Atoi(version)
+		if err != nil {
+			return config.Pepper{}, fmt.Errorf("invalid %s: version %q", envPepperKeys, version)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return config.Pepper{}, fmt.Errorf("invalid %s: key %d: %w", envPepperKeys, v, err)
+		}
+
+		if cfg.Keys == nil {
+			cfg.Keys = make(map[int][]byte)
+		}
+		if _, dup := cfg.Keys[v]; dup {
+			return config.Pepper{}, fmt.Errorf("invalid %s: duplicate version %d", envPepperKeys, v)
+		}
+		cfg.Keys[v] = key
+	}
+
+	value := strings.TrimSpace(os.Getenv(envPepperVersion))
+	if value != "" {
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return config.Pepper{}, fmt.Errorf("invalid %s: %w", envPepperVersion, err)
+		}
+		cfg.Current = v
+	}
+
+	return cfg, nil
+}
+
+func initCurrentHasher() (hasher.Algorithm, error) {
+	switch strings.TrimSpace(os.Getenv(envHasher)) {
+	case "", "bcrypt":
+		cost, err := envInt(envBcryptCost)
+		if err != nil {
+			return nil, err
+		}
+		return bcrypt.NewHasher(cost), nil
+	case "argon2id":
+		memory, err := envInt(envArgon2Memory)
+		if err != nil {
+			return nil, err
+		}
+		iterations, err := envInt(envArgon2Time)
+		if err != nil {
+			return nil, err
+		}
+		parallelism, err := envInt(envArgon2Parallelism)
+		if err != nil {
+			return nil, err
+		}
+		return argon2.NewHasher(config.Argon2{
+			Memory:      uint32(memory),
+			Time:        uint32(iterations),
+			Parallelism: uint8(parallelism),
+		}), nil
+	default:
+		return nil, fmt.Errorf("invalid %s: %s", envHasher, os.Getenv(envHasher))
+	}
+}
+
+func initPasswordPolicy() (*passpolicy.Policy, error) {
+	cfg := config.PasswordPolicy{
+		DenylistPath: strings.TrimSpace(os.Getenv(envPasswordDenylistPath)),
+	}
+
+	// only bcrypt truncates long passwords
+	if strings.TrimSpace(os.Getenv(envHasher)) == "argon2id" {
+		cfg.MaxBytes = 1024
+	}
+
+	var err error
+
+	cfg.MinLength, err = envInt(envPasswordMinLength)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.MaxLength, err = envInt(envPasswordMaxLength)
+	if err != nil {
+		return nil, err
+	}
+
+	if value := strings.TrimSpace(os.Getenv(envPasswordMinEntropy)); value != "" {
+		bits, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envPasswordMinEntropy, err)
+		}
+		cfg.MinEntropyBits = bits
+	}
+
+	return passpolicy.NewPolicy(cfg)
+}
+
+// initMFA reads the TOTP secret encryption key. Without it the service runs
+// but refuses new enrollments.
+func initMFA() (config.MFA, error) {
+	cfg := config.MFA{
+		Issuer: strings.TrimSpace(os.Getenv(envMFAIssuer)),
+	}
+
+	value := strings.TrimSpace(os.Getenv(envMFAEncryptionKey))
+	if value == "" {
+		log.Printf("env %s is empty, 2FA enrollment is disabled", envMFAEncryptionKey)
+		return cfg, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return config.MFA{}, fmt.Errorf("invalid %s: %w", envMFAEncryptionKey, err)
+	}
+	cfg.EncryptionKey = key
+
+	return cfg, nil
+}
+
+func initSignUp() (config.SignUp, error) {
+	cfg := config.SignUp{
+		ConflictPolicy:   config.SignUpConflictPolicy(strings.TrimSpace(os.Getenv(envSignUpConflictPolicy))),
+		PasswordResetURL: strings.TrimSpace(os.Getenv(envSignUpPasswordResetURL)),
+	}
+
+	switch cfg.ConflictPolicy {
+	case "":
+		cfg.ConflictPolicy = config.SignUpConflictReveal
+	case config.SignUpConflictReveal, config.SignUpConflictNotify:
+	default:
+		return config.SignUp{}, fmt.Errorf("invalid %s: %s", envSignUpConflictPolicy, cfg.ConflictPolicy)
+	}
+
+	return cfg, nil
+}
+
+func initDeviceNotice() (config.DeviceNotice, error) {
+	cfg := config.DeviceNotice{
+		SecureURL: strings.TrimSpace(os.Getenv(envSecureAccountURL)),
+	}
+
+	value := strings.TrimSpace(os.Getenv(envNewDeviceIgnoreNetworks))
+	if value != "" {
+		ignore, err := strconv.ParseBool(value)
+		if err != nil {
+			return config.DeviceNotice{}, fmt.Errorf("invalid %s: %w", envNewDeviceIgnoreNetworks, err)
+		}
+		cfg.IgnoreNewNetworks = ignore
+	}
+
+	return cfg, nil
+}
+
+func initAudit() (config.Audit, error) {
+	var cfg config.Audit
+
+	value := strings.TrimSpace(os.Getenv(envAuditRetention))
+	if value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return config.Audit{}, fmt.Errorf("invalid %s: %q", envAuditRetention, value)
+		}
+		cfg.Retention = d
+	}
+
+	return cfg, nil
+}
+
+func initAccountDeletion() (config.AccountDeletion, error) {
+	var cfg config.AccountDeletion
+
+	value := strings.TrimSpace(os.Getenv(envAccountDeletionGracePeriod))
+	if value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return config.AccountDeletion{}, fmt.Errorf("invalid %s: %w", envAccountDeletionGracePeriod, err)
+		}
+		cfg.GracePeriod = d
+	}
+
+	value = strings.TrimSpace(os.Getenv(envAccountDeletionAnonymize))
+	if value != "" {
+		anonymize, err := strconv.ParseBool(value)
+		if err != nil {
+			return config.AccountDeletion{}, fmt.Errorf("invalid %s: %w", envAccountDeletionAnonymize, err)
+		}
+		cfg.Anonymize = anonymize
+	}
+
+	return cfg, nil
+}
+
+func rateLimitConfig() config.RateLimit {
+	perMinute := func(n int) config.Limit {
+		return config.Limit{Requests: n, Window: time.Minute}
+	}
+	perHour := func(n int) config.Limit {
+		return config.Limit{Requests: n, Window: time.Hour}
+	}
+
+	return config.RateLimit{
+		TrustedProxies: splitList(os.Getenv(envTrustedProxies)),
+		Rules: []config.RateLimitRule{
+			{
+				Pattern:  "POST /signin",
+				PerIP:    perMinute(20),
+				PerEmail: perMinute(10),
+				Global:   perMinute(3000),
+			},
+			{
+				Pattern: "POST /signin/mfa",
+				PerIP:   perMinute(20),
+				Global:  perMinute(3000),
+			},
+			{
+				Pattern: "POST /signin/passkey",
+				PerIP:   perMinute(20),
+				Global:  perMinute(3000),
+			},
+			{
+				Pattern:  "POST /signin/magic-link",
+				PerIP:    perHour(20),
+				PerEmail: perHour(5),
+				Global:   perMinute(300),
+			},
+			{
+				Pattern: "POST /signin/magic-link/verify",
+				PerIP:   perMinute(20),
+				Global:  perMinute(3000),
+			},
+			{
+				Pattern:  "POST /signin/email-otp",
+				PerIP:    perHour(20),
+				PerEmail: perHour(5),
+				Global:   perMinute(300),
+			},
+			{
+				Pattern:  "POST /signin/email-otp/verify",
+				PerIP:    perMinute(20),
+				PerEmail: perMinute(10),
+				Global:   perMinute(3000),
+			},
+			{
+				Pattern:  "POST /signup",
+				PerIP:    perHour(20),
+				PerEmail: perHour(5),
+				Global:   perMinute(600),
+			},
+			{
+				Pattern:  "POST /password/forgot",
+				PerIP:    perHour(20),
+				PerEmail: perHour(5),
+				Global:   perMinute(300),
+			},
+			{
+				Pattern: "POST /password/reset",
+				PerIP:   perHour(20),
+				Global:  perMinute(300),
+			},
+			{
+				Pattern: "POST /password/change",
+				PerIP:   perHour(20),
+				Global:  perMinute(300),
+			},
+			{
+				Pattern: "DELETE /account",
+				PerIP:   perHour(20),
+				Global:  perMinute(300),
+			},
+			{
+				Pattern: "POST /account/secure",
+				PerIP:   perHour(20),
+				Global:  perMinute(300),
+			},
+			{
+				Pattern:  "POST /email/verify/resend",
+				PerIP:    perHour(20),
+				PerEmail: perHour(5),
+				Global:   perMinute(300),
+			},
+		},
+	}
+}
+
+// envInt returns 0 for unset variables.
+func envInt(key string) (int, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return n, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
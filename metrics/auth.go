@@ -12,7 +12,8 @@ func NewAuthMetrics(reg prometheus.Registerer) *AuthMetrics {
 			Name: "auth_signin_total",
 			Help: "Total number of signin attempts",
 		},
-		[]string{"result"},
+		// reason is only set for failures
+		[]string{"result", "reason"},
 	)
 
 	reg.MustRegister(authSignInTotal)
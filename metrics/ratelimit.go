@@ -0,0 +1,23 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type RateLimitMetrics struct {
+	RateLimitRejectedTotal *prometheus.CounterVec
+}
+
+func NewRateLimitMetrics(reg prometheus.Registerer) *RateLimitMetrics {
+	rateLimitRejectedTotal := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_ratelimit_rejected_total",
+			Help: "Total number of requests rejected by the rate limiter",
+		},
+		[]string{"route", "scope"},
+	)
+
+	reg.MustRegister(rateLimitRejectedTotal)
+
+	return &RateLimitMetrics{
+		RateLimitRejectedTotal: rateLimitRejectedTotal,
+	}
+}
@@ -0,0 +1,149 @@
+package authclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/google/uuid"
+)
+
+const testAdminKey = "admin-key"
+
+// fakeAdmin records the admin requests it accepts.
+type fakeAdmin struct {
+	mu       sync.Mutex
+	requests []string
+	query    url.Values
+	unknown  uuid.UUID
+}
+
+func newFakeAdmin(t *testing.T) (*fakeAdmin, *httptest.Server) {
+	t.Helper()
+
+	f := &fakeAdmin{unknown: uuid.New()}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Admin-Key") != testAdminKey {
+			writeTestErr(w, http.StatusUnauthorized, CodeInvalidAdminKey)
+			return
+		}
+
+		f.mu.Lock()
+		f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+		f.query = r.URL.Query()
+		f.mu.Unlock()
+
+		switch {
+		case strings.Contains(r.URL.Path, f.unknown.String()):
+			writeTestErr(w, http.StatusNotFound, CodeAccountNotFound)
+		case r.Method == http.MethodGet:
+			writeTestJSON(w, http.StatusOK, AuthEventsResponse{
+				Events:     []domain.AuthEvent{{ID: 7, Type: domain.AuthEventSignInFailed}},
+				NextCursor: "next",
+			})
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return f, srv
+}
+
+func TestAdminAccountActions(t *testing.T) {
+	userID := uuid.New()
+	prefix := "POST /admin/accounts/" + userID.String()
+
+	tests := []struct {
+		name string
+		call func(c *Client, userID uuid.UUID) error
+		want string
+	}{
+		{"suspend", func(c *Client, userID uuid.UUID) error {
+			return c.SuspendAccount(context.Background(), userID, SetAccountStatusRequest{
+				Status: domain.AccountStatusSuspended,
+				Reason: "abuse",
+			})
+		}, prefix + "/suspend"},
+		{"reinstate", func(c *Client, userID uuid.UUID) error {
+			return c.ReinstateAccount(context.Background(), userID)
+		}, prefix + "/reinstate"},
+		{"unlock", func(c *Client, userID uuid.UUID) error {
+			return c.UnlockAccount(context.Background(), userID)
+		}, prefix + "/unlock"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, srv := newFakeAdmin(t)
+			c := New(Config{BaseURL: srv.URL, AdminKey: testAdminKey})
+
+			err := tt.call(c, userID)
+			if err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+			if !reflect.DeepEqual(f.requests, []string{tt.want}) {
+				t.Errorf("requests = %v, want [%s]", f.requests, tt.want)
+			}
+
+			err = tt.call(c, f.unknown)
+			if !errors.Is(err, ErrAccountNotFound) {
+				t.Errorf("unknown account error = %v, want %v", err, ErrAccountNotFound)
+			}
+
+			err = tt.call(New(Config{BaseURL: srv.URL, AdminKey: "wrong"}), userID)
+			if !errors.Is(err, ErrInvalidAdminKey) {
+				t.Errorf("wrong key error = %v, want %v", err, ErrInvalidAdminKey)
+			}
+
+			err = tt.call(New(Config{BaseURL: srv.URL}), userID)
+			if !errors.Is(err, ErrInvalidAdminKey) {
+				t.Errorf("no key error = %v, want %v", err, ErrInvalidAdminKey)
+			}
+		})
+	}
+}
+
+func TestListAuthEvents(t *testing.T) {
+	f, srv := newFakeAdmin(t)
+	c := New(Config{BaseURL: srv.URL, AdminKey: testAdminKey})
+
+	userID := uuid.New()
+	from := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	resp, err := c.ListAuthEvents(context.Background(), AuthEventFilter{
+		UserID: &userID,
+		Types:  []domain.AuthEventType{domain.AuthEventSignInFailed, domain.AuthEventSignIn},
+		IP:     "203.0.113.7",
+		From:   &from,
+		Limit:  50,
+	}, "cursor-1")
+	if err != nil {
+		t.Fatalf("ListAuthEvents() error = %v", err)
+	}
+
+	if resp.NextCursor != "next" || len(resp.Events) != 1 || resp.Events[0].ID != 7 {
+		t.Errorf("ListAuthEvents() = %+v, want the fake page", resp)
+	}
+
+	want := url.Values{
+		"userID": {userID.String()},
+		"type":   {string(domain.AuthEventSignInFailed), string(domain.AuthEventSignIn)},
+		"ip":     {"203.0.113.7"},
+		"from":   {"2026-01-02T03:04:05Z"},
+		"limit":  {"50"},
+		"cursor": {"cursor-1"},
+	}
+	if !reflect.DeepEqual(f.query, want) {
+		t.Errorf("query = %v, want %v", f.query, want)
+	}
+}
@@ -0,0 +1,628 @@
+package authclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/google/uuid"
+)
+
+type (
+	SignUpRequest    = domain.SignUpRequest
+	SignInRequest    = domain.SignInRequest
+	SignInMFARequest = domain.SignInMFARequest
+	Tokens           = domain.SignInResponse
+)
+
+const defaultRetryBackoff = 200 * time.Millisecond
+
+type Config struct {
+	// BaseURL of the auth service, e.g. http://auth:80.
+	BaseURL string
+	// HTTPClient is used for all calls. Defaults to a client with a 10s timeout.
+	HTTPClient *http.Client
+	// MaxRetries is the number of extra attempts for transient failures.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry; it doubles each time.
+	RetryBackoff time.Duration
+	// DeviceID identifies this installation across sessions. Keep it
+	// stable, or every sign-in is reported to the user as a new device.
+	DeviceID string
+	// AdminKey is sent with the admin methods. Leave it empty in clients
+	// that act for end users.
+	AdminKey string
+}
+
+// Client is a typed client for the auth HTTP API. After SignIn it keeps the
+// issued tokens and transparently refreshes the access token when the
+// service rejects it.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+	deviceID   string
+	adminKey   string
+
+	mu     sync.Mutex
+	tokens Tokens
+}
+
+func New(cfg Config) *Client {
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	backoff := cfg.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		httpClient: httpClient,
+		maxRetries: cfg.MaxRetries,
+		backoff:    backoff,
+		deviceID:   cfg.DeviceID,
+		adminKey:   cfg.AdminKey,
+	}
+}
+
+// Tokens returns the tokens of the current session.
+func (c *Client) Tokens() Tokens {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens
+}
+
+// SetTokens replaces the tokens of the current session, e.g. when they were
+// persisted between runs.
+func (c *Client) SetTokens(t Tokens) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens = t
+}
+
+func (c *Client) SignUp(ctx context.Context, req SignUpRequest) error {
+	return c.do(ctx, http.MethodPost, "/signup", nil, req, nil, nil)
+}
+
+// SignIn starts a session. If the user has 2FA enabled the returned Tokens
+// only carry MFARequired and MFAToken; finish with SignInMFA.
+func (c *Client) SignIn(ctx context.Context, req SignInRequest) (Tokens, error) {
+	var t Tokens
+
+	err := c.do(ctx, http.MethodPost, "/signin", nil, req, &t, nil)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if !t.MFARequired {
+		c.SetTokens(t)
+	}
+
+	return t, nil
+}
+
+// SignInMFA completes a sign-in with a TOTP code or a recovery code.
+func (c *Client) SignInMFA(ctx context.Context, req SignInMFARequest) (Tokens, error) {
+	var t Tokens
+
+	err := c.do(ctx, http.MethodPost, "/signin/mfa", nil, req, &t, nil)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	c.SetTokens(t)
+
+	return t, nil
+}
+
+// EnrollTOTP starts 2FA enrollment for the session user. 2FA is enabled
+// only after ConfirmTOTP.
+func (c *Client) EnrollTOTP(ctx context.Context) (domain.TOTPEnrollResponse, error) {
+	var resp domain.TOTPEnrollResponse
+
+	err := c.doAuthorized(ctx, http.MethodPost, "/mfa/totp/enroll", nil, &resp, nil)
+	if err != nil {
+		return domain.TOTPEnrollResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// ConfirmTOTP enables 2FA and returns the recovery codes.
+func (c *Client) ConfirmTOTP(ctx context.Context, code string) ([]string, error) {
+	var resp domain.RecoveryCodesResponse
+
+	err := c.doAuthorized(ctx, http.MethodPost, "/mfa/totp/confirm", domain.TOTPConfirmRequest{
+		Code: code,
+	}, &resp, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.RecoveryCodes, nil
+}
+
+// SignOut revokes the refresh token of the current session and forgets it.
+func (c *Client) SignOut(ctx context.Context) error {
+	t := c.Tokens()
+	if t.RefreshToken == "" {
+		return ErrNoSession
+	}
+
+	err := c.do(ctx, http.MethodPost, "/signout", nil, domain.SignOutRequest{
+		RefreshToken: t.RefreshToken,
+	}, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	c.SetTokens(Tokens{})
+
+	return nil
+}
+
+// Refresh exchanges the current refresh token for a new token pair.
+func (c *Client) Refresh(ctx context.Context) (Tokens, error) {
+	t := c.Tokens()
+	if t.RefreshToken == "" {
+		return Tokens{}, ErrNoSession
+	}
+
+	var newTokens Tokens
+
+	err := c.do(ctx, http.MethodPost, "/refresh", nil, domain.RefreshRequest{
+		RefreshToken: t.RefreshToken,
+	}, &newTokens, nil)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	c.SetTokens(newTokens)
+
+	return newTokens, nil
+}
+
+// Check validates the session access token and returns its user ID.
+func (c *Client) Check(ctx context.Context) (uuid.UUID, error) {
+	var userID uuid.UUID
+
+	err := c.doAuthorized(ctx, http.MethodGet, "/check", nil, nil, func(h http.Header) error {
+		var err error
+		userID, err = uuid.Parse(h.Get("X-User-Id"))
+		return err
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return userID, nil
+}
+
+// CheckToken validates an arbitrary access token without touching the
+// session.
+func (c *Client) CheckToken(ctx context.Context, accessToken string) (uuid.UUID, error) {
+	var userID uuid.UUID
+
+	err := c.do(ctx, http.MethodGet, "/check", bearer(accessToken), nil, nil, func(h http.Header) error {
+		var err error
+		userID, err = uuid.Parse(h.Get("X-User-Id"))
+		return err
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return userID, nil
+}
+
+// RequestMagicLink asks the service to email a sign-in link and returns the
+// nonce that must accompany it in VerifyMagicLink.
+func (c *Client) RequestMagicLink(ctx context.Context, email string) (string, error) {
+	var resp domain.MagicLinkResponse
+
+	err := c.do(ctx, http.MethodPost, "/signin/magic-link", nil, domain.MagicLinkRequest{
+		Email: email,
+	}, &resp, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Nonce, nil
+}
+
+// VerifyMagicLink signs in with a magic link token. Like SignIn it may
+// return an MFA challenge instead of tokens.
+func (c *Client) VerifyMagicLink(ctx context.Context, token, nonce string) (Tokens, error) {
+	var t Tokens
+
+	err := c.do(ctx, http.MethodPost, "/signin/magic-link/verify", nil, domain.MagicLinkVerifyRequest{
+		Token: token,
+		Nonce: nonce,
+	}, &t, nil)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if !t.MFARequired {
+		c.SetTokens(t)
+	}
+
+	return t, nil
+}
+
+// RequestEmailOTP asks the service to email a sign-in code.
+func (c *Client) RequestEmailOTP(ctx context.Context, email string) error {
+	return c.do(ctx, http.MethodPost, "/signin/email-otp", nil, domain.EmailOTPRequest{
+		Email: email,
+	}, nil, nil)
+}
+
+// VerifyEmailOTP signs in with an emailed code. Like SignIn it may return
+// an MFA challenge instead of tokens.
+func (c *Client) VerifyEmailOTP(ctx context.Context, email, code string) (Tokens, error) {
+	var t Tokens
+
+	err := c.do(ctx, http.MethodPost, "/signin/email-otp/verify", nil, domain.EmailOTPVerifyRequest{
+		Email: email,
+		Code:  code,
+	}, &t, nil)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if !t.MFARequired {
+		c.SetTokens(t)
+	}
+
+	return t, nil
+}
+
+// PasskeySignInOptions returns the options to pass to
+// navigator.credentials.get() for a passwordless sign-in.
+func (c *Client) PasskeySignInOptions(ctx context.Context) (domain.PasskeyRequestOptions, error) {
+	var opts domain.PasskeyRequestOptions
+
+	err := c.do(ctx, http.MethodPost, "/signin/passkey/options", nil, nil, &opts, nil)
+	if err != nil {
+		return domain.PasskeyRequestOptions{}, err
+	}
+
+	return opts, nil
+}
+
+// SignInPasskey starts a session with a passkey assertion.
+func (c *Client) SignInPasskey(ctx context.Context, credential domain.PasskeyAssertion) (Tokens, error) {
+	var t Tokens
+
+	err := c.do(ctx, http.MethodPost, "/signin/passkey", nil, domain.SignInPasskeyRequest{
+		Credential: credential,
+	}, &t, nil)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	c.SetTokens(t)
+
+	return t, nil
+}
+
+// MFAPasskeyOptions returns the options for answering an MFA challenge with
+// a passkey.
+func (c *Client) MFAPasskeyOptions(ctx context.Context, mfaToken string) (domain.PasskeyRequestOptions, error) {
+	var opts domain.PasskeyRequestOptions
+
+	err := c.do(ctx, http.MethodPost, "/signin/mfa/passkey/options", nil, domain.MFAPasskeyOptionsRequest{
+		MFAToken: mfaToken,
+	}, &opts, nil)
+	if err != nil {
+		return domain.PasskeyRequestOptions{}, err
+	}
+
+	return opts, nil
+}
+
+func (c *Client) PasskeyRegisterOptions(ctx context.Context) (domain.PasskeyCreationOptions, error) {
+	var opts domain.PasskeyCreationOptions
+
+	err := c.doAuthorized(ctx, http.MethodPost, "/passkeys/register/options", nil, &opts, nil)
+	if err != nil {
+		return domain.PasskeyCreationOptions{}, err
+	}
+
+	return opts, nil
+}
+
+func (c *Client) RegisterPasskey(ctx context.Context, req domain.PasskeyRegisterRequest) (domain.Passkey, error) {
+	var p domain.Passkey
+
+	err := c.doAuthorized(ctx, http.MethodPost, "/passkeys/register", req, &p, nil)
+	if err != nil {
+		return domain.Passkey{}, err
+	}
+
+	return p, nil
+}
+
+func (c *Client) ListPasskeys(ctx context.Context) ([]domain.Passkey, error) {
+	var resp domain.PasskeysResponse
+
+	err := c.doAuthorized(ctx, http.MethodGet, "/passkeys", nil, &resp, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Passkeys, nil
+}
+
+// DeletePasskey removes a passkey by its base64url credential id.
+func (c *Client) DeletePasskey(ctx context.Context, id string) error {
+	return c.doAuthorized(ctx, http.MethodDelete, "/passkeys/"+url.PathEscape(id), nil, nil, nil)
+}
+
+func (c *Client) VerifyEmail(ctx context.Context, token string) error {
+	return c.do(ctx, http.MethodPost, "/email/verify", nil, domain.VerifyEmailRequest{
+		Token: token,
+	}, nil, nil)
+}
+
+func (c *Client) ResendVerification(ctx context.Context, email string) error {
+	return c.do(ctx, http.MethodPost, "/email/verify/resend", nil, domain.ResendVerificationRequest{
+		Email: email,
+	}, nil, nil)
+}
+
+// ChangePassword changes the password of the session user. The service
+// keeps the current session and signs out all others.
+func (c *Client) ChangePassword(ctx context.Context, currentPassword, newPassword string) error {
+	return c.doAuthorized(ctx, http.MethodPost, "/password/change", domain.ChangePasswordRequest{
+		CurrentPassword: currentPassword,
+		NewPassword:     newPassword,
+	}, nil, nil)
+}
+
+// ChangeEmail asks the service to send a confirmation link to newEmail.
+func (c *Client) ChangeEmail(ctx context.Context, password, newEmail string) error {
+	return c.doAuthorized(ctx, http.MethodPost, "/email/change", domain.ChangeEmailRequest{
+		Password: password,
+		NewEmail: newEmail,
+	}, nil, nil)
+}
+
+// DeleteAccount schedules the account for deletion. Every session is
+// revoked, so the client's tokens are cleared.
+func (c *Client) DeleteAccount(ctx context.Context, password string) (domain.DeleteAccountResponse, error) {
+	var resp domain.DeleteAccountResponse
+
+	err := c.doAuthorized(ctx, http.MethodDelete, "/account", domain.DeleteAccountRequest{
+		Password: password,
+	}, &resp, nil)
+	if err != nil {
+		return domain.DeleteAccountResponse{}, err
+	}
+
+	c.SetTokens(Tokens{})
+
+	return resp, nil
+}
+
+// SecureAccount acts on the link of a new-device email: every session is
+// revoked and the password must be reset before the next password sign-in.
+func (c *Client) SecureAccount(ctx context.Context, token string) error {
+	return c.do(ctx, http.MethodPost, "/account/secure", nil, domain.SecureAccountRequest{
+		Token: token,
+	}, nil, nil)
+}
+
+// LoginHistory returns a page of recent sign-ins, newest first. Pass the
+// NextCursor of the previous page to continue; limit 0 uses the server
+// default.
+func (c *Client) LoginHistory(ctx context.Context, limit int, cursor string) (domain.LoginHistoryResponse, error) {
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	if cursor != "" {
+		q.Set("cursor", cursor)
+	}
+
+	path := "/account/login-history"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	var resp domain.LoginHistoryResponse
+
+	err := c.doAuthorized(ctx, http.MethodGet, path, nil, &resp, nil)
+	if err != nil {
+		return domain.LoginHistoryResponse{}, err
+	}
+
+	return resp, nil
+}
+
+func (c *Client) ConfirmEmailChange(ctx context.Context, token string) error {
+	return c.do(ctx, http.MethodPost, "/email/change/confirm", nil, domain.EmailChangeTokenRequest{
+		Token: token,
+	}, nil, nil)
+}
+
+func (c *Client) RevertEmailChange(ctx context.Context, token string) error {
+	return c.do(ctx, http.MethodPost, "/email/change/revert", nil, domain.EmailChangeTokenRequest{
+		Token: token,
+	}, nil, nil)
+}
+
+func (c *Client) ForgotPassword(ctx context.Context, email string) error {
+	return c.do(ctx, http.MethodPost, "/password/forgot", nil, domain.ForgotPasswordRequest{
+		Email: email,
+	}, nil, nil)
+}
+
+func (c *Client) ResetPassword(ctx context.Context, resetToken, newPassword string) error {
+	return c.do(ctx, http.MethodPost, "/password/reset", nil, domain.ResetPasswordRequest{
+		Token:    resetToken,
+		Password: newPassword,
+	}, nil, nil)
+}
+
+// doAuthorized performs a request with the session access token and retries
+// it once with a refreshed token if the service rejects the current one.
+func (c *Client) doAuthorized(ctx context.Context, method, path string, body, out any, onHeader func(http.Header) error) error {
+	t := c.Tokens()
+	if t.AccessToken == "" {
+		return ErrNoSession
+	}
+
+	err := c.do(ctx, method, path, bearer(t.AccessToken), body, out, onHeader)
+	if !errors.Is(err, ErrInvalidAccessToken) || t.RefreshToken == "" {
+		return err
+	}
+
+	t, err = c.Refresh(ctx)
+	if err != nil {
+		return fmt.Errorf("refresh: %w", err)
+	}
+
+	return c.do(ctx, method, path, bearer(t.AccessToken), body, out, onHeader)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, header http.Header, body, out any, onHeader func(http.Header) error) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+	}
+
+	backoff := c.backoff
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, path, header, payload)
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			defer resp.Body.Close()
+			return handleResponse(resp, out, onHeader)
+		}
+
+		if attempt >= c.maxRetries || (err != nil && !retryableMethod(method)) {
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrInternal, err)
+			}
+			defer resp.Body.Close()
+			return handleResponse(resp, out, onHeader)
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, path string, header http.Header, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if c.deviceID != "" {
+		req.Header.Set("X-Device-Id", c.deviceID)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return c.httpClient.Do(req)
+}
+
+func handleResponse(resp *http.Response, out any, onHeader func(http.Header) error) error {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return decodeError(resp)
+	}
+
+	if onHeader != nil {
+		err := onHeader(resp.Header)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInternal, err)
+		}
+	}
+
+	if out != nil {
+		err := json.NewDecoder(resp.Body).Decode(out)
+		if err != nil {
+			return fmt.Errorf("%w: decode response: %s", ErrInternal, err)
+		}
+	}
+
+	return nil
+}
+
+func decodeError(resp *http.Response) error {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+	}
+
+	var body ErrResp
+	if json.Unmarshal(raw, &body) == nil && body.Error != "" {
+		apiErr.Code = body.Error
+		apiErr.Details = body.Details
+		apiErr.Fields = body.Fields
+		apiErr.RetryAfter = time.Duration(body.RetryAfter) * time.Second
+	} else {
+		apiErr.Details = strings.TrimSpace(string(raw))
+	}
+
+	return apiErr
+}
+
+func retryableStatus(status int) bool {
+	return status == http.StatusBadGateway ||
+		status == http.StatusServiceUnavailable ||
+		status == http.StatusGatewayTimeout
+}
+
+// retryableMethod reports whether a request can be repeated after a
+// transport error, when it is unknown whether the server processed it.
+func retryableMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+func bearer(token string) http.Header {
+	return http.Header{
+		"Authorization": []string{"Bearer " + token},
+	}
+}
@@ -0,0 +1,328 @@
+package authclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/google/uuid"
+)
+
+// fakeAuth issues numbered token pairs and accepts only the latest one, as
+// the auth service does after a rotation.
+type fakeAuth struct {
+	userID uuid.UUID
+
+	mu      sync.Mutex
+	access  string
+	refresh string
+	issued  int
+	// rejectRefreshed makes /check refuse even freshly refreshed tokens.
+	rejectRefreshed bool
+	failSignOut     bool
+	calls           map[string]int
+}
+
+func newFakeAuth(t *testing.T) (*fakeAuth, *httptest.Server) {
+	t.Helper()
+
+	f := &fakeAuth{
+		userID: uuid.New(),
+		calls:  make(map[string]int),
+	}
+	f.issue()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /refresh", f.handleRefresh)
+	mux.HandleFunc("GET /check", f.handleCheck)
+	mux.HandleFunc("POST /signout", f.handleSignOut)
+	mux.HandleFunc("DELETE /account", f.handleDeleteAccount)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.calls[r.Method+" "+r.URL.Path]++
+		f.mu.Unlock()
+
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	return f, srv
+}
+
+// issue rotates the valid pair. The caller holds mu or owns f.
+func (f *fakeAuth) issue() Tokens {
+	f.issued++
+	f.access = "access-" + strconv.Itoa(f.issued)
+	f.refresh = "refresh-" + strconv.Itoa(f.issued)
+	return Tokens{AccessToken: f.access, RefreshToken: f.refresh}
+}
+
+func (f *fakeAuth) tokens() Tokens {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return Tokens{AccessToken: f.access, RefreshToken: f.refresh}
+}
+
+func (f *fakeAuth) callCount(route string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[route]
+}
+
+func (f *fakeAuth) authorized(r *http.Request) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.rejectRefreshed && f.issued > 1 {
+		return false
+	}
+	return r.Header.Get("Authorization") == "Bearer "+f.access
+}
+
+func (f *fakeAuth) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	var req domain.RefreshRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if req.RefreshToken != f.refresh {
+		writeTestErr(w, http.StatusUnauthorized, CodeInvalidRefreshToken)
+		return
+	}
+
+	writeTestJSON(w, http.StatusOK, f.issue())
+}
+
+func (f *fakeAuth) handleCheck(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(r) {
+		writeTestErr(w, http.StatusUnauthorized, CodeInvalidAccessToken)
+		return
+	}
+
+	w.Header().Set("X-User-Id", f.userID.String())
+	w.WriteHeader(http.StatusOK)
+}
+
+func (f *fakeAuth) handleSignOut(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	fail := f.failSignOut
+	f.mu.Unlock()
+
+	if fail {
+		writeTestErr(w, http.StatusInternalServerError, CodeInternalError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeAuth) handleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(r) {
+		writeTestErr(w, http.StatusUnauthorized, CodeInvalidAccessToken)
+		return
+	}
+
+	var req domain.DeleteAccountRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	if req.Password != "secret" {
+		writeTestErr(w, http.StatusUnauthorized, CodeInvalidCurrentPassword)
+		return
+	}
+
+	writeTestJSON(w, http.StatusOK, domain.DeleteAccountResponse{
+		PurgeAfter: time.Now().Add(30 * 24 * time.Hour),
+	})
+}
+
+func writeTestJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeTestErr(w http.ResponseWriter, status int, code string) {
+	writeTestJSON(w, status, ErrResp{Error: code, Details: code})
+}
+
+func TestDoAuthorizedRefresh(t *testing.T) {
+	tests := []struct {
+		name string
+		// session returns the tokens the client starts with
+		session         func(valid Tokens) Tokens
+		rejectRefreshed bool
+		wantErr         error
+		wantChecks      int
+		wantRefreshes   int
+		// wantRotated means the client ends up with the second pair
+		wantRotated bool
+	}{
+		{
+			name:       "valid access token",
+			session:    func(valid Tokens) Tokens { return valid },
+			wantChecks: 1,
+		},
+		{
+			name: "expired access token",
+			session: func(valid Tokens) Tokens {
+				return Tokens{AccessToken: "expired", RefreshToken: valid.RefreshToken}
+			},
+			wantChecks:    2,
+			wantRefreshes: 1,
+			wantRotated:   true,
+		},
+		{
+			name: "revoked refresh token",
+			session: func(valid Tokens) Tokens {
+				return Tokens{AccessToken: "expired", RefreshToken: "revoked"}
+			},
+			wantErr:       ErrInvalidRefreshToken,
+			wantChecks:    1,
+			wantRefreshes: 1,
+		},
+		{
+			name: "no refresh token",
+			session: func(valid Tokens) Tokens {
+				return Tokens{AccessToken: "expired"}
+			},
+			wantErr:    ErrInvalidAccessToken,
+			wantChecks: 1,
+		},
+		{
+			name: "refreshed token rejected too",
+			session: func(valid Tokens) Tokens {
+				return Tokens{AccessToken: "expired", RefreshToken: valid.RefreshToken}
+			},
+			rejectRefreshed: true,
+			wantErr:         ErrInvalidAccessToken,
+			wantChecks:      2,
+			wantRefreshes:   1,
+			wantRotated:     true,
+		},
+		{
+			name:    "no session",
+			session: func(valid Tokens) Tokens { return Tokens{} },
+			wantErr: ErrNoSession,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, srv := newFakeAuth(t)
+			f.rejectRefreshed = tt.rejectRefreshed
+
+			c := New(Config{BaseURL: srv.URL})
+			c.SetTokens(tt.session(f.tokens()))
+
+			userID, err := c.Check(context.Background())
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+				t.Fatalf("Check() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && userID != f.userID {
+				t.Errorf("Check() = %s, want %s", userID, f.userID)
+			}
+
+			if n := f.callCount("GET /check"); n != tt.wantChecks {
+				t.Errorf("/check called %d times, want %d", n, tt.wantChecks)
+			}
+			if n := f.callCount("POST /refresh"); n != tt.wantRefreshes {
+				t.Errorf("/refresh called %d times, want %d", n, tt.wantRefreshes)
+			}
+
+			if tt.wantRotated && !reflect.DeepEqual(c.Tokens(), f.tokens()) {
+				t.Errorf("Tokens() = %+v, want rotated pair %+v", c.Tokens(), f.tokens())
+			}
+		})
+	}
+}
+
+func TestTokensCleared(t *testing.T) {
+	tests := []struct {
+		name        string
+		failSignOut bool
+		call        func(c *Client) error
+		wantErr     error
+		wantCleared bool
+	}{
+		{
+			name: "sign out",
+			call: func(c *Client) error {
+				return c.SignOut(context.Background())
+			},
+			wantCleared: true,
+		},
+		{
+			name:        "failed sign out",
+			failSignOut: true,
+			call: func(c *Client) error {
+				return c.SignOut(context.Background())
+			},
+			wantErr: ErrInternal,
+		},
+		{
+			name: "delete account",
+			call: func(c *Client) error {
+				_, err := c.DeleteAccount(context.Background(), "secret")
+				return err
+			},
+			wantCleared: true,
+		},
+		{
+			name: "delete account with wrong password",
+			call: func(c *Client) error {
+				_, err := c.DeleteAccount(context.Background(), "wrong")
+				return err
+			},
+			wantErr: ErrInvalidCurrentPassword,
+		},
+		{
+			name: "check",
+			call: func(c *Client) error {
+				_, err := c.Check(context.Background())
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, srv := newFakeAuth(t)
+			f.failSignOut = tt.failSignOut
+
+			c := New(Config{BaseURL: srv.URL})
+			session := f.tokens()
+			c.SetTokens(session)
+
+			err := tt.call(c)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			want := session
+			if tt.wantCleared {
+				want = Tokens{}
+			}
+			if got := c.Tokens(); !reflect.DeepEqual(got, want) {
+				t.Errorf("Tokens() = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	t.Run("sign out without session", func(t *testing.T) {
+		_, srv := newFakeAuth(t)
+
+		err := New(Config{BaseURL: srv.URL}).SignOut(context.Background())
+		if !errors.Is(err, ErrNoSession) {
+			t.Fatalf("SignOut() error = %v, want ErrNoSession", err)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package authclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+)
+
+// Error codes returned by the auth service in the "error" field.
+const (
+	CodeEmailExists         = "email_exists"
+	CodeUsernameExists      = "username_exists"
+	CodeUnknownConflict     = "unknown_conflict"
+	CodeInternalError       = "internal_error"
+	CodeInvalidAccessToken  = "invalid_access_token"
+	CodeInvalidRefreshToken = "invalid_refresh_token"
+	CodeInvalidCredentials  = "invalid_credentials"
+
+	CodeInvalidVerificationToken = "invalid_verification_token"
+	CodeEmailNotVerified         = "email_not_verified"
+	CodeInvalidResetToken        = "invalid_reset_token"
+	CodeInvalidRequest           = "invalid_request"
+	CodeInvalidCurrentPassword   = "invalid_current_password"
+	CodeInvalidEmailChangeToken  = "invalid_email_change_token"
+	CodeValidationFailed         = "validation_failed"
+	CodeAccountLocked            = "account_locked"
+	CodeRateLimited              = "rate_limited"
+	CodeMFAUnavailable           = "mfa_unavailable"
+	CodeMFANotEnrolled           = "mfa_not_enrolled"
+	CodeMFAAlreadyEnabled        = "mfa_already_enabled"
+	CodeInvalidMFACode           = "invalid_mfa_code"
+	CodeInvalidMFAToken          = "invalid_mfa_token"
+	CodeInvalidMagicLink         = "invalid_magic_link"
+	CodeInvalidEmailOTP          = "invalid_email_otp"
+	CodeAccountPendingDeletion   = "account_pending_deletion"
+	CodeAccountSuspended         = "account_suspended"
+	CodeAccountDisabled          = "account_disabled"
+	CodePasskeysUnavailable      = "passkeys_unavailable"
+	CodeInvalidPasskey           = "invalid_passkey"
+	CodePasskeyNotFound          = "passkey_not_found"
+	CodePasskeyExists            = "passkey_exists"
+	CodePasswordResetRequired    = "password_reset_required"
+	CodeInvalidSecureToken       = "invalid_secure_token"
+	CodeInvalidAdminKey          = "invalid_admin_key"
+	CodeAccountNotFound          = "account_not_found"
+)
+
+var (
+	ErrEmailExists         = errors.New("email already exists")
+	ErrUsernameExists      = errors.New("username already exists")
+	ErrUnknownConflict     = errors.New("unknown conflict")
+	ErrInvalidAccessToken  = errors.New("invalid access token")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrInvalidCredentials  = errors.New("invalid email or password")
+
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrEmailNotVerified         = errors.New("email not verified")
+	ErrInvalidResetToken        = errors.New("invalid or expired reset token")
+	ErrInvalidCurrentPassword   = errors.New("invalid current password")
+	ErrInvalidEmailChangeToken  = errors.New("invalid or expired email change token")
+	ErrAccountLocked            = errors.New("account locked")
+	ErrRateLimited              = errors.New("rate limited")
+	ErrMFAUnavailable           = errors.New("mfa unavailable")
+	ErrMFANotEnrolled           = errors.New("mfa not enrolled")
+	ErrMFAAlreadyEnabled        = errors.New("mfa already enabled")
+	ErrInvalidMFACode           = errors.New("invalid mfa code")
+	ErrInvalidMFAToken          = errors.New("invalid or expired mfa token")
+	ErrInvalidMagicLink         = errors.New("invalid or expired magic link")
+	ErrInvalidEmailOTP          = errors.New("invalid or expired email code")
+	ErrAccountPendingDeletion   = errors.New("account is scheduled for deletion")
+	ErrAccountSuspended         = errors.New("account suspended")
+	ErrAccountDisabled          = errors.New("account disabled")
+	ErrPasskeysUnavailable      = errors.New("passkeys unavailable")
+	ErrInvalidPasskey           = errors.New("invalid passkey response")
+	ErrPasskeyNotFound          = errors.New("passkey not found")
+	ErrPasskeyExists            = errors.New("passkey already registered")
+	ErrPasswordResetRequired    = errors.New("password reset required")
+	ErrInvalidSecureToken       = errors.New("invalid or expired secure account token")
+	ErrInvalidAdminKey          = errors.New("invalid admin key")
+	ErrAccountNotFound          = errors.New("account not found")
+
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrInternal     = errors.New("internal error")
+	ErrNoSession    = errors.New("no session")
+)
+
+var codeToErr = map[string]error{
+	CodeEmailExists:         ErrEmailExists,
+	CodeUsernameExists:      ErrUsernameExists,
+	CodeUnknownConflict:     ErrUnknownConflict,
+	CodeInternalError:       ErrInternal,
+	CodeInvalidAccessToken:  ErrInvalidAccessToken,
+	CodeInvalidRefreshToken: ErrInvalidRefreshToken,
+	CodeInvalidCredentials:  ErrInvalidCredentials,
+
+	CodeInvalidVerificationToken: ErrInvalidVerificationToken,
+	CodeEmailNotVerified:         ErrEmailNotVerified,
+	CodeInvalidResetToken:        ErrInvalidResetToken,
+	CodeInvalidRequest:           ErrBadRequest,
+	CodeInvalidCurrentPassword:   ErrInvalidCurrentPassword,
+	CodeInvalidEmailChangeToken:  ErrInvalidEmailChangeToken,
+	CodeValidationFailed:         ErrBadRequest,
+	CodeAccountLocked:            ErrAccountLocked,
+	CodeRateLimited:              ErrRateLimited,
+	CodeMFAUnavailable:           ErrMFAUnavailable,
+	CodeMFANotEnrolled:           ErrMFANotEnrolled,
+	CodeMFAAlreadyEnabled:        ErrMFAAlreadyEnabled,
+	CodeInvalidMFACode:           ErrInvalidMFACode,
+	CodeInvalidMFAToken:          ErrInvalidMFAToken,
+	CodeInvalidMagicLink:         ErrInvalidMagicLink,
+	CodeInvalidEmailOTP:          ErrInvalidEmailOTP,
+	CodeAccountPendingDeletion:   ErrAccountPendingDeletion,
+	CodeAccountSuspended:         ErrAccountSuspended,
+	CodeAccountDisabled:          ErrAccountDisabled,
+	CodePasskeysUnavailable:      ErrPasskeysUnavailable,
+	CodeInvalidPasskey:           ErrInvalidPasskey,
+	CodePasskeyNotFound:          ErrPasskeyNotFound,
+	CodePasskeyExists:            ErrPasskeyExists,
+	CodePasswordResetRequired:    ErrPasswordResetRequired,
+	CodeInvalidSecureToken:       ErrInvalidSecureToken,
+	CodeInvalidAdminKey:          ErrInvalidAdminKey,
+	CodeAccountNotFound:          ErrAccountNotFound,
+}
+
+// FieldError describes one violated validation rule.
+type FieldError = domain.FieldError
+
+// ErrResp mirrors the error body written by the auth service.
+type ErrResp struct {
+	Error   string       `json:"error"`
+	Details string       `json:"details"`
+	Fields  []FieldError `json:"fields,omitempty"`
+	// RetryAfter is in seconds.
+	RetryAfter int `json:"retryAfter,omitempty"`
+}
+
+// APIError is returned for every non-successful response. It unwraps to one
+// of the package sentinel errors so callers can use errors.Is.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Details    string
+	Fields     []FieldError
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("auth api: status %d: %s", e.StatusCode, e.Details)
+	}
+	return fmt.Sprintf("auth api: status %d: %s: %s", e.StatusCode, e.Code, e.Details)
+}
+
+func (e *APIError) Unwrap() error {
+	if err, ok := codeToErr[e.Code]; ok {
+		return err
+	}
+
+	switch {
+	case e.StatusCode == http.StatusBadRequest:
+		return ErrBadRequest
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrInternal
+	default:
+		return nil
+	}
+}
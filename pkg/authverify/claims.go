@@ -0,0 +1,48 @@
+package authverify
+
+import (
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// Response headers of the auth service GET /check endpoint.
+const (
+	HeaderUserID = "X-User-Id"
+	// HeaderRestricted is "true" or "false".
+	HeaderRestricted = "X-Token-Restricted"
+	// HeaderScopes lists the scopes of a restricted token, comma separated.
+	HeaderScopes = "X-Token-Scopes"
+)
+
+// Claims is the JWT payload issued by the auth service. The server signs
+// tokens with this exact type, so verifiers and issuer cannot drift apart.
+type Claims struct {
+	UserID    uuid.UUID `json:"userID"`
+	TokenType string    `json:"typ,omitempty"`
+	// SessionID is shared by the access and refresh tokens of one sign-in.
+	SessionID string `json:"sid,omitempty"`
+	// Restricted tokens may only be used for the operations in Scopes.
+	Restricted bool     `json:"restricted,omitempty"`
+	Scopes     []string `json:"scopes,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// IsAccess reports whether the claims belong to an access token. Tokens
+// issued before the typ claim was introduced have no type and are treated
+// as access tokens.
+func (c Claims) IsAccess() bool {
+	return c.TokenType == "" || c.TokenType == TokenTypeAccess
+}
+
+// HasScope reports whether the token may be used for scope. Unrestricted
+// tokens allow every scope.
+func (c Claims) HasScope(scope string) bool {
+	return !c.Restricted || slices.Contains(c.Scopes, scope)
+}
@@ -0,0 +1,12 @@
+package authverify
+
+import "errors"
+
+var (
+	ErrInvalidToken   = errors.New("invalid access token")
+	ErrKeyUnavailable = errors.New("verification key unavailable")
+	ErrNotConfigured  = errors.New("verifier not configured")
+	// ErrRevocationUnavailable means the revocation list could not be read,
+	// so the token is neither accepted nor known to be revoked.
+	ErrRevocationUnavailable = errors.New("revocation list unavailable")
+)
@@ -0,0 +1,226 @@
+package authverify
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultJWKSRefreshInterval = 10 * time.Minute
+	minJWKSRefreshInterval     = 30 * time.Second
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// jwksCache keeps the public keys of a JWKS endpoint in memory. Keys are
+// refreshed periodically in the background and on demand when a token
+// references an unknown key id.
+type jwksCache struct {
+	url      string
+	client   *http.Client
+	interval time.Duration
+	group    singleflight.Group
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	lastRefresh time.Time
+	// lastMiss is when an unknown key id last caused a refresh, whether it
+	// succeeded or not.
+	lastMiss time.Time
+}
+
+func newJWKSCache(url string, client *http.Client, interval time.Duration) *jwksCache {
+	if interval <= 0 {
+		interval = defaultJWKSRefreshInterval
+	}
+	return &jwksCache{
+		url:      url,
+		client:   client,
+		interval: interval,
+		keys:     map[string]crypto.PublicKey{},
+	}
+}
+
+func (c *jwksCache) run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := c.refresh(ctx)
+			if err != nil {
+				log.Printf("authverify: jwks refresh: %s", err)
+			}
+		}
+	}
+}
+
+func (c *jwksCache) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	c.mu.RLock()
+	key, ok := c.keys[kid]
+	c.mu.RUnlock()
+
+	if ok {
+		return key, nil
+	}
+
+	err := c.refreshOnMiss(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrKeyUnavailable, err)
+	}
+
+	c.mu.RLock()
+	key, ok = c.keys[kid]
+	c.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key id %q", ErrKeyUnavailable, kid)
+	}
+
+	return key, nil
+}
+
+// refreshOnMiss refreshes the keys for an unknown key id. Concurrent misses
+// share one fetch, and no fetch starts within minJWKSRefreshInterval of the
+// previous one, failed or not, so tokens with made-up key ids cannot flood
+// the JWKS endpoint.
+func (c *jwksCache) refreshOnMiss(ctx context.Context) error {
+	_, err, _ := c.group.Do("", func() (any, error) {
+		c.mu.Lock()
+		last := c.lastMiss
+		if c.lastRefresh.After(last) {
+			last = c.lastRefresh
+		}
+		throttled := time.Since(last) < minJWKSRefreshInterval
+		if !throttled {
+			c.lastMiss = time.Now()
+		}
+		c.mu.Unlock()
+
+		if throttled {
+			return nil, nil
+		}
+		return nil, c.refresh(ctx)
+	})
+	return err
+}
+
+func (c *jwksCache) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var set jwkSet
+	err = json.NewDecoder(resp.Body).Decode(&set)
+	if err != nil {
+		return fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			log.Printf("authverify: skip jwk %q: %s", k.Kid, err)
+			continue
+		}
+
+		keys[k.Kid] = key
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.lastRefresh = time.Now()
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("exponent: %w", err)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,65 @@
+package authverify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJWKSUnknownKeyRefresh(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"endpoint up", http.StatusOK},
+		{"endpoint down", http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetches atomic.Int32
+			release := make(chan struct{})
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fetches.Add(1)
+				<-release
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"keys":[]}`))
+			}))
+			defer srv.Close()
+
+			c := newJWKSCache(srv.URL, srv.Client(), time.Hour)
+
+			var wg sync.WaitGroup
+			for range 20 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := c.key(context.Background(), "made-up")
+					if !errors.Is(err, ErrKeyUnavailable) {
+						t.Errorf("key() error = %v, want %v", err, ErrKeyUnavailable)
+					}
+				}()
+			}
+
+			// let the lookups pile up on the first fetch
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			_, err := c.key(context.Background(), "another")
+			if !errors.Is(err, ErrKeyUnavailable) {
+				t.Errorf("key() error = %v, want %v", err, ErrKeyUnavailable)
+			}
+
+			if got := fetches.Load(); got != 1 {
+				t.Errorf("fetches = %d, want 1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package authverify
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+const (
+	errCodeInvalidAccessToken = "invalid_access_token"
+	errCodeInsufficientScope  = "insufficient_scope"
+	errCodeInternal           = "internal_error"
+)
+
+type errResp struct {
+	Error   string `json:"error"`
+	Details string `json:"details"`
+}
+
+// Middleware rejects requests without a valid access token and stores the
+// verified claims in the request context for the next handler.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := BearerToken(r.Header.Get("Authorization"))
+		if err != nil {
+			writeErr(w, http.StatusUnauthorized, errCodeInvalidAccessToken, ErrInvalidToken)
+			return
+		}
+
+		claims, err := v.Verify(r.Context(), token)
+		if err != nil {
+			if errors.Is(err, ErrInvalidToken) {
+				writeErr(w, http.StatusUnauthorized, errCodeInvalidAccessToken, ErrInvalidToken)
+				return
+			}
+
+			log.Printf("authverify: %s", err)
+			writeErr(w, http.StatusInternalServerError, errCodeInternal, errors.New("internal error"))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+	})
+}
+
+// RequireScope returns a middleware that rejects restricted tokens without
+// the given scope. It must run after Middleware.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				writeErr(w, http.StatusUnauthorized, errCodeInvalidAccessToken, ErrInvalidToken)
+				return
+			}
+
+			if !claims.HasScope(scope) {
+				writeErr(w, http.StatusForbidden, errCodeInsufficientScope, errors.New("insufficient scope"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeErr(w http.ResponseWriter, status int, code string, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errResp{
+		Error:   code,
+		Details: err.Error(),
+	})
+}
@@ -0,0 +1,44 @@
+package authverify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevocationList tells when the access tokens of a user were last revoked,
+// for example after a password reset or a suspension. The auth service
+// keeps this in Redis, see repo/token/redis.RevocationRepo.
+type RevocationList interface {
+	// RevokedBefore returns the zero time if nothing was revoked.
+	RevokedBefore(ctx context.Context, userID uuid.UUID) (time.Time, error)
+}
+
+// Revoked reports whether c was issued at or before revokedBefore. iat has
+// a resolution of one second, so a token from the second of the revocation
+// is rejected too.
+func Revoked(c Claims, revokedBefore time.Time) bool {
+	if revokedBefore.IsZero() {
+		return false
+	}
+	return c.IssuedAt == nil || !c.IssuedAt.After(revokedBefore)
+}
+
+func (v *Verifier) checkRevoked(ctx context.Context, c Claims) error {
+	if v.revocations == nil {
+		return nil
+	}
+
+	revokedBefore, err := v.revocations.RevokedBefore(ctx, c.UserID)
+	if err != nil {
+		return fmt.Errorf("%w: revocation list: %s", ErrRevocationUnavailable, err)
+	}
+
+	if Revoked(c, revokedBefore) {
+		return fmt.Errorf("%w: revoked", ErrInvalidToken)
+	}
+
+	return nil
+}
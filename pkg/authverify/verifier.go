@@ -0,0 +1,240 @@
+package authverify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Config describes where a Verifier takes its key material from. At least
+// one of Secret, JWKSURL or CheckURL must be set. When local verification
+// is impossible because no key is available, the verifier falls back to
+// the remote check endpoint if CheckURL is set.
+type Config struct {
+	// Secret is the shared HS256 secret of the auth service.
+	Secret string
+	// JWKSURL points to a JSON Web Key Set used for asymmetric tokens.
+	JWKSURL string
+	// JWKSRefreshInterval controls background refresh of the key set.
+	JWKSRefreshInterval time.Duration
+	// CheckURL is the full URL of the auth service GET /check endpoint.
+	CheckURL string
+	// HTTPClient is used for JWKS and remote check calls.
+	HTTPClient *http.Client
+	// Revocations, if set, is consulted for every locally verified token.
+	// Without it a revoked access token is accepted until it expires;
+	// tokens checked remotely are always checked for revocation.
+	Revocations RevocationList
+}
+
+type Verifier struct {
+	secret      []byte
+	jwks        *jwksCache
+	checkURL    string
+	client      *http.Client
+	revocations RevocationList
+	parser      *jwt.Parser
+}
+
+// NewVerifier builds a Verifier from cfg. If a JWKS URL is configured the
+// key set is fetched immediately and refreshed in the background until ctx
+// is done.
+func NewVerifier(ctx context.Context, cfg Config) (*Verifier, error) {
+	if cfg.Secret == "" && cfg.JWKSURL == "" && cfg.CheckURL == "" {
+		return nil, ErrNotConfigured
+	}
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	v := &Verifier{
+		checkURL:    cfg.CheckURL,
+		client:      client,
+		revocations: cfg.Revocations,
+	}
+
+	var methods []string
+
+	if cfg.Secret != "" {
+		v.secret = []byte(cfg.Secret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+
+	if cfg.JWKSURL != "" {
+		v.jwks = newJWKSCache(cfg.JWKSURL, client, cfg.JWKSRefreshInterval)
+		methods = append(methods,
+			jwt.SigningMethodRS256.Alg(),
+			jwt.SigningMethodRS384.Alg(),
+			jwt.SigningMethodRS512.Alg(),
+			jwt.SigningMethodES256.Alg(),
+			jwt.SigningMethodES384.Alg(),
+			jwt.SigningMethodES512.Alg(),
+		)
+
+		err := v.jwks.refresh(ctx)
+		if err != nil && v.checkURL == "" {
+			return nil, fmt.Errorf("fetch jwks: %w", err)
+		}
+
+		go v.jwks.run(ctx)
+	}
+
+	v.parser = jwt.NewParser(jwt.WithValidMethods(methods), jwt.WithExpirationRequired())
+
+	return v, nil
+}
+
+// NewSecretVerifier returns a Verifier that only checks HS256 tokens signed
+// with secret. It is what the auth service itself uses.
+func NewSecretVerifier(secret string) *Verifier {
+	return &Verifier{
+		secret: []byte(secret),
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+			jwt.WithExpirationRequired(),
+		),
+	}
+}
+
+// BearerToken extracts the token from an Authorization header value.
+func BearerToken(header string) (string, error) {
+	parts := strings.Fields(header)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "", ErrInvalidToken
+	}
+	return parts[1], nil
+}
+
+// Verify checks an access token and returns its claims.
+func (v *Verifier) Verify(ctx context.Context, token string) (Claims, error) {
+	if v.secret == nil && v.jwks == nil {
+		return v.verifyRemote(ctx, token)
+	}
+
+	c, err := v.verifyLocal(ctx, token)
+	if errors.Is(err, ErrKeyUnavailable) && v.checkURL != "" {
+		return v.verifyRemote(ctx, token)
+	}
+
+	return c, err
+}
+
+// VerifyToken parses a token with any type and returns its claims. Unlike
+// Verify it does not reject refresh tokens and never calls the remote check.
+func (v *Verifier) VerifyToken(ctx context.Context, token string) (Claims, error) {
+	var c Claims
+
+	_, err := v.parser.ParseWithClaims(token, &c, func(t *jwt.Token) (any, error) {
+		return v.keyFor(ctx, t)
+	})
+	if err != nil {
+		if errors.Is(err, ErrKeyUnavailable) {
+			return Claims{}, err
+		}
+		return Claims{}, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+
+	if c.UserID == uuid.Nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	return c, nil
+}
+
+func (v *Verifier) verifyLocal(ctx context.Context, token string) (Claims, error) {
+	c, err := v.VerifyToken(ctx, token)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if !c.IsAccess() {
+		return Claims{}, fmt.Errorf("%w: unexpected token type %q", ErrInvalidToken, c.TokenType)
+	}
+
+	err = v.checkRevoked(ctx, c)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	return c, nil
+}
+
+func (v *Verifier) keyFor(ctx context.Context, t *jwt.Token) (any, error) {
+	switch t.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if v.secret == nil {
+			return nil, fmt.Errorf("%w: no shared secret", ErrKeyUnavailable)
+		}
+		return v.secret, nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		if v.jwks == nil {
+			return nil, fmt.Errorf("%w: no jwks", ErrKeyUnavailable)
+		}
+		kid, _ := t.Header["kid"].(string)
+		return v.jwks.key(ctx, kid)
+	default:
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+}
+
+func (v *Verifier) verifyRemote(ctx context.Context, token string) (Claims, error) {
+	if v.checkURL == "" {
+		return Claims{}, ErrNotConfigured
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.checkURL, nil)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %s", ErrKeyUnavailable, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: remote check: %s", ErrKeyUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return Claims{}, ErrInvalidToken
+	default:
+		return Claims{}, fmt.Errorf("%w: remote check: unexpected status code: %d", ErrKeyUnavailable, resp.StatusCode)
+	}
+
+	userID, err := uuid.Parse(resp.Header.Get(HeaderUserID))
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: remote check: %s", ErrKeyUnavailable, err)
+	}
+
+	// a restricted token must not pass as a full one, so a service that
+	// does not report restriction is not trusted
+	restricted, err := strconv.ParseBool(resp.Header.Get(HeaderRestricted))
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: remote check: restriction not reported", ErrKeyUnavailable)
+	}
+
+	var scopes []string
+	for _, scope := range strings.Split(resp.Header.Get(HeaderScopes), ",") {
+		scope = strings.TrimSpace(scope)
+		if scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return Claims{
+		UserID:     userID,
+		TokenType:  TokenTypeAccess,
+		Restricted: restricted,
+		Scopes:     scopes,
+	}, nil
+}
@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+)
+
+type AuditRepo struct {
+	db *sql.DB
+}
+
+func NewAuditRepo(db *sql.DB) *AuditRepo {
+	return &AuditRepo{
+		db: db,
+	}
+}
+
+//go:embed sql/insert_auth_events.sql
+var insertAuthEventsSQL string
+
+func (r *AuditRepo) Insert(ctx context.Context, events []domain.AuthEvent) error {
+	n := len(events)
+	var (
+		types       = make([]string, n)
+		userIDs     = make([]string, n)
+		emails      = make([]string, n)
+		methods     = make([]string, n)
+		reasons     = make([]string, n)
+		ips         = make([]string, n)
+		userAgents  = make([]string, n)
+		requestIDs  = make([]string, n)
+		occurredAts = make([]time.Time, n)
+	)
+
+	for i, e := range events {
+		types[i] = string(e.Type)
+		if e.UserID != nil {
+			userIDs[i] = e.UserID.String()
+		}
+		emails[i] = e.Email
+		methods[i] = e.Method
+		reasons[i] = e.Reason
+		ips[i] = e.IP
+		userAgents[i] = e.UserAgent
+		requestIDs[i] = e.RequestID
+		occurredAts[i] = e.OccurredAt
+	}
+
+	_, err := r.db.ExecContext(ctx, insertAuthEventsSQL,
+		types,
+		userIDs,
+		emails,
+		methods,
+		reasons,
+		ips,
+		userAgents,
+		requestIDs,
+		occurredAts,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return nil
+}
+
+//go:embed sql/list_auth_events.sql
+var listAuthEventsSQL string
+
+func (r *AuditRepo) List(ctx context.Context, f domain.AuthEventFilter) ([]domain.AuthEvent, error) {
+	types := make([]string, len(f.Types))
+	for i, t := range f.Types {
+		types[i] = string(t)
+	}
+
+	rows, err := r.db.QueryContext(ctx, listAuthEventsSQL,
+		f.UserID,
+		types,
+		f.Email,
+		f.IP,
+		f.From,
+		f.To,
+		f.BeforeID,
+		f.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	events := []domain.AuthEvent{}
+	for rows.Next() {
+		var e domain.AuthEvent
+
+		err = rows.Scan(
+			&e.ID,
+			&e.Type,
+			&e.UserID,
+			&e.Email,
+			&e.Method,
+			&e.Reason,
+			&e.IP,
+			&e.UserAgent,
+			&e.RequestID,
+			&e.OccurredAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+		}
+
+		events = append(events, e)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return events, nil
+}
+
+//go:embed sql/delete_expired_auth_events.sql
+var deleteExpiredAuthEventsSQL string
+
+// DeleteExpired removes up to limit events that occurred before t and
+// returns how many were removed.
+func (r *AuditRepo) DeleteExpired(ctx context.Context, t time.Time, limit int) (int64, error) {
+	res, err := r.db.ExecContext(ctx, deleteExpiredAuthEventsSQL, t, limit)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return n, nil
+}
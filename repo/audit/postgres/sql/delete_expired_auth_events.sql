@@ -0,0 +1,8 @@
+delete from auth_events
+where id in (
+    select id
+    from auth_events
+    where occurred_at < $1
+    order by id
+    limit $2
+)
@@ -0,0 +1,31 @@
+insert into auth_events (
+    type,
+    user_id,
+    email,
+    method,
+    reason,
+    ip,
+    user_agent,
+    request_id,
+    occurred_at
+)
+select e.type,
+       nullif(e.user_id, '')::uuid,
+       nullif(e.email, ''),
+       nullif(e.method, ''),
+       nullif(e.reason, ''),
+       nullif(e.ip, ''),
+       nullif(e.user_agent, ''),
+       nullif(e.request_id, ''),
+       e.occurred_at
+from unnest(
+    $1::text[],
+    $2::text[],
+    $3::text[],
+    $4::text[],
+    $5::text[],
+    $6::text[],
+    $7::text[],
+    $8::text[],
+    $9::timestamptz[]
+) as e (type, user_id, email, method, reason, ip, user_agent, request_id, occurred_at)
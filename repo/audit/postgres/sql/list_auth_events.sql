@@ -0,0 +1,20 @@
+select id,
+       type,
+       user_id,
+       coalesce(email, ''),
+       coalesce(method, ''),
+       coalesce(reason, ''),
+       coalesce(ip, ''),
+       coalesce(user_agent, ''),
+       coalesce(request_id, ''),
+       occurred_at
+from auth_events
+where ($1::uuid is null or user_id = $1)
+  and (cardinality($2::text[]) = 0 or type = any($2))
+  and ($3::text = '' or email = $3)
+  and ($4::text = '' or ip = $4)
+  and ($5::timestamptz is null or occurred_at >= $5)
+  and ($6::timestamptz is null or occurred_at < $6)
+  and ($7::bigint = 0 or id < $7)
+order by id desc
+limit $8
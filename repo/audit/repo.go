@@ -0,0 +1,15 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+)
+
+type Repo interface {
+	Insert(ctx context.Context, events []domain.AuthEvent) error
+	// List returns matching events, newest first.
+	List(ctx context.Context, filter domain.AuthEventFilter) ([]domain.AuthEvent, error)
+	DeleteExpired(ctx context.Context, before time.Time, limit int) (int64, error)
+}
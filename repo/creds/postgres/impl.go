@@ -6,6 +6,7 @@ import (
 	_ "embed"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/akemoon/crowdfunding-app-auth/domain"
 	"github.com/google/uuid"
@@ -40,6 +41,10 @@ func (r *CredsRepo) CreateCreds(ctx context.Context, c domain.Creds) (uuid.UUID,
 		&userID,
 	)
 	if err != nil {
+		// no row is inserted if the email exists in another case
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, domain.ErrEmailExists
+		}
 		pgErr := asPostgresError(err)
 		if pgErr != nil {
 			mappedErr := mapPostgresError(pgErr)
@@ -51,6 +56,48 @@ func (r *CredsRepo) CreateCreds(ctx context.Context, c domain.Creds) (uuid.UUID,
 	return userID, nil
 }
 
+//go:embed sql/import_creds.sql
+var importCredsSQL string
+
+// ImportCreds inserts creds in one statement. Rows colliding with an
+// existing account, or with an earlier row, are skipped; the user ids of
+// the inserted rows are returned.
+func (r *CredsRepo) ImportCreds(ctx context.Context, creds []domain.Creds, verified bool) ([]uuid.UUID, error) {
+	userIDs := make([]string, len(creds))
+	emails := make([]string, len(creds))
+	hashes := make([]string, len(creds))
+	for i, c := range creds {
+		userIDs[i] = c.UserID.String()
+		emails[i] = c.Email
+		hashes[i] = c.PasswordHash
+	}
+
+	rows, err := r.db.QueryContext(ctx, importCredsSQL, userIDs, emails, hashes, verified)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	var inserted []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+
+		err = rows.Scan(&userID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+		}
+
+		inserted = append(inserted, userID)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return inserted, nil
+}
+
 //go:embed sql/delete_creds_by_user_id.sql
 var deleteCredsByUserIDSQL string
 
@@ -81,6 +128,41 @@ func (r *CredsRepo) GetCredsByEmail(ctx context.Context, email string) (domain.C
 		&c.UserID,
 		&c.Email,
 		&c.PasswordHash,
+		&c.EmailVerifiedAt,
+		&c.DeletionRequestedAt,
+		&c.Status,
+		&c.StatusReason,
+		&c.StatusUntil,
+		&c.PasswordResetRequiredAt,
+		&c.PasswordChangedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Creds{}, domain.ErrCredsNotFound
+		}
+		return domain.Creds{}, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return c, nil
+}
+
+//go:embed sql/get_creds_by_user_id.sql
+var getCredsByUserIDSQL string
+
+func (r *CredsRepo) GetCredsByUserID(ctx context.Context, userID uuid.UUID) (domain.Creds, error) {
+	var c domain.Creds
+
+	err := r.db.QueryRowContext(ctx, getCredsByUserIDSQL, userID).Scan(
+		&c.UserID,
+		&c.Email,
+		&c.PasswordHash,
+		&c.EmailVerifiedAt,
+		&c.DeletionRequestedAt,
+		&c.Status,
+		&c.StatusReason,
+		&c.StatusUntil,
+		&c.PasswordResetRequiredAt,
+		&c.PasswordChangedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -92,6 +174,247 @@ func (r *CredsRepo) GetCredsByEmail(ctx context.Context, email string) (domain.C
 	return c, nil
 }
 
+//go:embed sql/set_email_verified.sql
+var setEmailVerifiedSQL string
+
+func (r *CredsRepo) SetEmailVerified(ctx context.Context, userID uuid.UUID, email string) error {
+	res, err := r.db.ExecContext(ctx, setEmailVerifiedSQL, userID, email)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	if n == 0 {
+		return domain.ErrCredsNotFound
+	}
+
+	return nil
+}
+
+//go:embed sql/update_password_hash.sql
+var updatePasswordHashSQL string
+
+// UpdatePasswordHash also lifts a required password reset.
+func (r *CredsRepo) UpdatePasswordHash(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+	res, err := r.db.ExecContext(ctx, updatePasswordHashSQL, userID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	if n == 0 {
+		return domain.ErrCredsNotFound
+	}
+
+	return nil
+}
+
+//go:embed sql/rehash_password.sql
+var rehashPasswordSQL string
+
+// RehashPassword replaces the hash of an unchanged password, so it keeps
+// PasswordChangedAt.
+func (r *CredsRepo) RehashPassword(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+	res, err := r.db.ExecContext(ctx, rehashPasswordSQL, userID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	if n == 0 {
+		return domain.ErrCredsNotFound
+	}
+
+	return nil
+}
+
+//go:embed sql/update_email.sql
+var updateEmailSQL string
+
+// UpdateEmail replaces the email and marks it verified: callers only use it
+// once the new address has been confirmed.
+func (r *CredsRepo) UpdateEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	res, err := r.db.ExecContext(ctx, updateEmailSQL, userID, email)
+	if err != nil {
+		pgErr := asPostgresError(err)
+		if pgErr != nil {
+			mappedErr := mapPostgresError(pgErr)
+			return fmt.Errorf("%w: %s", mappedErr, pgErr.Detail)
+		}
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	if n == 0 {
+		return domain.ErrCredsNotFound
+	}
+
+	return nil
+}
+
+//go:embed sql/set_status.sql
+var setStatusSQL string
+
+func (r *CredsRepo) SetStatus(ctx context.Context, userID uuid.UUID, status domain.AccountStatus, reason string, until *time.Time) error {
+	res, err := r.db.ExecContext(ctx, setStatusSQL, userID, status, reason, until)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	if n == 0 {
+		return domain.ErrCredsNotFound
+	}
+
+	return nil
+}
+
+//go:embed sql/require_password_reset.sql
+var requirePasswordResetSQL string
+
+func (r *CredsRepo) RequirePasswordReset(ctx context.Context, userID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, requirePasswordResetSQL, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	if n == 0 {
+		return domain.ErrCredsNotFound
+	}
+
+	return nil
+}
+
+//go:embed sql/anonymize_creds.sql
+var anonymizeCredsSQL string
+
+// AnonymizeCreds keeps the row, so references to the user id stay valid,
+// but drops the email, the password and every second factor.
+func (r *CredsRepo) AnonymizeCreds(ctx context.Context, userID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, anonymizeCredsSQL, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	if n == 0 {
+		return domain.ErrCredsNotFound
+	}
+
+	return nil
+}
+
+//go:embed sql/list_emails.sql
+var listEmailsSQL string
+
+// ListEmails returns the email of every account. It is used by the
+// email-conflicts tool, not by the service.
+func (r *CredsRepo) ListEmails(ctx context.Context) ([]domain.EmailRecord, error) {
+	rows, err := r.db.QueryContext(ctx, listEmailsSQL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	var records []domain.EmailRecord
+	for rows.Next() {
+		var rec domain.EmailRecord
+
+		err = rows.Scan(
+			&rec.UserID,
+			&rec.Email,
+			&rec.Conflict,
+			&rec.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+		}
+
+		records = append(records, rec)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return records, nil
+}
+
+//go:embed sql/rewrite_email.sql
+var rewriteEmailSQL string
+
+// RewriteEmail changes the spelling of an email without touching its
+// verification state.
+func (r *CredsRepo) RewriteEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	res, err := r.db.ExecContext(ctx, rewriteEmailSQL, userID, email)
+	if err != nil {
+		pgErr := asPostgresError(err)
+		if pgErr != nil {
+			mappedErr := mapPostgresError(pgErr)
+			return fmt.Errorf("%w: %s", mappedErr, pgErr.Detail)
+		}
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	if n == 0 {
+		return domain.ErrCredsNotFound
+	}
+
+	return nil
+}
+
+//go:embed sql/set_email_conflict.sql
+var setEmailConflictSQL string
+
+func (r *CredsRepo) SetEmailConflict(ctx context.Context, userID uuid.UUID, conflict bool) error {
+	res, err := r.db.ExecContext(ctx, setEmailConflictSQL, userID, conflict)
+	if err != nil {
+		pgErr := asPostgresError(err)
+		if pgErr != nil {
+			mappedErr := mapPostgresError(pgErr)
+			return fmt.Errorf("%w: %s", mappedErr, pgErr.Detail)
+		}
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	if n == 0 {
+		return domain.ErrCredsNotFound
+	}
+
+	return nil
+}
+
 func asPostgresError(err error) *pgconn.PgError {
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) {
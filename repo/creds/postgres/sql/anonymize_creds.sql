@@ -0,0 +1,23 @@
+with deleted_totp as (
+    delete from mfa_totp
+    where user_id = $1
+), deleted_recovery_codes as (
+    delete from mfa_recovery_codes
+    where user_id = $1
+), deleted_passkeys as (
+    delete from passkeys
+    where user_id = $1
+), deleted_devices as (
+    delete from known_devices
+    where user_id = $1
+), deleted_networks as (
+    delete from known_networks
+    where user_id = $1
+)
+update credentials
+set email                      = 'deleted-' || user_id || '@invalid',
+    password_hash              = '',
+    email_verified_at          = null,
+    password_reset_required_at = null,
+    password_changed_at        = now()
+where user_id = $1
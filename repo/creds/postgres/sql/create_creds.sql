@@ -1,5 +1,11 @@
 insert into credentials (
     email,
     password_hash
-) values ($1, $2)
+)
+select $1, $2
+where not exists (
+    select 1
+    from credentials
+    where lower(email) = lower($1)
+)
 returning user_id;
@@ -1,5 +1,15 @@
 select user_id,
        email,
-       password_hash
+       password_hash,
+       email_verified_at,
+       deletion_requested_at,
+       status,
+       coalesce(status_reason, ''),
+       status_until,
+       password_reset_required_at,
+       password_changed_at
 from credentials
-where email = $1
+where lower(email) = $1
+order by email = $1 desc,
+         created_at
+limit 1
@@ -0,0 +1,12 @@
+select user_id,
+       email,
+       password_hash,
+       email_verified_at,
+       deletion_requested_at,
+       status,
+       coalesce(status_reason, ''),
+       status_until,
+       password_reset_required_at,
+       password_changed_at
+from credentials
+where user_id = $1
@@ -0,0 +1,13 @@
+insert into credentials (
+    user_id,
+    email,
+    password_hash,
+    email_verified_at
+)
+select r.user_id,
+       r.email,
+       r.password_hash,
+       case when $4 then now() end
+from unnest($1::uuid[], $2::text[], $3::text[]) as r (user_id, email, password_hash)
+on conflict do nothing
+returning user_id
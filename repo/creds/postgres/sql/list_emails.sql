@@ -0,0 +1,6 @@
+select user_id,
+       email,
+       email_conflict,
+       created_at
+from credentials
+order by created_at
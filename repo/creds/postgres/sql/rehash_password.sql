@@ -0,0 +1,3 @@
+update credentials
+set password_hash = $2
+where user_id = $1
@@ -0,0 +1,3 @@
+update credentials
+set password_reset_required_at = now()
+where user_id = $1
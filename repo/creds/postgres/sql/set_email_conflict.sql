@@ -0,0 +1,3 @@
+update credentials
+set email_conflict = $2
+where user_id = $1
@@ -0,0 +1,6 @@
+update credentials
+set status            = $2,
+    status_reason     = nullif($3, ''),
+    status_until      = $4,
+    status_changed_at = now()
+where user_id = $1
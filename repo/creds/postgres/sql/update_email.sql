@@ -0,0 +1,4 @@
+update credentials
+set email             = $2,
+    email_verified_at = now()
+where user_id = $1
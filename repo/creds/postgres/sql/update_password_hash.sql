@@ -0,0 +1,5 @@
+update credentials
+set password_hash              = $2,
+    password_reset_required_at = null,
+    password_changed_at        = now()
+where user_id = $1
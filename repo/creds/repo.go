@@ -2,6 +2,7 @@ package creds
 
 import (
 	"context"
+	"time"
 
 	"github.com/akemoon/crowdfunding-app-auth/domain"
 	"github.com/google/uuid"
@@ -10,5 +11,13 @@ import (
 type Repo interface {
 	CreateCreds(ctx context.Context, creds domain.Creds) (uuid.UUID, error)
 	DeleteCredsByUserID(ctx context.Context, userID uuid.UUID) error
+	AnonymizeCreds(ctx context.Context, userID uuid.UUID) error
 	GetCredsByEmail(ctx context.Context, email string) (domain.Creds, error)
+	GetCredsByUserID(ctx context.Context, userID uuid.UUID) (domain.Creds, error)
+	SetEmailVerified(ctx context.Context, userID uuid.UUID, email string) error
+	UpdatePasswordHash(ctx context.Context, userID uuid.UUID, passwordHash string) error
+	RehashPassword(ctx context.Context, userID uuid.UUID, passwordHash string) error
+	UpdateEmail(ctx context.Context, userID uuid.UUID, email string) error
+	SetStatus(ctx context.Context, userID uuid.UUID, status domain.AccountStatus, reason string, until *time.Time) error
+	RequirePasswordReset(ctx context.Context, userID uuid.UUID) error
 }
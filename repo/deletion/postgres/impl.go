@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/google/uuid"
+)
+
+type DeletionRepo struct {
+	db *sql.DB
+}
+
+func NewDeletionRepo(db *sql.DB) *DeletionRepo {
+	return &DeletionRepo{
+		db: db,
+	}
+}
+
+//go:embed sql/request_deletion.sql
+var requestDeletionSQL string
+
+//go:embed sql/create_deletion.sql
+var createDeletionSQL string
+
+func (r *DeletionRepo) Schedule(ctx context.Context, userID uuid.UUID, purgeAfter time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, requestDeletionSQL, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	if n == 0 {
+		return domain.ErrAccountPendingDeletion
+	}
+
+	_, err = tx.ExecContext(ctx, createDeletionSQL, userID, purgeAfter)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return nil
+}
+
+//go:embed sql/claim_due_deletions.sql
+var claimDueDeletionsSQL string
+
+func (r *DeletionRepo) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]domain.AccountDeletion, error) {
+	rows, err := r.db.QueryContext(ctx, claimDueDeletionsSQL, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	var deletions []domain.AccountDeletion
+	for rows.Next() {
+		var d domain.AccountDeletion
+
+		err = rows.Scan(
+			&d.UserID,
+			&d.RequestedAt,
+			&d.PurgeAfter,
+			&d.Attempts,
+			&d.UserDeletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+		}
+
+		deletions = append(deletions, d)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return deletions, nil
+}
+
+//go:embed sql/retry_deletion.sql
+var retryDeletionSQL string
+
+func (r *DeletionRepo) Retry(ctx context.Context, userID uuid.UUID, nextAttemptAt time.Time, lastErr string) error {
+	_, err := r.db.ExecContext(ctx, retryDeletionSQL, userID, nextAttemptAt, lastErr)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return nil
+}
+
+//go:embed sql/mark_user_deleted.sql
+var markUserDeletedSQL string
+
+func (r *DeletionRepo) MarkUserDeleted(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, markUserDeletedSQL, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return nil
+}
+
+//go:embed sql/complete_deletion.sql
+var completeDeletionSQL string
+
+func (r *DeletionRepo) Complete(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, completeDeletionSQL, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,17 @@
+update account_deletions
+set next_attempt_at = now() + $2 * interval '1 second',
+    attempts        = attempts + 1
+where user_id in (
+    select user_id
+    from account_deletions
+    where completed_at is null
+      and next_attempt_at <= now()
+    order by next_attempt_at
+    limit $1
+    for update skip locked
+)
+returning user_id,
+          requested_at,
+          purge_after,
+          attempts,
+          user_deleted_at
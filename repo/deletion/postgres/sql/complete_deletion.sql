@@ -0,0 +1,4 @@
+update account_deletions
+set completed_at = now(),
+    last_error   = null
+where user_id = $1
@@ -0,0 +1,5 @@
+insert into account_deletions (
+    user_id,
+    purge_after,
+    next_attempt_at
+) values ($1, $2, $2)
@@ -0,0 +1,3 @@
+update account_deletions
+set user_deleted_at = now()
+where user_id = $1
@@ -0,0 +1,4 @@
+update credentials
+set deletion_requested_at = now()
+where user_id = $1
+  and deletion_requested_at is null
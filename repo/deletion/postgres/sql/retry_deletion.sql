@@ -0,0 +1,4 @@
+update account_deletions
+set next_attempt_at = $2,
+    last_error      = $3
+where user_id = $1
@@ -0,0 +1,23 @@
+package deletion
+
+import (
+	"context"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/google/uuid"
+)
+
+// Repo keeps scheduled account deletions until every step has succeeded.
+type Repo interface {
+	// Schedule marks the credentials as pending deletion and queues the
+	// deletion for purgeAfter. It fails with
+	// domain.ErrAccountPendingDeletion if a deletion is already queued.
+	Schedule(ctx context.Context, userID uuid.UUID, purgeAfter time.Time) error
+	// ClaimDue returns up to limit due deletions and hides them from other
+	// replicas for lease.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]domain.AccountDeletion, error)
+	Retry(ctx context.Context, userID uuid.UUID, nextAttemptAt time.Time, lastErr string) error
+	MarkUserDeleted(ctx context.Context, userID uuid.UUID) error
+	Complete(ctx context.Context, userID uuid.UUID) error
+}
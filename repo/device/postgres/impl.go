@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"fmt"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/google/uuid"
+)
+
+type DeviceRepo struct {
+	db *sql.DB
+}
+
+func NewDeviceRepo(db *sql.DB) *DeviceRepo {
+	return &DeviceRepo{
+		db: db,
+	}
+}
+
+//go:embed sql/has_devices.sql
+var hasDevicesSQL string
+
+func (r *DeviceRepo) HasDevices(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var exists bool
+
+	err := r.db.QueryRowContext(ctx, hasDevicesSQL, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return exists, nil
+}
+
+//go:embed sql/remember_device.sql
+var rememberDeviceSQL string
+
+func (r *DeviceRepo) RememberDevice(ctx context.Context, d domain.KnownDevice) (bool, error) {
+	var inserted bool
+
+	err := r.db.QueryRowContext(ctx, rememberDeviceSQL,
+		d.UserID,
+		d.Fingerprint,
+		d.Description,
+		d.IP,
+	).Scan(&inserted)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return inserted, nil
+}
+
+//go:embed sql/remember_network.sql
+var rememberNetworkSQL string
+
+func (r *DeviceRepo) RememberNetwork(ctx context.Context, userID uuid.UUID, network string) (bool, error) {
+	var inserted bool
+
+	err := r.db.QueryRowContext(ctx, rememberNetworkSQL, userID, network).Scan(&inserted)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return inserted, nil
+}
+
+//go:embed sql/forget.sql
+var forgetSQL string
+
+func (r *DeviceRepo) Forget(ctx context.Context, userID uuid.UUID, fingerprint, network string) error {
+	_, err := r.db.ExecContext(ctx, forgetSQL, userID, fingerprint, network)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,8 @@
+with deleted_devices as (
+    delete from known_devices
+    where user_id = $1
+      and fingerprint = $2
+)
+delete from known_networks
+where user_id = $1
+  and network = $3
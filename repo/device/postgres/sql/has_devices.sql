@@ -0,0 +1,5 @@
+select exists (
+    select 1
+    from known_devices
+    where user_id = $1
+)
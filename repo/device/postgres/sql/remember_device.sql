@@ -0,0 +1,12 @@
+insert into known_devices (
+    user_id,
+    fingerprint,
+    description,
+    last_ip
+)
+values ($1, $2, $3, nullif($4, ''))
+on conflict (user_id, fingerprint) do update
+set description  = excluded.description,
+    last_ip      = excluded.last_ip,
+    last_seen_at = now()
+returning xmax = 0
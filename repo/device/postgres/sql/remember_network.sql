@@ -0,0 +1,8 @@
+insert into known_networks (
+    user_id,
+    network
+)
+values ($1, $2)
+on conflict (user_id, network) do update
+set last_seen_at = now()
+returning xmax = 0
@@ -0,0 +1,19 @@
+package device
+
+import (
+	"context"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/google/uuid"
+)
+
+type Repo interface {
+	// HasDevices reports whether any device of the user is known.
+	HasDevices(ctx context.Context, userID uuid.UUID) (bool, error)
+	// RememberDevice stores d or refreshes its last sighting. It reports
+	// whether the device was new.
+	RememberDevice(ctx context.Context, d domain.KnownDevice) (bool, error)
+	// RememberNetwork is RememberDevice for the network of a sign-in.
+	RememberNetwork(ctx context.Context, userID uuid.UUID, network string) (bool, error)
+	Forget(ctx context.Context, userID uuid.UUID, fingerprint, network string) error
+}
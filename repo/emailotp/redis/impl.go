@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	checkMissing  = -1
+	checkMismatch = 0
+	checkMatch    = 1
+)
+
+// checkScript compares and counts in one step so parallel guesses cannot
+// exceed the attempt limit.
+var checkScript = redis.NewScript(`
+local h = redis.call('HGET', KEYS[1], 'hash')
+if not h then
+	return -1
+end
+if h == ARGV[1] then
+	redis.call('DEL', KEYS[1])
+	return 1
+end
+local n = redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+if n >= tonumber(ARGV[2]) then
+	redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+type EmailOTPRepo struct {
+	redisClient *redis.Client
+}
+
+func NewEmailOTPRepo(rc *redis.Client) *EmailOTPRepo {
+	return &EmailOTPRepo{
+		redisClient: rc,
+	}
+}
+
+func (r *EmailOTPRepo) Set(ctx context.Context, emailHash, codeHash string, ttl time.Duration) error {
+	_, err := r.redisClient.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, key(emailHash))
+		p.HSet(ctx, key(emailHash), "hash", codeHash, "attempts", 0)
+		p.Expire(ctx, key(emailHash), ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	return nil
+}
+
+func (r *EmailOTPRepo) Check(ctx context.Context, emailHash, codeHash string, maxAttempts int) (bool, error) {
+	res, err := checkScript.Run(ctx, r.redisClient, []string{key(emailHash)}, codeHash, maxAttempts).Int()
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	switch res {
+	case checkMissing:
+		return false, domain.ErrOneTimeTokenNotFound
+	case checkMatch:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func key(emailHash string) string {
+	return "emailotp:" + emailHash
+}
@@ -0,0 +1,18 @@
+package emailotp
+
+import (
+	"context"
+	"time"
+)
+
+// Repo stores one pending sign-in code per address. Addresses and codes are
+// only stored as hashes.
+type Repo interface {
+	// Set stores a code, replacing the previous one and its attempts.
+	Set(ctx context.Context, emailHash, codeHash string, ttl time.Duration) error
+	// Check compares codeHash with the stored code. A match consumes the
+	// code; a miss counts an attempt and deletes the code once maxAttempts
+	// is reached. It returns domain.ErrOneTimeTokenNotFound if there is no
+	// code.
+	Check(ctx context.Context, emailHash, codeHash string, maxAttempts int) (bool, error)
+}
@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// lockScript escalates in one step and only while unlocked, so parallel
+// failures that all cross the threshold raise the level once.
+var lockScript = redis.NewScript(`
+redis.call('DEL', KEYS[1])
+local ttl = redis.call('PTTL', KEYS[3])
+if ttl > 0 then
+	return ttl
+end
+local level = redis.call('INCR', KEYS[2])
+redis.call('PEXPIRE', KEYS[2], ARGV[1])
+local d = ARGV[math.min(level, #ARGV - 1) + 1]
+redis.call('SET', KEYS[3], 1, 'PX', d)
+return tonumber(d)
+`)
+
+type LockoutRepo struct {
+	redisClient *redis.Client
+}
+
+func NewLockoutRepo(rc *redis.Client) *LockoutRepo {
+	return &LockoutRepo{
+		redisClient: rc,
+	}
+}
+
+func (r *LockoutRepo) IncrFailures(ctx context.Context, userID uuid.UUID, window time.Duration) (int, error) {
+	var incr *redis.IntCmd
+
+	_, err := r.redisClient.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		incr = p.Incr(ctx, failuresKey(userID))
+		p.Expire(ctx, failuresKey(userID), window)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return int(incr.Val()), nil
+}
+
+func (r *LockoutRepo) Lock(ctx context.Context, userID uuid.UUID, levelTTL time.Duration, durations []time.Duration) (time.Duration, error) {
+	if len(durations) == 0 {
+		return 0, fmt.Errorf("%w: no lock durations", domain.ErrInternal)
+	}
+
+	args := make([]any, 0, len(durations)+1)
+	args = append(args, levelTTL.Milliseconds())
+	for _, d := range durations {
+		args = append(args, d.Milliseconds())
+	}
+
+	keys := []string{failuresKey(userID), levelKey(userID), lockedKey(userID)}
+
+	ms, err := lockScript.Run(ctx, r.redisClient, keys, args...).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+func (r *LockoutRepo) LockedFor(ctx context.Context, userID uuid.UUID) (time.Duration, error) {
+	ttl, err := r.redisClient.PTTL(ctx, lockedKey(userID)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	// negative values mean the key does not exist or has no expiry
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
+
+func (r *LockoutRepo) Reset(ctx context.Context, userID uuid.UUID) error {
+	err := r.redisClient.Del(ctx, failuresKey(userID), levelKey(userID), lockedKey(userID)).Err()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	return nil
+}
+
+func failuresKey(userID uuid.UUID) string {
+	return "lockout:failures:" + userID.String()
+}
+
+func levelKey(userID uuid.UUID) string {
+	return "lockout:level:" + userID.String()
+}
+
+func lockedKey(userID uuid.UUID) string {
+	return "lockout:locked:" + userID.String()
+}
@@ -0,0 +1,25 @@
+package lockout
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Repo keeps sign-in failure counters in storage shared by all replicas.
+type Repo interface {
+	// IncrFailures increments the consecutive failure counter and returns
+	// the new value. The counter expires after window without failures.
+	IncrFailures(ctx context.Context, userID uuid.UUID, window time.Duration) (int, error)
+	// Lock clears the failure counter, raises the lock level and locks the
+	// account for durations[level-1], or the last duration at higher
+	// levels. level is the number of locks in a row, including this one.
+	// If the account is already locked, the level and the lock are left
+	// as they are. It returns the remaining lock time.
+	Lock(ctx context.Context, userID uuid.UUID, levelTTL time.Duration, durations []time.Duration) (time.Duration, error)
+	// LockedFor returns the remaining lock time, zero if not locked.
+	LockedFor(ctx context.Context, userID uuid.UUID) (time.Duration, error)
+	// Reset clears failures, lock and lock level.
+	Reset(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/google/uuid"
+)
+
+type MFARepo struct {
+	db *sql.DB
+}
+
+func NewMFARepo(db *sql.DB) *MFARepo {
+	return &MFARepo{
+		db: db,
+	}
+}
+
+//go:embed sql/upsert_totp.sql
+var upsertTOTPSQL string
+
+func (r *MFARepo) UpsertTOTP(ctx context.Context, userID uuid.UUID, secretEnc []byte) error {
+	res, err := r.db.ExecContext(ctx, upsertTOTPSQL, userID, secretEnc)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	if n == 0 {
+		return domain.ErrMFAAlreadyEnabled
+	}
+
+	return nil
+}
+
+//go:embed sql/get_totp.sql
+var getTOTPSQL string
+
+func (r *MFARepo) GetTOTP(ctx context.Context, userID uuid.UUID) (domain.TOTP, error) {
+	var t domain.TOTP
+
+	err := r.db.QueryRowContext(ctx, getTOTPSQL, userID).Scan(
+		&t.UserID,
+		&t.SecretEnc,
+		&t.ConfirmedAt,
+		&t.LastUsedStep,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.TOTP{}, domain.ErrMFANotEnrolled
+		}
+		return domain.TOTP{}, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return t, nil
+}
+
+//go:embed sql/confirm_totp.sql
+var confirmTOTPSQL string
+
+func (r *MFARepo) ConfirmTOTP(ctx context.Context, userID uuid.UUID, step int64) error {
+	res, err := r.db.ExecContext(ctx, confirmTOTPSQL, userID, step)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	if n == 0 {
+		return domain.ErrMFANotEnrolled
+	}
+
+	return nil
+}
+
+//go:embed sql/use_totp_step.sql
+var useTOTPStepSQL string
+
+func (r *MFARepo) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	res, err := r.db.ExecContext(ctx, useTOTPStepSQL, userID, step)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	if n == 0 {
+		return domain.ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+//go:embed sql/delete_recovery_codes.sql
+var deleteRecoveryCodesSQL string
+
+//go:embed sql/create_recovery_code.sql
+var createRecoveryCodeSQL string
+
+func (r *MFARepo) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, deleteRecoveryCodesSQL, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	for _, h := range codeHashes {
+		_, err = tx.ExecContext(ctx, createRecoveryCodeSQL, userID, h)
+		if err != nil {
+			return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return nil
+}
+
+//go:embed sql/use_recovery_code.sql
+var useRecoveryCodeSQL string
+
+func (r *MFARepo) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	res, err := r.db.ExecContext(ctx, useRecoveryCodeSQL, userID, codeHash)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	if n == 0 {
+		return domain.ErrInvalidMFACode
+	}
+
+	return nil
+}
@@ -0,0 +1,5 @@
+update mfa_totp
+set confirmed_at   = now(),
+    last_used_step = $2
+where user_id = $1
+  and confirmed_at is null
@@ -0,0 +1,4 @@
+insert into mfa_recovery_codes (
+    user_id,
+    code_hash
+) values ($1, $2)
@@ -0,0 +1,2 @@
+delete from mfa_recovery_codes
+where user_id = $1
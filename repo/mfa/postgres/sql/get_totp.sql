@@ -0,0 +1,6 @@
+select user_id,
+       secret_enc,
+       confirmed_at,
+       last_used_step
+from mfa_totp
+where user_id = $1
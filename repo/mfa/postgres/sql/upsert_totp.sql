@@ -0,0 +1,10 @@
+insert into mfa_totp (
+    user_id,
+    secret_enc
+) values ($1, $2)
+on conflict (user_id) do update
+set secret_enc     = excluded.secret_enc,
+    confirmed_at   = null,
+    last_used_step = 0,
+    created_at     = now()
+where mfa_totp.confirmed_at is null
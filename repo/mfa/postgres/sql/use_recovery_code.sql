@@ -0,0 +1,5 @@
+update mfa_recovery_codes
+set used_at = now()
+where user_id = $1
+  and code_hash = $2
+  and used_at is null
@@ -0,0 +1,4 @@
+update mfa_totp
+set last_used_step = $2
+where user_id = $1
+  and last_used_step < $2
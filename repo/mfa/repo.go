@@ -0,0 +1,25 @@
+package mfa
+
+import (
+	"context"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/google/uuid"
+)
+
+type Repo interface {
+	// UpsertTOTP stores a pending secret. It fails with
+	// domain.ErrMFAAlreadyEnabled once a secret has been confirmed.
+	UpsertTOTP(ctx context.Context, userID uuid.UUID, secretEnc []byte) error
+	GetTOTP(ctx context.Context, userID uuid.UUID) (domain.TOTP, error)
+	ConfirmTOTP(ctx context.Context, userID uuid.UUID, step int64) error
+	// UseTOTPStep records step as used. It fails with domain.ErrInvalidMFACode
+	// if the step, or a later one, has already been used.
+	UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error
+	// ReplaceRecoveryCodes drops all recovery codes of the user and stores
+	// codeHashes instead.
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	// UseRecoveryCode marks a code as used. It fails with
+	// domain.ErrInvalidMFACode for unknown or used codes.
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error
+}
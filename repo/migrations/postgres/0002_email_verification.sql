@@ -0,0 +1,9 @@
+-- +goose Up
+
+alter table credentials
+    add column if not exists email_verified_at timestamptz;
+
+-- +goose Down
+
+alter table credentials
+    drop column if exists email_verified_at;
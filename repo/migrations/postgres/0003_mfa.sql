@@ -0,0 +1,24 @@
+-- +goose Up
+
+create table if not exists mfa_totp (
+    user_id        uuid primary key references credentials (user_id) on delete cascade,
+    secret_enc     bytea not null,
+    confirmed_at   timestamptz,
+    last_used_step bigint not null default 0,
+    created_at     timestamptz not null default now()
+);
+
+create table if not exists mfa_recovery_codes (
+    id         bigserial primary key,
+    user_id    uuid not null references credentials (user_id) on delete cascade,
+    code_hash  text not null,
+    used_at    timestamptz,
+    created_at timestamptz not null default now(),
+
+    constraint mfa_recovery_codes_unique unique (user_id, code_hash)
+);
+
+-- +goose Down
+
+drop table if exists mfa_recovery_codes;
+drop table if exists mfa_totp;
@@ -0,0 +1,17 @@
+-- +goose Up
+
+create table if not exists passkeys (
+    credential_id bytea primary key,
+    user_id       uuid not null references credentials (user_id) on delete cascade,
+    public_key    bytea not null,
+    sign_count    bigint not null default 0,
+    name          text not null,
+    created_at    timestamptz not null default now(),
+    last_used_at  timestamptz
+);
+
+create index if not exists passkeys_user_id_idx on passkeys (user_id);
+
+-- +goose Down
+
+drop table if exists passkeys;
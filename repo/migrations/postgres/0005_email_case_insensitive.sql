@@ -0,0 +1,45 @@
+-- +goose Up
+
+-- Accounts whose emails differ only in case cannot be merged automatically.
+-- They are flagged instead of failing the migration and left out of the
+-- unique index; "app email-conflicts" reports them for a manual merge.
+-- Only case and surrounding spaces are normalized here. Emails that also
+-- differ in Unicode composition or IDN encoding are reported as unreachable
+-- by "app email-conflicts" and rewritten by its -apply flag.
+alter table credentials
+    add column if not exists email_conflict boolean not null default false;
+
+update credentials c
+set email_conflict = true
+where exists (
+    select 1
+    from credentials d
+    where lower(btrim(d.email)) = lower(btrim(c.email))
+      and d.user_id <> c.user_id
+);
+
+update credentials
+set email = lower(btrim(email))
+where not email_conflict
+  and email <> lower(btrim(email));
+
+alter table credentials
+    drop constraint if exists credentials_email_unique;
+
+create unique index if not exists credentials_email_unique
+    on credentials (lower(email))
+    where not email_conflict;
+
+create index if not exists credentials_email_lower_idx
+    on credentials (lower(email));
+
+-- +goose Down
+
+drop index if exists credentials_email_lower_idx;
+drop index if exists credentials_email_unique;
+
+alter table credentials
+    add constraint credentials_email_unique unique (email);
+
+alter table credentials
+    drop column if exists email_conflict;
@@ -0,0 +1,28 @@
+-- +goose Up
+
+alter table credentials
+    add column if not exists deletion_requested_at timestamptz;
+
+-- account_deletions outlives the credentials it deletes, so it has no
+-- foreign key to them.
+create table if not exists account_deletions (
+    user_id         uuid primary key,
+    requested_at    timestamptz not null default now(),
+    purge_after     timestamptz not null,
+    next_attempt_at timestamptz not null,
+    attempts        int not null default 0,
+    last_error      text,
+    user_deleted_at timestamptz,
+    completed_at    timestamptz
+);
+
+create index if not exists account_deletions_due_idx
+    on account_deletions (next_attempt_at)
+    where completed_at is null;
+
+-- +goose Down
+
+drop table if exists account_deletions;
+
+alter table credentials
+    drop column if exists deletion_requested_at;
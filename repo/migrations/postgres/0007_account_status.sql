@@ -0,0 +1,18 @@
+-- +goose Up
+
+-- status_until is when a suspension lifts by itself; null means until an
+-- admin reinstates the account.
+alter table credentials
+    add column if not exists status text not null default 'active'
+        check (status in ('active', 'suspended', 'disabled')),
+    add column if not exists status_reason text,
+    add column if not exists status_until timestamptz,
+    add column if not exists status_changed_at timestamptz;
+
+-- +goose Down
+
+alter table credentials
+    drop column if exists status_changed_at,
+    drop column if exists status_until,
+    drop column if exists status_reason,
+    drop column if exists status;
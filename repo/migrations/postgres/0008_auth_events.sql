@@ -0,0 +1,40 @@
+-- +goose Up
+
+create table if not exists auth_events (
+    id          bigint generated always as identity primary key,
+    type        text not null,
+    user_id     uuid,
+    email       text,
+    method      text,
+    reason      text,
+    ip          text,
+    user_agent  text,
+    request_id  text,
+    occurred_at timestamptz not null default now()
+);
+
+create index if not exists auth_events_user_id_idx
+    on auth_events (user_id, id);
+
+create index if not exists auth_events_occurred_at_idx
+    on auth_events (occurred_at);
+
+-- Events are evidence: they may expire, but never change.
+-- +goose StatementBegin
+create or replace function auth_events_reject_update() returns trigger
+language plpgsql as $$
+begin
+    raise exception 'auth_events is append-only';
+end;
+$$;
+-- +goose StatementEnd
+
+create trigger auth_events_no_update
+    before update on auth_events
+    for each row execute function auth_events_reject_update();
+
+-- +goose Down
+
+drop table if exists auth_events;
+
+drop function if exists auth_events_reject_update();
@@ -0,0 +1,33 @@
+-- +goose Up
+
+-- password_reset_required_at is set when the owner reports a sign-in as not
+-- theirs; password sign-in is refused until the password is reset.
+alter table credentials
+    add column if not exists password_reset_required_at timestamptz;
+
+create table if not exists known_devices (
+    user_id       uuid not null references credentials (user_id) on delete cascade,
+    fingerprint   text not null,
+    description   text not null,
+    last_ip       text,
+    first_seen_at timestamptz not null default now(),
+    last_seen_at  timestamptz not null default now(),
+    primary key (user_id, fingerprint)
+);
+
+create table if not exists known_networks (
+    user_id       uuid not null references credentials (user_id) on delete cascade,
+    network       text not null,
+    first_seen_at timestamptz not null default now(),
+    last_seen_at  timestamptz not null default now(),
+    primary key (user_id, network)
+);
+
+-- +goose Down
+
+drop table if exists known_networks;
+
+drop table if exists known_devices;
+
+alter table credentials
+    drop column if exists password_reset_required_at;
@@ -0,0 +1,11 @@
+-- +goose Up
+
+-- password_changed_at binds password reset links to the password they were
+-- issued for. Rehashing the same password leaves it unchanged.
+alter table credentials
+    add column if not exists password_changed_at timestamptz not null default now();
+
+-- +goose Down
+
+alter table credentials
+    drop column if exists password_changed_at;
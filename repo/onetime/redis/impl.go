@@ -0,0 +1,63 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+type OneTimeTokenRepo struct {
+	redisClient *redis.Client
+}
+
+func NewOneTimeTokenRepo(rc *redis.Client) *OneTimeTokenRepo {
+	return &OneTimeTokenRepo{
+		redisClient: rc,
+	}
+}
+
+func (r *OneTimeTokenRepo) Set(ctx context.Context, purpose, tokenHash, value string, ttl time.Duration) error {
+	err := r.redisClient.Set(ctx, key(purpose, tokenHash), value, ttl).Err()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	return nil
+}
+
+func (r *OneTimeTokenRepo) Get(ctx context.Context, purpose, tokenHash string) (string, error) {
+	value, err := r.redisClient.Get(ctx, key(purpose, tokenHash)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", domain.ErrOneTimeTokenNotFound
+		}
+		return "", fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	return value, nil
+}
+
+func (r *OneTimeTokenRepo) Take(ctx context.Context, purpose, tokenHash string) (string, error) {
+	value, err := r.redisClient.GetDel(ctx, key(purpose, tokenHash)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", domain.ErrOneTimeTokenNotFound
+		}
+		return "", fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	return value, nil
+}
+
+func (r *OneTimeTokenRepo) Delete(ctx context.Context, purpose, tokenHash string) error {
+	err := r.redisClient.Del(ctx, key(purpose, tokenHash)).Err()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	return nil
+}
+
+func key(purpose, tokenHash string) string {
+	return "onetime:" + purpose + ":" + tokenHash
+}
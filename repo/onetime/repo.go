@@ -0,0 +1,17 @@
+package onetime
+
+import (
+	"context"
+	"time"
+)
+
+// Repo stores single-use tokens. Tokens are addressed by purpose and by a
+// hash of the token, never by the token itself.
+type Repo interface {
+	Set(ctx context.Context, purpose, tokenHash, value string, ttl time.Duration) error
+	// Get returns the stored value without consuming the token.
+	Get(ctx context.Context, purpose, tokenHash string) (string, error)
+	// Take returns the stored value and deletes the token atomically.
+	Take(ctx context.Context, purpose, tokenHash string) (string, error)
+	Delete(ctx context.Context, purpose, tokenHash string) error
+}
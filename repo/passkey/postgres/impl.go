@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const pgUniqueViolation = "23505"
+
+type PasskeyRepo struct {
+	db *sql.DB
+}
+
+func NewPasskeyRepo(db *sql.DB) *PasskeyRepo {
+	return &PasskeyRepo{
+		db: db,
+	}
+}
+
+//go:embed sql/create_passkey.sql
+var createPasskeySQL string
+
+func (r *PasskeyRepo) CreatePasskey(ctx context.Context, p domain.Passkey, credentialID []byte) (domain.Passkey, error) {
+	err := r.db.QueryRowContext(ctx, createPasskeySQL,
+		credentialID,
+		p.UserID,
+		p.PublicKey,
+		int64(p.SignCount),
+		p.Name,
+	).Scan(
+		&p.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return domain.Passkey{}, domain.ErrPasskeyExists
+		}
+		return domain.Passkey{}, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	p.ID = base64.RawURLEncoding.EncodeToString(credentialID)
+
+	return p, nil
+}
+
+//go:embed sql/get_passkey.sql
+var getPasskeySQL string
+
+func (r *PasskeyRepo) GetPasskey(ctx context.Context, credentialID []byte) (domain.Passkey, error) {
+	p, err := scanPasskey(r.db.QueryRowContext(ctx, getPasskeySQL, credentialID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Passkey{}, domain.ErrPasskeyNotFound
+		}
+		return domain.Passkey{}, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return p, nil
+}
+
+//go:embed sql/list_passkeys.sql
+var listPasskeysSQL string
+
+func (r *PasskeyRepo) ListPasskeys(ctx context.Context, userID uuid.UUID) ([]domain.Passkey, error) {
+	rows, err := r.db.QueryContext(ctx, listPasskeysSQL, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	passkeys := []domain.Passkey{}
+	for rows.Next() {
+		p, err := scanPasskey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+		}
+		passkeys = append(passkeys, p)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return passkeys, nil
+}
+
+//go:embed sql/use_passkey.sql
+var usePasskeySQL string
+
+func (r *PasskeyRepo) UsePasskey(ctx context.Context, credentialID []byte, signCount uint32) error {
+	res, err := r.db.ExecContext(ctx, usePasskeySQL, credentialID, int64(signCount))
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	if n == 0 {
+		return domain.ErrInvalidPasskey
+	}
+
+	return nil
+}
+
+//go:embed sql/delete_passkey.sql
+var deletePasskeySQL string
+
+func (r *PasskeyRepo) DeletePasskey(ctx context.Context, userID uuid.UUID, credentialID []byte) error {
+	res, err := r.db.ExecContext(ctx, deletePasskeySQL, credentialID, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	if n == 0 {
+		return domain.ErrPasskeyNotFound
+	}
+
+	return nil
+}
+
+//go:embed sql/delete_all_passkeys.sql
+var deleteAllPasskeysSQL string
+
+func (r *PasskeyRepo) DeleteAllPasskeys(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, deleteAllPasskeysSQL, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPasskey(row rowScanner) (domain.Passkey, error) {
+	var (
+		p            domain.Passkey
+		credentialID []byte
+		signCount    int64
+	)
+
+	err := row.Scan(
+		&credentialID,
+		&p.UserID,
+		&p.PublicKey,
+		&signCount,
+		&p.Name,
+		&p.CreatedAt,
+		&p.LastUsedAt,
+	)
+	if err != nil {
+		return domain.Passkey{}, err
+	}
+
+	p.ID = base64.RawURLEncoding.EncodeToString(credentialID)
+	p.SignCount = uint32(signCount)
+
+	return p, nil
+}
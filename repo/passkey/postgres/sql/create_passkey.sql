@@ -0,0 +1,8 @@
+insert into passkeys (
+    credential_id,
+    user_id,
+    public_key,
+    sign_count,
+    name
+) values ($1, $2, $3, $4, $5)
+returning created_at
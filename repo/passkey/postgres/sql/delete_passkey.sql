@@ -0,0 +1,3 @@
+delete from passkeys
+where credential_id = $1
+  and user_id = $2
@@ -0,0 +1,9 @@
+select credential_id,
+       user_id,
+       public_key,
+       sign_count,
+       name,
+       created_at,
+       last_used_at
+from passkeys
+where credential_id = $1
@@ -0,0 +1,10 @@
+select credential_id,
+       user_id,
+       public_key,
+       sign_count,
+       name,
+       created_at,
+       last_used_at
+from passkeys
+where user_id = $1
+order by created_at
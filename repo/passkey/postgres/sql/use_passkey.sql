@@ -0,0 +1,5 @@
+update passkeys
+set sign_count   = $2,
+    last_used_at = now()
+where credential_id = $1
+  and (sign_count < $2 or (sign_count = 0 and $2 = 0))
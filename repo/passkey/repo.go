@@ -0,0 +1,22 @@
+package passkey
+
+import (
+	"context"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/google/uuid"
+)
+
+// Repo stores passkeys. Credential ids are raw bytes; domain.Passkey.ID
+// carries their base64url form.
+type Repo interface {
+	CreatePasskey(ctx context.Context, p domain.Passkey, credentialID []byte) (domain.Passkey, error)
+	GetPasskey(ctx context.Context, credentialID []byte) (domain.Passkey, error)
+	ListPasskeys(ctx context.Context, userID uuid.UUID) ([]domain.Passkey, error)
+	// UsePasskey stores the new sign counter. It fails with
+	// domain.ErrInvalidPasskey if the counter did not increase, which
+	// points to a cloned authenticator or a replayed assertion.
+	UsePasskey(ctx context.Context, credentialID []byte, signCount uint32) error
+	DeletePasskey(ctx context.Context, userID uuid.UUID, credentialID []byte) error
+	DeleteAllPasskeys(ctx context.Context, userID uuid.UUID) error
+}
@@ -2,7 +2,12 @@ package redis
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -16,15 +21,103 @@ func NewRefreshTokenRepository(rc *redis.Client) *RefreshTokenRepo {
 	}
 }
 
-func (r *RefreshTokenRepo) Set(ctx context.Context, refreshToken string) error {
-	return r.redisClient.Set(ctx, refreshToken, nil, 0).Err()
+// Set stores the token under its own key and indexes it in a per-user hash
+// of token to session id, so sessions of a user can be revoked together.
+func (r *RefreshTokenRepo) Set(ctx context.Context, userID uuid.UUID, sessionID, refreshToken string, ttl time.Duration) error {
+	_, err := r.redisClient.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, refreshToken, userID.String(), ttl)
+		p.HSet(ctx, userKey(userID), refreshToken, sessionID)
+		p.Expire(ctx, userKey(userID), ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	return nil
 }
 
-func (r *RefreshTokenRepo) Check(ctx context.Context, refreshToken string) error {
-	_, err := r.redisClient.Get(ctx, refreshToken).Result()
-	return err
+func (r *RefreshTokenRepo) Take(ctx context.Context, refreshToken string) error {
+	return r.take(ctx, refreshToken)
 }
 
 func (r *RefreshTokenRepo) Delete(ctx context.Context, refreshToken string) error {
-	return r.redisClient.Del(ctx, refreshToken).Err()
+	err := r.take(ctx, refreshToken)
+	if errors.Is(err, domain.ErrInvlaidRefreshToken) {
+		return nil
+	}
+	return err
+}
+
+// take removes the token with a single GETDEL, so exactly one caller sees
+// it stored.
+func (r *RefreshTokenRepo) take(ctx context.Context, refreshToken string) error {
+	userID, err := r.redisClient.GetDel(ctx, refreshToken).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return domain.ErrInvlaidRefreshToken
+		}
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		// tokens stored before per-user indexing have no owner
+		return nil
+	}
+
+	err = r.redisClient.HDel(ctx, userKey(id), refreshToken).Err()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenRepo) DeleteAllByUserID(ctx context.Context, userID uuid.UUID) error {
+	tokens, err := r.redisClient.HKeys(ctx, userKey(userID)).Result()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	keys := append(tokens, userKey(userID))
+
+	err = r.redisClient.Del(ctx, keys...).Err()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenRepo) DeleteOtherSessions(ctx context.Context, userID uuid.UUID, keepSessionID string) error {
+	sessions, err := r.redisClient.HGetAll(ctx, userKey(userID)).Result()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	var tokens []string
+	for token, sessionID := range sessions {
+		if keepSessionID == "" || sessionID != keepSessionID {
+			tokens = append(tokens, token)
+		}
+	}
+
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	_, err = r.redisClient.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, tokens...)
+		p.HDel(ctx, userKey(userID), tokens...)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return nil
+}
+
+func userKey(userID uuid.UUID) string {
+	return "user_refresh_tokens:" + userID.String()
 }
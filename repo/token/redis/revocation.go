@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type RevocationRepo struct {
+	redisClient *redis.Client
+}
+
+func NewRevocationRepo(rc *redis.Client) *RevocationRepo {
+	return &RevocationRepo{
+		redisClient: rc,
+	}
+}
+
+func (r *RevocationRepo) RevokeBefore(ctx context.Context, userID uuid.UUID, t time.Time, ttl time.Duration) error {
+	err := r.redisClient.Set(ctx, revokedKey(userID), t.Unix(), ttl).Err()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	return nil
+}
+
+func (r *RevocationRepo) RevokedBefore(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	sec, err := r.redisClient.Get(ctx, revokedKey(userID)).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	return time.Unix(sec, 0), nil
+}
+
+func revokedKey(userID uuid.UUID) string {
+	return "access_tokens_revoked:" + userID.String()
+}
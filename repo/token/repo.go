@@ -2,10 +2,27 @@ package token
 
 import (
 	"context"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type RefreshTokenRepo interface {
-	Set(ctx context.Context, refreshToken string) error
-	Check(ctx context.Context, refreshToken string) error
+	Set(ctx context.Context, userID uuid.UUID, sessionID, refreshToken string, ttl time.Duration) error
+	// Take deletes the token and fails with ErrInvlaidRefreshToken if it
+	// was not stored, so concurrent callers cannot both consume it.
+	Take(ctx context.Context, refreshToken string) error
 	Delete(ctx context.Context, refreshToken string) error
+	DeleteAllByUserID(ctx context.Context, userID uuid.UUID) error
+	DeleteOtherSessions(ctx context.Context, userID uuid.UUID, keepSessionID string) error
+}
+
+// RevocationRepo invalidates access tokens, which are otherwise valid until
+// they expire.
+type RevocationRepo interface {
+	// RevokeBefore rejects every token of the user issued at or before t.
+	// The revocation is kept for ttl, the lifetime of an access token.
+	RevokeBefore(ctx context.Context, userID uuid.UUID, t time.Time, ttl time.Duration) error
+	// RevokedBefore returns the zero time if nothing was revoked.
+	RevokedBefore(ctx context.Context, userID uuid.UUID) (time.Time, error)
 }
@@ -0,0 +1,270 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/cluster/user"
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/repo/deletion"
+	"github.com/akemoon/crowdfunding-app-auth/service/audit"
+	"github.com/akemoon/crowdfunding-app-auth/service/creds"
+	"github.com/akemoon/crowdfunding-app-auth/service/lockout"
+	"github.com/akemoon/crowdfunding-app-auth/service/token"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultGracePeriod  = 30 * 24 * time.Hour
+	defaultPollInterval = time.Minute
+	defaultRetryBackoff = time.Minute
+	defaultMaxBackoff   = time.Hour
+
+	claimBatchSize = 50
+	// claimLease hides a claimed deletion from other replicas while it is
+	// processed. It must exceed the time one deletion takes.
+	claimLease = 5 * time.Minute
+)
+
+type Service struct {
+	repo       deletion.Repo
+	credsSvc   *creds.Service
+	tokenSvc   *token.Service
+	lockoutSvc *lockout.Service
+	auditSvc   *audit.Service
+	userClient user.Client
+	cfg        config.AccountDeletion
+}
+
+func NewService(r deletion.Repo, cs *creds.Service, ts *token.Service, ls *lockout.Service, as *audit.Service, uc user.Client, cfg config.AccountDeletion) *Service {
+	if cfg.GracePeriod == 0 {
+		cfg.GracePeriod = defaultGracePeriod
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	if cfg.RetryBackoff == 0 {
+		cfg.RetryBackoff = defaultRetryBackoff
+	}
+	if cfg.MaxBackoff == 0 {
+		cfg.MaxBackoff = defaultMaxBackoff
+	}
+
+	return &Service{
+		repo:       r,
+		credsSvc:   cs,
+		tokenSvc:   ts,
+		lockoutSvc: ls,
+		auditSvc:   as,
+		userClient: uc,
+		cfg:        cfg,
+	}
+}
+
+// RequestDeletion schedules the account for deletion after the grace period
+// and signs the user out everywhere. The account cannot be signed in to
+// while the deletion is pending.
+func (s *Service) RequestDeletion(ctx context.Context, tc domain.TokenClaims, req domain.DeleteAccountRequest) (domain.DeleteAccountResponse, error) {
+	if req.Password == "" {
+		return domain.DeleteAccountResponse{}, domain.ErrInvalidRequest
+	}
+
+	_, err := s.credsSvc.CheckPassword(ctx, tc.UserID, req.Password)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidPassrord) {
+			return domain.DeleteAccountResponse{}, domain.ErrInvalidCurrentPassword
+		}
+		return domain.DeleteAccountResponse{}, fmt.Errorf("creds service: %w", err)
+	}
+
+	purgeAfter := time.Now().Add(s.cfg.GracePeriod).UTC()
+
+	err = s.repo.Schedule(ctx, tc.UserID, purgeAfter)
+	if err != nil {
+		return domain.DeleteAccountResponse{}, fmt.Errorf("deletion repo: %w", err)
+	}
+
+	err = s.tokenSvc.RevokeAllRefreshTokens(ctx, tc.UserID)
+	if err != nil {
+		return domain.DeleteAccountResponse{}, fmt.Errorf("token service: %w", err)
+	}
+
+	err = s.tokenSvc.RevokeAccessTokens(ctx, tc.UserID)
+	if err != nil {
+		return domain.DeleteAccountResponse{}, fmt.Errorf("token service: %w", err)
+	}
+
+	return domain.DeleteAccountResponse{
+		PurgeAfter: purgeAfter,
+	}, nil
+}
+
+// Run purges accounts whose grace period is over until ctx is done. Failed
+// deletions are retried with exponential backoff until they succeed.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.purgeDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) purgeDue(ctx context.Context) {
+	for {
+		deletions, err := s.repo.ClaimDue(ctx, claimBatchSize, claimLease)
+		if err != nil {
+			log.Printf("claim account deletions: %s", err)
+			return
+		}
+
+		for _, d := range deletions {
+			err = s.purge(ctx, d)
+			if err == nil {
+				continue
+			}
+
+			log.Printf("delete account %s (attempt %d): %s", d.UserID, d.Attempts, err)
+
+			err = s.repo.Retry(ctx, d.UserID, time.Now().Add(s.backoff(d.Attempts)), err.Error())
+			if err != nil {
+				log.Printf("reschedule deletion of %s: %s", d.UserID, err)
+			}
+		}
+
+		if len(deletions) < claimBatchSize {
+			return
+		}
+	}
+}
+
+// purge deletes the profile first: once the credentials are gone nothing
+// else refers to the user, so they must go last. Every step tolerates
+// having already run.
+func (s *Service) purge(ctx context.Context, d domain.AccountDeletion) error {
+	if d.UserDeletedAt == nil {
+		err := s.userClient.DeleteUser(ctx, d.UserID)
+		if err != nil {
+			return fmt.Errorf("user client: %w", err)
+		}
+
+		err = s.repo.MarkUserDeleted(ctx, d.UserID)
+		if err != nil {
+			return fmt.Errorf("deletion repo: %w", err)
+		}
+	}
+
+	var err error
+	if s.cfg.Anonymize {
+		err = s.credsSvc.AnonymizeCreds(ctx, d.UserID)
+	} else {
+		err = s.credsSvc.DeleteCredsByUserID(ctx, d.UserID)
+	}
+	if err != nil && !errors.Is(err, domain.ErrCredsNotFound) {
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	err = s.tokenSvc.RevokeAllRefreshTokens(ctx, d.UserID)
+	if err != nil {
+		return fmt.Errorf("token service: %w", err)
+	}
+
+	err = s.repo.Complete(ctx, d.UserID)
+	if err != nil {
+		return fmt.Errorf("deletion repo: %w", err)
+	}
+
+	return nil
+}
+
+// SetStatus suspends or disables the account and ends its sessions at
+// once, including access tokens that have not expired yet.
+func (s *Service) SetStatus(ctx context.Context, userID uuid.UUID, req domain.SetAccountStatusRequest) error {
+	if req.Status != domain.AccountStatusSuspended && req.Status != domain.AccountStatusDisabled {
+		return domain.ErrInvalidRequest
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return domain.ErrInvalidRequest
+	}
+	if req.Until != nil && !req.Until.After(time.Now()) {
+		return domain.ErrInvalidRequest
+	}
+
+	err := s.credsSvc.SetStatus(ctx, userID, req.Status, strings.TrimSpace(req.Reason), req.Until)
+	if err != nil {
+		if errors.Is(err, domain.ErrCredsNotFound) {
+			return domain.ErrAccountNotFound
+		}
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	err = s.tokenSvc.RevokeAllRefreshTokens(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("token service: %w", err)
+	}
+
+	err = s.tokenSvc.RevokeAccessTokens(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("token service: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, domain.AuthEvent{
+		Type:   domain.AuthEventAdminSuspend,
+		UserID: &userID,
+		Reason: fmt.Sprintf("%s: %s", req.Status, strings.TrimSpace(req.Reason)),
+	})
+
+	return nil
+}
+
+// Reinstate makes a suspended or disabled account active again.
+func (s *Service) Reinstate(ctx context.Context, userID uuid.UUID) error {
+	err := s.credsSvc.SetStatus(ctx, userID, domain.AccountStatusActive, "", nil)
+	if err != nil {
+		if errors.Is(err, domain.ErrCredsNotFound) {
+			return domain.ErrAccountNotFound
+		}
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, domain.AuthEvent{
+		Type:   domain.AuthEventAdminReinstate,
+		UserID: &userID,
+	})
+
+	return nil
+}
+
+// Unlock lifts a sign-in lockout immediately.
+func (s *Service) Unlock(ctx context.Context, userID uuid.UUID) error {
+	err := s.lockoutSvc.Unlock(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("lockout service: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, domain.AuthEvent{
+		Type:   domain.AuthEventAdminUnlock,
+		UserID: &userID,
+	})
+
+	return nil
+}
+
+func (s *Service) backoff(attempts int) time.Duration {
+	d := s.cfg.RetryBackoff
+	for i := 1; i < attempts && d < s.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, s.cfg.MaxBackoff)
+}
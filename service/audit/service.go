@@ -0,0 +1,272 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/repo/audit"
+	"github.com/akemoon/crowdfunding-app-auth/tool/useragent"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultBufferSize    = 4096
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+
+	defaultRetention     = 180 * 24 * time.Hour
+	defaultPurgeInterval = time.Hour
+	purgeBatchSize       = 1000
+
+	defaultListLimit = 50
+	maxListLimit     = 500
+
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+
+	// shutdownFlushTimeout bounds the final write when the service stops.
+	shutdownFlushTimeout = 5 * time.Second
+	maxUserAgentLen      = 512
+	maxEmailLen          = 320
+)
+
+type Service struct {
+	repo   audit.Repo
+	events chan domain.AuthEvent
+	cfg    config.Audit
+}
+
+func NewService(repo audit.Repo, cfg config.Audit) *Service {
+	if cfg.BufferSize == 0 {
+		cfg.BufferSize = defaultBufferSize
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+	if cfg.Retention == 0 {
+		cfg.Retention = defaultRetention
+	}
+	if cfg.PurgeInterval == 0 {
+		cfg.PurgeInterval = defaultPurgeInterval
+	}
+
+	return &Service{
+		repo:   repo,
+		events: make(chan domain.AuthEvent, cfg.BufferSize),
+		cfg:    cfg,
+	}
+}
+
+// Record queues ev with the request metadata of ctx. It never blocks the
+// caller: when the queue is full the event is logged and dropped.
+func (s *Service) Record(ctx context.Context, ev domain.AuthEvent) {
+	meta := domain.RequestMetaFromContext(ctx)
+
+	ev.IP = meta.IP
+	ev.UserAgent = truncate(meta.UserAgent, maxUserAgentLen)
+	ev.Email = truncate(ev.Email, maxEmailLen)
+	ev.RequestID = meta.RequestID
+	if ev.OccurredAt.IsZero() {
+		ev.OccurredAt = time.Now().UTC()
+	}
+
+	select {
+	case s.events <- ev:
+	default:
+		log.Printf("audit queue full, dropped %s event of %v", ev.Type, ev.UserID)
+	}
+}
+
+// Run writes queued events in batches until ctx is done, then flushes what
+// is left. Events older than the retention period are purged meanwhile.
+func (s *Service) Run(ctx context.Context) {
+	go s.purge(ctx)
+
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]domain.AuthEvent, 0, s.cfg.BatchSize)
+
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+
+		err := s.repo.Insert(ctx, batch)
+		if err != nil {
+			log.Printf("write %d audit events: %s", len(batch), err)
+		}
+
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case ev := <-s.events:
+			batch = append(batch, ev)
+			if len(batch) >= s.cfg.BatchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), shutdownFlushTimeout)
+			defer cancel()
+
+			for {
+				select {
+				case ev := <-s.events:
+					batch = append(batch, ev)
+					if len(batch) >= s.cfg.BatchSize {
+						flush(flushCtx)
+					}
+				default:
+					flush(flushCtx)
+					return
+				}
+			}
+		}
+	}
+}
+
+// List returns events matching filter, newest first. cursor is the
+// NextCursor of the previous page.
+func (s *Service) List(ctx context.Context, filter domain.AuthEventFilter, cursor string) (domain.AuthEventsResponse, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+
+	if cursor != "" {
+		id, err := decodeCursor(cursor)
+		if err != nil {
+			return domain.AuthEventsResponse{}, domain.ErrInvalidRequest
+		}
+		filter.BeforeID = id
+	}
+
+	events, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return domain.AuthEventsResponse{}, fmt.Errorf("audit repo: %w", err)
+	}
+
+	resp := domain.AuthEventsResponse{
+		Events: events,
+	}
+	if len(events) == filter.Limit {
+		resp.NextCursor = encodeCursor(events[len(events)-1].ID)
+	}
+
+	return resp, nil
+}
+
+// LoginHistory returns the sign-in attempts on the account of userID within
+// the retention period, newest first.
+func (s *Service) LoginHistory(ctx context.Context, userID uuid.UUID, limit int, cursor string) (domain.LoginHistoryResponse, error) {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+
+	// purging runs periodically, so expired events may still be stored
+	from := time.Now().Add(-s.cfg.Retention)
+
+	events, err := s.List(ctx, domain.AuthEventFilter{
+		UserID: &userID,
+		Types:  []domain.AuthEventType{domain.AuthEventSignIn, domain.AuthEventSignInFailed},
+		From:   &from,
+		Limit:  limit,
+	}, cursor)
+	if err != nil {
+		return domain.LoginHistoryResponse{}, err
+	}
+
+	resp := domain.LoginHistoryResponse{
+		Entries:    make([]domain.LoginHistoryEntry, 0, len(events.Events)),
+		NextCursor: events.NextCursor,
+	}
+	for _, ev := range events.Events {
+		resp.Entries = append(resp.Entries, domain.LoginHistoryEntry{
+			Success:    ev.Type == domain.AuthEventSignIn,
+			Method:     ev.Method,
+			IP:         ev.IP,
+			Device:     useragent.Describe(ev.UserAgent),
+			OccurredAt: ev.OccurredAt,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *Service) purge(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		s.purgeExpired(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeExpired deletes in batches so a large backlog does not hold one
+// long-running transaction.
+func (s *Service) purgeExpired(ctx context.Context) {
+	before := time.Now().Add(-s.cfg.Retention)
+
+	for ctx.Err() == nil {
+		n, err := s.repo.DeleteExpired(ctx, before, purgeBatchSize)
+		if err != nil {
+			log.Printf("purge audit events: %s", err)
+			return
+		}
+		if n < purgeBatchSize {
+			return
+		}
+	}
+}
+
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+
+	return id, nil
+}
+
+// truncate cuts s to at most n bytes of valid UTF-8, which headers do not
+// guarantee and Postgres requires.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		s = s[:n]
+	}
+	return strings.ToValidUTF8(s, "")
+}
@@ -2,31 +2,73 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"time"
 
 	"github.com/akemoon/crowdfunding-app-auth/cluster/user"
+	"github.com/akemoon/crowdfunding-app-auth/config"
 	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/service/audit"
 	"github.com/akemoon/crowdfunding-app-auth/service/creds"
+	"github.com/akemoon/crowdfunding-app-auth/service/device"
+	"github.com/akemoon/crowdfunding-app-auth/service/emailotp"
+	"github.com/akemoon/crowdfunding-app-auth/service/magiclink"
+	"github.com/akemoon/crowdfunding-app-auth/service/mfa"
+	"github.com/akemoon/crowdfunding-app-auth/service/passkey"
 	"github.com/akemoon/crowdfunding-app-auth/service/token"
+	"github.com/akemoon/crowdfunding-app-auth/service/verification"
+	"github.com/akemoon/crowdfunding-app-auth/tool/mailer"
+	"github.com/google/uuid"
 )
 
+// deviceCheckTimeout bounds the new-device check that runs after the
+// response, including the notification email.
+const deviceCheckTimeout = 30 * time.Second
+
 type Service struct {
-	userClient user.Client
-	credsSvc   *creds.Service
-	tokenSvc   *token.Service
+	userClient      user.Client
+	credsSvc        *creds.Service
+	tokenSvc        *token.Service
+	verificationSvc *verification.Service
+	mfaSvc          *mfa.Service
+	passkeySvc      *passkey.Service
+	magicLinkSvc    *magiclink.Service
+	emailOTPSvc     *emailotp.Service
+	auditSvc        *audit.Service
+	deviceSvc       *device.Service
+	mailer          mailer.Mailer
+	cfg             config.SignUp
 }
 
-func NewService(uc user.Client, cs *creds.Service, ts *token.Service) *Service {
+func NewService(uc user.Client, cs *creds.Service, ts *token.Service, vs *verification.Service, ms *mfa.Service, ps *passkey.Service, mls *magiclink.Service, eos *emailotp.Service, as *audit.Service, ds *device.Service, m mailer.Mailer, cfg config.SignUp) *Service {
+	if cfg.ConflictPolicy == "" {
+		cfg.ConflictPolicy = config.SignUpConflictReveal
+	}
+
 	return &Service{
-		userClient: uc,
-		credsSvc:   cs,
-		tokenSvc:   ts,
+		userClient:      uc,
+		credsSvc:        cs,
+		tokenSvc:        ts,
+		verificationSvc: vs,
+		mfaSvc:          ms,
+		passkeySvc:      ps,
+		magicLinkSvc:    mls,
+		emailOTPSvc:     eos,
+		auditSvc:        as,
+		deviceSvc:       ds,
+		mailer:          m,
+		cfg:             cfg,
 	}
 }
 
 func (s *Service) SignUp(ctx context.Context, req domain.SignUpRequest) error {
 	userID, err := s.credsSvc.CreateCreds(ctx, req)
 	if err != nil {
+		if errors.Is(err, domain.ErrEmailExists) && s.cfg.ConflictPolicy == config.SignUpConflictNotify {
+			return s.notifyOwner(ctx, req.Email)
+		}
 		return fmt.Errorf("creds service: %w", err)
 	}
 
@@ -43,29 +85,310 @@ func (s *Service) SignUp(ctx context.Context, req domain.SignUpRequest) error {
 		return fmt.Errorf("user client: %w", err)
 	}
 
+	s.auditSvc.Record(ctx, domain.AuthEvent{
+		Type:   domain.AuthEventSignUp,
+		UserID: &userID,
+	})
+
+	err = s.verificationSvc.SendVerification(ctx, userID, req.Email)
+	if err != nil {
+		log.Printf("send verification email: %s", err)
+	}
+
+	return nil
+}
+
+// notifyOwner tells the owner of email that someone tried to sign up with
+// it. The caller answers as for a new account either way.
+func (s *Service) notifyOwner(ctx context.Context, email string) error {
+	c, err := s.credsSvc.GetCredsByEmail(ctx, email)
+	if err != nil {
+		// the account was deleted in between; nothing to disclose
+		if errors.Is(err, domain.ErrCredsNotFound) {
+			return nil
+		}
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, domain.AuthEvent{
+		Type:   domain.AuthEventSignUpConflict,
+		UserID: &c.UserID,
+	})
+
+	reset := "use the \"forgot password\" option when signing in"
+	if s.cfg.PasswordResetURL != "" {
+		reset = "request a reset at " + s.cfg.PasswordResetURL
+	}
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      c.Email,
+		Subject: "Sign-up attempt with your email",
+		Body: fmt.Sprintf("Someone tried to create an account with this email address, which already has an account.\n\nIf it was you, sign in instead; if you forgot your password, %s. If it wasn't you, ignore this email.",
+			reset),
+	})
+	if err != nil {
+		log.Printf("sign-up conflict notification of %s: %s", c.UserID, err)
+	}
+
 	return nil
 }
 
 func (s *Service) SignIn(ctx context.Context, req domain.SignInRequest) (domain.SignInResponse, error) {
-	userID, err := s.credsSvc.ValidateCredentials(ctx, req)
+	c, err := s.credsSvc.ValidateCredentials(ctx, req)
+	if err != nil {
+		return s.recordSignIn(ctx, domain.SignInMethodPassword, c.UserID, req.Email, domain.SignInResponse{}, fmt.Errorf("creds service: %w", err))
+	}
+
+	resp, err := s.startSession(ctx, c)
+	return s.recordSignIn(ctx, domain.SignInMethodPassword, c.UserID, c.Email, resp, err)
+}
+
+// SignInWithMagicLink signs in with a link from the inbox. The link stands
+// in for the password only: users with 2FA still get an MFA challenge.
+func (s *Service) SignInWithMagicLink(ctx context.Context, req domain.MagicLinkVerifyRequest) (domain.SignInResponse, error) {
+	c, err := s.magicLinkSvc.Verify(ctx, req.Token, req.Nonce)
+	if err != nil {
+		return s.recordSignIn(ctx, domain.SignInMethodMagicLink, uuid.Nil, "", domain.SignInResponse{}, fmt.Errorf("magic link service: %w", err))
+	}
+
+	resp, err := s.startSession(ctx, c)
+	return s.recordSignIn(ctx, domain.SignInMethodMagicLink, c.UserID, c.Email, resp, err)
+}
+
+// SignInWithEmailOTP signs in with a code from the inbox. Like a magic
+// link, the code only replaces the password.
+func (s *Service) SignInWithEmailOTP(ctx context.Context, req domain.EmailOTPVerifyRequest) (domain.SignInResponse, error) {
+	c, err := s.emailOTPSvc.Verify(ctx, req.Email, req.Code)
+	if err != nil {
+		return s.recordSignIn(ctx, domain.SignInMethodEmailOTP, c.UserID, req.Email, domain.SignInResponse{}, fmt.Errorf("email otp service: %w", err))
+	}
+
+	resp, err := s.startSession(ctx, c)
+	return s.recordSignIn(ctx, domain.SignInMethodEmailOTP, c.UserID, c.Email, resp, err)
+}
+
+// startSession applies the sign-in policies to a user who has proven their
+// first factor and issues tokens or an MFA challenge.
+func (s *Service) startSession(ctx context.Context, c domain.Creds) (domain.SignInResponse, error) {
+	err := checkAccount(c)
+	if err != nil {
+		return domain.SignInResponse{}, err
+	}
+
+	tc := domain.TokenClaims{
+		UserID:    c.UserID,
+		SessionID: uuid.NewString(),
+	}
+
+	err = s.verificationSvc.ApplySignInPolicy(c, &tc)
+	if err != nil {
+		return domain.SignInResponse{}, fmt.Errorf("verification service: %w", err)
+	}
+
+	mfaMethods, err := s.mfaSvc.Methods(ctx, c.UserID)
+	if err != nil {
+		return domain.SignInResponse{}, fmt.Errorf("mfa service: %w", err)
+	}
+
+	if len(mfaMethods) > 0 {
+		mfaToken, err := s.mfaSvc.CreateChallenge(ctx, tc)
+		if err != nil {
+			return domain.SignInResponse{}, fmt.Errorf("mfa service: %w", err)
+		}
+
+		return domain.SignInResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			MFAMethods:  mfaMethods,
+		}, nil
+	}
+
+	return s.issueTokens(ctx, tc)
+}
+
+// SignInWithPasskey signs in without a password. A user-verified passkey
+// already combines possession and a biometric or PIN, so no second factor is
+// asked for.
+func (s *Service) SignInWithPasskey(ctx context.Context, req domain.SignInPasskeyRequest) (domain.SignInResponse, error) {
+	userID, err := s.passkeySvc.FinishLogin(ctx, req.Credential)
+	if err != nil {
+		return s.recordSignIn(ctx, domain.SignInMethodPasskey, uuid.Nil, "", domain.SignInResponse{}, fmt.Errorf("passkey service: %w", err))
+	}
+
+	resp, err := s.passkeySession(ctx, userID)
+	return s.recordSignIn(ctx, domain.SignInMethodPasskey, userID, "", resp, err)
+}
+
+func (s *Service) passkeySession(ctx context.Context, userID uuid.UUID) (domain.SignInResponse, error) {
+	c, err := s.credsSvc.GetCredsByUserID(ctx, userID)
 	if err != nil {
 		return domain.SignInResponse{}, fmt.Errorf("creds service: %w", err)
 	}
 
-	accessToken, err := s.tokenSvc.GenAccessToken(ctx, domain.TokenClaims{
-		UserID: userID,
-	})
+	err = checkAccount(c)
+	if err != nil {
+		return domain.SignInResponse{}, err
+	}
+
+	tc := domain.TokenClaims{
+		UserID:    c.UserID,
+		SessionID: uuid.NewString(),
+	}
+
+	err = s.verificationSvc.ApplySignInPolicy(c, &tc)
+	if err != nil {
+		return domain.SignInResponse{}, fmt.Errorf("verification service: %w", err)
+	}
+
+	return s.issueTokens(ctx, tc)
+}
+
+// CompleteMFA finishes a sign-in that SignIn answered with an MFA challenge.
+func (s *Service) CompleteMFA(ctx context.Context, req domain.SignInMFARequest) (domain.SignInResponse, error) {
+	tc, err := s.mfaSvc.VerifyChallenge(ctx, req)
+	if err != nil {
+		return s.recordSignIn(ctx, domain.SignInMethodMFA, tc.UserID, "", domain.SignInResponse{}, fmt.Errorf("mfa service: %w", err))
+	}
+
+	resp, err := s.mfaSession(ctx, tc)
+	return s.recordSignIn(ctx, domain.SignInMethodMFA, tc.UserID, "", resp, err)
+}
+
+func (s *Service) mfaSession(ctx context.Context, tc domain.TokenClaims) (domain.SignInResponse, error) {
+	// the account may have been restricted since the challenge was issued
+	c, err := s.credsSvc.GetCredsByUserID(ctx, tc.UserID)
+	if err != nil {
+		return domain.SignInResponse{}, fmt.Errorf("creds service: %w", err)
+	}
+
+	err = checkAccount(c)
+	if err != nil {
+		return domain.SignInResponse{}, err
+	}
+
+	return s.issueTokens(ctx, tc)
+}
+
+// recordSignIn audits the outcome of a sign-in attempt and passes it on.
+// userID is uuid.Nil and email empty when the attempt names no account.
+func (s *Service) recordSignIn(ctx context.Context, method string, userID uuid.UUID, email string, resp domain.SignInResponse, err error) (domain.SignInResponse, error) {
+	ev := domain.AuthEvent{
+		Type:   domain.AuthEventSignIn,
+		Method: method,
+	}
+
+	if userID != uuid.Nil {
+		ev.UserID = &userID
+	}
+
+	switch {
+	case err != nil:
+		ev.Type = domain.AuthEventSignInFailed
+		ev.Reason = domain.SignInFailureReason(err)
+		// keep the email only when no account can be named
+		if ev.UserID == nil {
+			ev.Email = email
+		}
+	case resp.MFARequired:
+		ev.Type = domain.AuthEventMFAChallenge
+	}
+
+	s.auditSvc.Record(ctx, ev)
+
+	return resp, err
+}
+
+// Refresh rotates a refresh token unless the account was suspended,
+// disabled or scheduled for deletion since the session started. The new
+// tokens are restricted by the email verification policy as of now.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (domain.SignInResponse, error) {
+	userID, err := s.tokenSvc.RefreshTokenOwner(ctx, refreshToken)
 	if err != nil {
 		return domain.SignInResponse{}, fmt.Errorf("token service: %w", err)
 	}
 
-	refreshToken, err := s.tokenSvc.GenRefreshToken(ctx, domain.TokenClaims{
-		UserID: userID,
-	})
+	resp, err := s.refresh(ctx, userID, refreshToken)
+
+	ev := domain.AuthEvent{
+		Type:   domain.AuthEventTokenRefresh,
+		UserID: &userID,
+	}
+	if err != nil {
+		ev.Type = domain.AuthEventTokenRefreshFailed
+		ev.Reason = domain.SignInFailureReason(err)
+	}
+	s.auditSvc.Record(ctx, ev)
+
+	return resp, err
+}
+
+func (s *Service) refresh(ctx context.Context, userID uuid.UUID, refreshToken string) (domain.SignInResponse, error) {
+	c, err := s.credsSvc.GetCredsByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrCredsNotFound) {
+			return domain.SignInResponse{}, domain.ErrInvlaidRefreshToken
+		}
+		return domain.SignInResponse{}, fmt.Errorf("creds service: %w", err)
+	}
+
+	err = checkAccount(c)
+	if err != nil {
+		return domain.SignInResponse{}, err
+	}
+
+	// the email may have been verified, or the policy changed, since the
+	// session started
+	tc := domain.TokenClaims{UserID: c.UserID}
+	err = s.verificationSvc.ApplySignInPolicy(c, &tc)
+	if err != nil {
+		return domain.SignInResponse{}, fmt.Errorf("verification service: %w", err)
+	}
+
+	resp, err := s.tokenSvc.Refresh(ctx, refreshToken, tc)
+	if err != nil {
+		return domain.SignInResponse{}, fmt.Errorf("token service: %w", err)
+	}
+
+	return resp, nil
+}
+
+// checkAccount rejects accounts that may not start or extend a session.
+// A required password reset blocks every sign-in method, not only the
+// password, until the owner sets a new password.
+func checkAccount(c domain.Creds) error {
+	if c.DeletionRequested() {
+		return domain.ErrAccountPendingDeletion
+	}
+	if c.PasswordResetRequired() {
+		return domain.ErrPasswordResetRequired
+	}
+
+	return c.CheckStatus(time.Now())
+}
+
+func (s *Service) issueTokens(ctx context.Context, tc domain.TokenClaims) (domain.SignInResponse, error) {
+	accessToken, err := s.tokenSvc.GenAccessToken(ctx, tc)
 	if err != nil {
 		return domain.SignInResponse{}, fmt.Errorf("token service: %w", err)
 	}
 
+	refreshToken, err := s.tokenSvc.GenRefreshToken(ctx, tc)
+	if err != nil {
+		return domain.SignInResponse{}, fmt.Errorf("token service: %w", err)
+	}
+
+	// the session exists already: the response neither waits for the
+	// device check and its email nor fails with them
+	go func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, deviceCheckTimeout)
+		defer cancel()
+
+		err := s.deviceSvc.SignedIn(ctx, tc.UserID)
+		if err != nil {
+			log.Printf("new device notification of %s: %s", tc.UserID, err)
+		}
+	}(context.WithoutCancel(ctx))
+
 	return domain.SignInResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
@@ -82,5 +405,14 @@ func (s *Service) SignOut(ctx context.Context, refreshToken string) error {
 		return fmt.Errorf("token service: %w", err)
 	}
 
+	ev := domain.AuthEvent{
+		Type: domain.AuthEventSignOut,
+	}
+	userID, err := s.tokenSvc.RefreshTokenOwner(ctx, refreshToken)
+	if err == nil {
+		ev.UserID = &userID
+	}
+	s.auditSvc.Record(ctx, ev)
+
 	return nil
 }
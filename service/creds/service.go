@@ -2,27 +2,94 @@ package creds
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/akemoon/crowdfunding-app-auth/domain"
 	"github.com/akemoon/crowdfunding-app-auth/repo/creds"
+	"github.com/akemoon/crowdfunding-app-auth/service/lockout"
+	"github.com/akemoon/crowdfunding-app-auth/tool/emailnorm"
 	"github.com/akemoon/crowdfunding-app-auth/tool/hasher"
+	"github.com/akemoon/crowdfunding-app-auth/tool/passpolicy"
+	"github.com/akemoon/crowdfunding-app-auth/tool/randtoken"
 	"github.com/google/uuid"
 )
 
+const (
+	codeInvalidEmail = "invalid_format"
+	codeRequired     = "required"
+)
+
+// unknownEmailNamespace derives lockout keys for emails without an account,
+// so they lock after the same failures as a real account and a lock tells
+// nothing about whether an email is registered.
+var unknownEmailNamespace = uuid.MustParse("d39e9d6e-1173-4fa2-9ec6-a59c12d40881")
+
 type Service struct {
-	repo   creds.Repo
-	hasher hasher.Hasher
+	repo       creds.Repo
+	hasher     hasher.Hasher
+	policy     *passpolicy.Policy
+	lockoutSvc *lockout.Service
+
+	// dummyHash is compared against when a sign-in names no account, so
+	// the response takes as long as for a wrong password.
+	dummyHash func() (string, error)
 }
 
-func NewService(repo creds.Repo, hasher hasher.Hasher) *Service {
+func NewService(repo creds.Repo, hasher hasher.Hasher, policy *passpolicy.Policy, ls *lockout.Service) *Service {
 	return &Service{
-		repo:   repo,
-		hasher: hasher,
+		repo:       repo,
+		hasher:     hasher,
+		policy:     policy,
+		lockoutSvc: ls,
+		dummyHash: sync.OnceValues(func() (string, error) {
+			password, err := randtoken.New()
+			if err != nil {
+				return "", err
+			}
+			return hasher.Hash(password)
+		}),
 	}
 }
 
+// ValidatePassword applies the password policy. userInputs are values the
+// password must not resemble.
+func (s *Service) ValidatePassword(field, password string, userInputs ...string) error {
+	return s.policy.Validate(field, password, userInputs...)
+}
+
+// NormalizeEmail returns the form emails are stored and compared in, so
+// addresses differing only in case or Unicode form name one account. It
+// returns a *domain.ValidationError for field if email is malformed.
+func (s *Service) NormalizeEmail(field, email string) (string, error) {
+	normalized, err := emailnorm.Normalize(email)
+	if err != nil {
+		return "", &domain.ValidationError{Fields: []domain.FieldError{{
+			Field:   field,
+			Code:    codeInvalidEmail,
+			Message: "is not a valid email address",
+		}}}
+	}
+
+	return normalized, nil
+}
+
 func (s *Service) CreateCreds(ctx context.Context, req domain.SignUpRequest) (uuid.UUID, error) {
+	email, err := s.NormalizeEmail("email", req.Email)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	req.Email = email
+
+	err = s.ValidatePassword("password", req.Password, req.Email, req.Username)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
 	passwordHash, err := s.hasher.Hash(req.Password)
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("%w: %s", domain.ErrInternal, err.Error())
@@ -48,16 +115,268 @@ func (s *Service) DeleteCredsByUserID(ctx context.Context, userID uuid.UUID) err
 	return nil
 }
 
-func (s *Service) ValidateCredentials(ctx context.Context, req domain.SignInRequest) (uuid.UUID, error) {
-	creds, err := s.repo.GetCredsByEmail(ctx, req.Email)
+func (s *Service) AnonymizeCreds(ctx context.Context, userID uuid.UUID) error {
+	err := s.repo.AnonymizeCreds(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("repo: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) SetStatus(ctx context.Context, userID uuid.UUID, status domain.AccountStatus, reason string, until *time.Time) error {
+	err := s.repo.SetStatus(ctx, userID, status, reason, until)
+	if err != nil {
+		return fmt.Errorf("repo: %w", err)
+	}
+
+	return nil
+}
+
+// RequirePasswordReset refuses password sign-in until the password is
+// changed or reset.
+func (s *Service) RequirePasswordReset(ctx context.Context, userID uuid.UUID) error {
+	err := s.repo.RequirePasswordReset(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("repo: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateCredentials returns the account matching email and password. If
+// the account exists but the attempt fails, the returned Creds carry only
+// its UserID, so the failure can be attributed to the account.
+//
+// A missing or malformed field is a *domain.ValidationError. An unknown
+// email and a wrong password both fail with domain.ErrInvalidCredentials
+// after a password hash comparison, and repeated failures lock either the
+// same way, so neither the error nor the response time tells whether an
+// email is registered.
+func (s *Service) ValidateCredentials(ctx context.Context, req domain.SignInRequest) (domain.Creds, error) {
+	err := validateSignIn(req)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("creds repo: %w", err)
+		return domain.Creds{}, err
+	}
+
+	email, err := s.NormalizeEmail("email", req.Email)
+	if err != nil {
+		return domain.Creds{}, err
+	}
+
+	creds, err := s.repo.GetCredsByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrCredsNotFound) {
+			return domain.Creds{}, s.rejectUnknown(ctx, email, req.Password)
+		}
+		return domain.Creds{}, fmt.Errorf("creds repo: %w", err)
+	}
+
+	// anonymized accounts have no password to compare with
+	if creds.PasswordHash == "" {
+		return domain.Creds{}, s.rejectUnknown(ctx, email, req.Password)
+	}
+
+	err = s.lockoutSvc.Check(ctx, creds.UserID)
+	if err != nil {
+		return domain.Creds{UserID: creds.UserID}, fmt.Errorf("lockout service: %w", err)
 	}
 
 	err = s.hasher.Compare(req.Password, creds.PasswordHash)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("password compare: %s", err)
+		if errors.Is(err, domain.ErrInvalidPassrord) {
+			lockErr := s.lockoutSvc.RegisterFailure(ctx, creds.UserID)
+			if errors.Is(lockErr, domain.ErrAccountLocked) {
+				return domain.Creds{UserID: creds.UserID}, fmt.Errorf("lockout service: %w", lockErr)
+			}
+			if lockErr != nil {
+				log.Printf("register sign-in failure of %s: %s", creds.UserID, lockErr)
+			}
+			return domain.Creds{UserID: creds.UserID}, domain.ErrInvalidCredentials
+		}
+		return domain.Creds{UserID: creds.UserID}, fmt.Errorf("password compare: %w", err)
+	}
+
+	err = s.lockoutSvc.Reset(ctx, creds.UserID)
+	if err != nil {
+		log.Printf("reset sign-in failures of %s: %s", creds.UserID, err)
+	}
+
+	// checked after the password so the error tells nothing to a guesser
+	if creds.PasswordResetRequired() {
+		return domain.Creds{UserID: creds.UserID}, domain.ErrPasswordResetRequired
+	}
+
+	s.rehash(ctx, creds, req.Password)
+
+	return creds, nil
+}
+
+// validateSignIn reports the required fields missing from a sign-in
+// request. The email format is checked when it is normalized.
+func validateSignIn(req domain.SignInRequest) error {
+	var fields []domain.FieldError
+
+	if strings.TrimSpace(req.Email) == "" {
+		fields = append(fields, domain.FieldError{
+			Field:   "email",
+			Code:    codeRequired,
+			Message: "is required",
+		})
+	}
+	if req.Password == "" {
+		fields = append(fields, domain.FieldError{
+			Field:   "password",
+			Code:    codeRequired,
+			Message: "is required",
+		})
+	}
+
+	if len(fields) > 0 {
+		return &domain.ValidationError{Fields: fields}
+	}
+
+	return nil
+}
+
+// rejectUnknown fails a sign-in that names no account the way a wrong
+// password fails: it is subject to lockout and spends the time of a
+// password check.
+func (s *Service) rejectUnknown(ctx context.Context, email, password string) error {
+	key := uuid.NewSHA1(unknownEmailNamespace, []byte(email))
+
+	err := s.lockoutSvc.Check(ctx, key)
+	if err != nil {
+		return fmt.Errorf("lockout service: %w", err)
+	}
+
+	hash, err := s.dummyHash()
+	if err != nil {
+		return fmt.Errorf("%w: dummy hash: %s", domain.ErrInternal, err)
+	}
+
+	_ = s.hasher.Compare(password, hash)
+
+	err = s.lockoutSvc.RegisterFailure(ctx, key)
+	if errors.Is(err, domain.ErrAccountLocked) {
+		return fmt.Errorf("lockout service: %w", err)
+	}
+	if err != nil {
+		log.Printf("register sign-in failure of unknown email: %s", err)
+	}
+
+	return domain.ErrInvalidCredentials
+}
+
+// rehash upgrades a hash made by an older algorithm or with weaker
+// parameters. It runs after a successful login, the only time the plaintext
+// password is known. Failures are logged and never block the login.
+func (s *Service) rehash(ctx context.Context, c domain.Creds, password string) {
+	if !s.hasher.NeedsRehash(c.PasswordHash) {
+		return
+	}
+
+	passwordHash, err := s.hasher.Hash(password)
+	if err != nil {
+		log.Printf("rehash password of %s: %s", c.UserID, err)
+		return
+	}
+
+	err = s.repo.RehashPassword(ctx, c.UserID, passwordHash)
+	if err != nil {
+		log.Printf("rehash password of %s: %s", c.UserID, err)
+	}
+}
+
+// GetCredsByEmail looks up an account by any spelling of its email.
+// Malformed addresses are reported as not found.
+func (s *Service) GetCredsByEmail(ctx context.Context, email string) (domain.Creds, error) {
+	email, err := emailnorm.Normalize(email)
+	if err != nil {
+		return domain.Creds{}, domain.ErrCredsNotFound
+	}
+
+	c, err := s.repo.GetCredsByEmail(ctx, email)
+	if err != nil {
+		return domain.Creds{}, fmt.Errorf("repo: %w", err)
+	}
+
+	return c, nil
+}
+
+func (s *Service) GetCredsByUserID(ctx context.Context, userID uuid.UUID) (domain.Creds, error) {
+	c, err := s.repo.GetCredsByUserID(ctx, userID)
+	if err != nil {
+		return domain.Creds{}, fmt.Errorf("repo: %w", err)
+	}
+
+	return c, nil
+}
+
+func (s *Service) UpdatePassword(ctx context.Context, userID uuid.UUID, password string) error {
+	passwordHash, err := s.hasher.Hash(password)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err.Error())
+	}
+
+	err = s.repo.UpdatePasswordHash(ctx, userID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("repo: %w", err)
+	}
+
+	return nil
+}
+
+// CheckPassword verifies the password of an existing user. Wrong passwords
+// count towards the same lockout as failed sign-ins.
+func (s *Service) CheckPassword(ctx context.Context, userID uuid.UUID, password string) (domain.Creds, error) {
+	c, err := s.repo.GetCredsByUserID(ctx, userID)
+	if err != nil {
+		return domain.Creds{}, fmt.Errorf("repo: %w", err)
+	}
+
+	// anonymized accounts have no password to compare with
+	if c.PasswordHash == "" {
+		return domain.Creds{}, domain.ErrInvalidPassrord
+	}
+
+	err = s.lockoutSvc.Check(ctx, userID)
+	if err != nil {
+		return domain.Creds{}, fmt.Errorf("lockout service: %w", err)
 	}
 
-	return creds.UserID, nil
+	err = s.hasher.Compare(password, c.PasswordHash)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidPassrord) {
+			lockErr := s.lockoutSvc.RegisterFailure(ctx, userID)
+			if errors.Is(lockErr, domain.ErrAccountLocked) {
+				return domain.Creds{}, fmt.Errorf("lockout service: %w", lockErr)
+			}
+			if lockErr != nil {
+				log.Printf("register password check failure of %s: %s", userID, lockErr)
+			}
+		}
+		return domain.Creds{}, fmt.Errorf("password compare: %w", err)
+	}
+
+	err = s.lockoutSvc.Reset(ctx, userID)
+	if err != nil {
+		log.Printf("reset sign-in failures of %s: %s", userID, err)
+	}
+
+	return c, nil
+}
+
+func (s *Service) UpdateEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	email, err := s.NormalizeEmail("email", email)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.UpdateEmail(ctx, userID, email)
+	if err != nil {
+		return fmt.Errorf("repo: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,258 @@
+package device
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/netip"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/repo/device"
+	"github.com/akemoon/crowdfunding-app-auth/repo/onetime"
+	"github.com/akemoon/crowdfunding-app-auth/service/audit"
+	"github.com/akemoon/crowdfunding-app-auth/service/creds"
+	"github.com/akemoon/crowdfunding-app-auth/service/passkey"
+	"github.com/akemoon/crowdfunding-app-auth/service/password"
+	"github.com/akemoon/crowdfunding-app-auth/service/token"
+	"github.com/akemoon/crowdfunding-app-auth/tool/mailer"
+	"github.com/akemoon/crowdfunding-app-auth/tool/randtoken"
+	"github.com/akemoon/crowdfunding-app-auth/tool/useragent"
+	"github.com/google/uuid"
+)
+
+const (
+	purposeSecureAccount = "secure_account"
+	defaultSecureTTL     = 7 * 24 * time.Hour
+
+	// Addresses in the same block usually belong to the same provider and
+	// place; comparing whole addresses would flag every DHCP renewal.
+	ipv4NetworkBits = 24
+	ipv6NetworkBits = 48
+)
+
+// securePayload remembers which sign-in the owner disowns, so that device
+// is no longer trusted.
+type securePayload struct {
+	UserID      uuid.UUID `json:"userID"`
+	Fingerprint string    `json:"fingerprint"`
+	Network     string    `json:"network"`
+}
+
+// Service remembers the devices and networks users sign in from and emails
+// the owner when one is new.
+type Service struct {
+	repo        device.Repo
+	credsSvc    *creds.Service
+	tokenSvc    *token.Service
+	passwordSvc *password.Service
+	passkeySvc  *passkey.Service
+	tokenRepo   onetime.Repo
+	mailer      mailer.Mailer
+	auditSvc    *audit.Service
+	cfg         config.DeviceNotice
+}
+
+func NewService(r device.Repo, cs *creds.Service, ts *token.Service, ps *password.Service, pks *passkey.Service, tr onetime.Repo, m mailer.Mailer, as *audit.Service, cfg config.DeviceNotice) *Service {
+	if cfg.SecureTokenTTL == 0 {
+		cfg.SecureTokenTTL = defaultSecureTTL
+	}
+
+	return &Service{
+		repo:        r,
+		credsSvc:    cs,
+		tokenSvc:    ts,
+		passwordSvc: ps,
+		passkeySvc:  pks,
+		tokenRepo:   tr,
+		mailer:      m,
+		auditSvc:    as,
+		cfg:         cfg,
+	}
+}
+
+// SignedIn records the device of the request in ctx as used by userID and
+// notifies the owner when the device or its network was not seen before.
+// The very first sign-in only records: there is nothing to compare with.
+func (s *Service) SignedIn(ctx context.Context, userID uuid.UUID) error {
+	meta := domain.RequestMetaFromContext(ctx)
+	if meta.DeviceID == "" && meta.UserAgent == "" {
+		return nil
+	}
+
+	description := useragent.Describe(meta.UserAgent)
+	fingerprint := randtoken.Hash(meta.DeviceID + "\n" + description)
+	network := networkOf(meta.IP)
+
+	known, err := s.repo.HasDevices(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("device repo: %w", err)
+	}
+
+	newDevice, err := s.repo.RememberDevice(ctx, domain.KnownDevice{
+		UserID:      userID,
+		Fingerprint: fingerprint,
+		Description: description,
+		IP:          meta.IP,
+	})
+	if err != nil {
+		return fmt.Errorf("device repo: %w", err)
+	}
+
+	var newNetwork bool
+	if network != "" {
+		newNetwork, err = s.repo.RememberNetwork(ctx, userID, network)
+		if err != nil {
+			return fmt.Errorf("device repo: %w", err)
+		}
+	}
+
+	if !known || !(newDevice || newNetwork && !s.cfg.IgnoreNewNetworks) {
+		return nil
+	}
+
+	s.auditSvc.Record(ctx, domain.AuthEvent{
+		Type:   domain.AuthEventNewDevice,
+		UserID: &userID,
+	})
+
+	return s.notify(ctx, userID, securePayload{
+		UserID:      userID,
+		Fingerprint: fingerprint,
+		Network:     network,
+	}, description, meta.IP)
+}
+
+func (s *Service) notify(ctx context.Context, userID uuid.UUID, payload securePayload, description, ip string) error {
+	c, err := s.credsSvc.GetCredsByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	secureToken, err := randtoken.New()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	value, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	err = s.tokenRepo.Set(ctx, purposeSecureAccount, randtoken.Hash(secureToken), string(value), s.cfg.SecureTokenTTL)
+	if err != nil {
+		return fmt.Errorf("token repo: %w", err)
+	}
+
+	if ip == "" {
+		ip = "unknown"
+	}
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      c.Email,
+		Subject: "New sign-in to your account",
+		Body: fmt.Sprintf("Your account was just signed in to from a new device or location:\n\n%s, IP address %s, at %s\n\nIf this was you, ignore this email. If not, secure your account now:\n\n%s%s\n\nThe link signs out every session and requires a new password. It expires in %s.",
+			description, ip, time.Now().UTC().Format(time.RFC1123), s.cfg.SecureURL, secureToken, s.cfg.SecureTokenTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: mailer: %s", domain.ErrInternal, err)
+	}
+
+	return nil
+}
+
+// SecureAccount acts on a "secure my account" link: every session is
+// revoked, sign-in is refused until the password is reset, and a reset link
+// is sent. Passkeys are not tied to the device they were registered from,
+// so all of them are removed in case the intruder added one.
+func (s *Service) SecureAccount(ctx context.Context, secureToken string) error {
+	if secureToken == "" {
+		return domain.ErrInvalidSecureToken
+	}
+
+	value, err := s.tokenRepo.Take(ctx, purposeSecureAccount, randtoken.Hash(secureToken))
+	if err != nil {
+		if errors.Is(err, domain.ErrOneTimeTokenNotFound) {
+			return domain.ErrInvalidSecureToken
+		}
+		return fmt.Errorf("token repo: %w", err)
+	}
+
+	var payload securePayload
+	err = json.Unmarshal([]byte(value), &payload)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	c, err := s.credsSvc.GetCredsByUserID(ctx, payload.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrCredsNotFound) {
+			return domain.ErrInvalidSecureToken
+		}
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	err = s.credsSvc.RequirePasswordReset(ctx, c.UserID)
+	if err != nil {
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	err = s.tokenSvc.RevokeAllRefreshTokens(ctx, c.UserID)
+	if err != nil {
+		return fmt.Errorf("token service: %w", err)
+	}
+
+	err = s.tokenSvc.RevokeAccessTokens(ctx, c.UserID)
+	if err != nil {
+		return fmt.Errorf("token service: %w", err)
+	}
+
+	err = s.passkeySvc.DeleteAll(ctx, c.UserID)
+	if err != nil {
+		return fmt.Errorf("passkey service: %w", err)
+	}
+
+	err = s.repo.Forget(ctx, c.UserID, payload.Fingerprint, payload.Network)
+	if err != nil {
+		return fmt.Errorf("device repo: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, domain.AuthEvent{
+		Type:   domain.AuthEventAccountSecured,
+		UserID: &c.UserID,
+	})
+
+	// the account is secured either way; the owner can still ask for
+	// another link through /password/forgot
+	err = s.passwordSvc.Forgot(ctx, c.Email)
+	if err != nil {
+		log.Printf("password reset link after securing %s: %s", c.UserID, err)
+	}
+
+	return nil
+}
+
+// networkOf returns the block ip belongs to, or "" if ip is not an
+// address.
+func networkOf(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+
+	bits := ipv6NetworkBits
+	if addr.Is4() {
+		bits = ipv4NetworkBits
+	}
+
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+
+	return prefix.String()
+}
@@ -0,0 +1,239 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/repo/onetime"
+	"github.com/akemoon/crowdfunding-app-auth/service/creds"
+	"github.com/akemoon/crowdfunding-app-auth/service/token"
+	"github.com/akemoon/crowdfunding-app-auth/tool/mailer"
+	"github.com/akemoon/crowdfunding-app-auth/tool/randtoken"
+	"github.com/google/uuid"
+)
+
+const (
+	purposeEmailChangeConfirm = "email_change_confirm"
+	purposeEmailChangeRevert  = "email_change_revert"
+
+	defaultConfirmTTL = 24 * time.Hour
+	defaultRevertTTL  = 7 * 24 * time.Hour
+)
+
+type changePayload struct {
+	UserID   uuid.UUID `json:"userID"`
+	OldEmail string    `json:"oldEmail"`
+	NewEmail string    `json:"newEmail"`
+}
+
+type Service struct {
+	credsSvc  *creds.Service
+	tokenSvc  *token.Service
+	tokenRepo onetime.Repo
+	mailer    mailer.Mailer
+	cfg       config.EmailChange
+}
+
+func NewService(cs *creds.Service, ts *token.Service, tr onetime.Repo, m mailer.Mailer, cfg config.EmailChange) *Service {
+	if cfg.ConfirmTTL == 0 {
+		cfg.ConfirmTTL = defaultConfirmTTL
+	}
+	if cfg.RevertTTL == 0 {
+		cfg.RevertTTL = defaultRevertTTL
+	}
+
+	return &Service{
+		credsSvc:  cs,
+		tokenSvc:  ts,
+		tokenRepo: tr,
+		mailer:    m,
+		cfg:       cfg,
+	}
+}
+
+// RequestChange sends a confirmation link to the new address. The email is
+// not changed until the link is followed.
+func (s *Service) RequestChange(ctx context.Context, tc domain.TokenClaims, req domain.ChangeEmailRequest) error {
+	if req.NewEmail == "" {
+		return domain.ErrInvalidRequest
+	}
+
+	c, err := s.credsSvc.CheckPassword(ctx, tc.UserID, req.Password)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidPassrord) {
+			return domain.ErrInvalidCurrentPassword
+		}
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	req.NewEmail, err = s.credsSvc.NormalizeEmail("newEmail", req.NewEmail)
+	if err != nil {
+		return err
+	}
+
+	if c.Email == req.NewEmail {
+		return domain.ErrInvalidRequest
+	}
+
+	_, err = s.credsSvc.GetCredsByEmail(ctx, req.NewEmail)
+	if err == nil {
+		return domain.ErrEmailExists
+	}
+	if !errors.Is(err, domain.ErrCredsNotFound) {
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	confirmToken, err := s.issue(ctx, purposeEmailChangeConfirm, changePayload{
+		UserID:   c.UserID,
+		OldEmail: c.Email,
+		NewEmail: req.NewEmail,
+	}, s.cfg.ConfirmTTL)
+	if err != nil {
+		return err
+	}
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      req.NewEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Follow the link to use this address for your account:\n\n%s%s\n\nThe link expires in %s.",
+			s.cfg.ConfirmURL, confirmToken, s.cfg.ConfirmTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: mailer: %s", domain.ErrInternal, err)
+	}
+
+	return nil
+}
+
+// Confirm swaps the email and notifies the old address with a link that
+// reverts the change.
+func (s *Service) Confirm(ctx context.Context, confirmToken string) error {
+	payload, err := s.take(ctx, purposeEmailChangeConfirm, confirmToken)
+	if err != nil {
+		return err
+	}
+
+	c, err := s.credsSvc.GetCredsByUserID(ctx, payload.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrCredsNotFound) {
+			return domain.ErrInvalidEmailChangeToken
+		}
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	if c.Email != payload.OldEmail {
+		return domain.ErrInvalidEmailChangeToken
+	}
+
+	err = s.credsSvc.UpdateEmail(ctx, payload.UserID, payload.NewEmail)
+	if err != nil {
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	revertToken, err := s.issue(ctx, purposeEmailChangeRevert, payload, s.cfg.RevertTTL)
+	if err != nil {
+		log.Printf("email change revert token: %s", err)
+		return nil
+	}
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      payload.OldEmail,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf("The email of your account was changed to %s.\n\nIf this wasn't you, follow the link to undo the change and sign out all sessions. You will then need to reset your password:\n\n%s%s",
+			payload.NewEmail, s.cfg.RevertURL, revertToken),
+	})
+	if err != nil {
+		log.Printf("email change notification: %s", err)
+	}
+
+	return nil
+}
+
+// Revert restores the previous email and, since whoever changed it may know
+// the password, revokes every session and refuses sign-in until the
+// password is reset, as securing the account from a new-device email does.
+func (s *Service) Revert(ctx context.Context, revertToken string) error {
+	payload, err := s.take(ctx, purposeEmailChangeRevert, revertToken)
+	if err != nil {
+		return err
+	}
+
+	c, err := s.credsSvc.GetCredsByUserID(ctx, payload.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrCredsNotFound) {
+			return domain.ErrInvalidEmailChangeToken
+		}
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	if c.Email != payload.OldEmail {
+		err = s.credsSvc.UpdateEmail(ctx, payload.UserID, payload.OldEmail)
+		if err != nil {
+			return fmt.Errorf("creds service: %w", err)
+		}
+	}
+
+	err = s.credsSvc.RequirePasswordReset(ctx, payload.UserID)
+	if err != nil {
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	err = s.tokenSvc.RevokeAllRefreshTokens(ctx, payload.UserID)
+	if err != nil {
+		return fmt.Errorf("token service: %w", err)
+	}
+
+	err = s.tokenSvc.RevokeAccessTokens(ctx, payload.UserID)
+	if err != nil {
+		return fmt.Errorf("token service: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) issue(ctx context.Context, purpose string, payload changePayload, ttl time.Duration) (string, error) {
+	t, err := randtoken.New()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	value, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	err = s.tokenRepo.Set(ctx, purpose, randtoken.Hash(t), string(value), ttl)
+	if err != nil {
+		return "", fmt.Errorf("token repo: %w", err)
+	}
+
+	return t, nil
+}
+
+func (s *Service) take(ctx context.Context, purpose, t string) (changePayload, error) {
+	if t == "" {
+		return changePayload{}, domain.ErrInvalidEmailChangeToken
+	}
+
+	value, err := s.tokenRepo.Take(ctx, purpose, randtoken.Hash(t))
+	if err != nil {
+		if errors.Is(err, domain.ErrOneTimeTokenNotFound) {
+			return changePayload{}, domain.ErrInvalidEmailChangeToken
+		}
+		return changePayload{}, fmt.Errorf("token repo: %w", err)
+	}
+
+	var payload changePayload
+	err = json.Unmarshal([]byte(value), &payload)
+	if err != nil {
+		return changePayload{}, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,132 @@
+package emailotp
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/repo/emailotp"
+	"github.com/akemoon/crowdfunding-app-auth/service/creds"
+	"github.com/akemoon/crowdfunding-app-auth/tool/mailer"
+	"github.com/akemoon/crowdfunding-app-auth/tool/randtoken"
+)
+
+const (
+	codeDigits = 6
+
+	defaultCodeTTL     = 10 * time.Minute
+	defaultMaxAttempts = 5
+)
+
+var codeSpace = big.NewInt(1_000_000)
+
+type Service struct {
+	repo     emailotp.Repo
+	credsSvc *creds.Service
+	mailer   mailer.Mailer
+	cfg      config.EmailOTP
+}
+
+func NewService(repo emailotp.Repo, cs *creds.Service, m mailer.Mailer, cfg config.EmailOTP) *Service {
+	if cfg.CodeTTL == 0 {
+		cfg.CodeTTL = defaultCodeTTL
+	}
+	if cfg.MaxAttempts == 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+
+	return &Service{
+		repo:     repo,
+		credsSvc: cs,
+		mailer:   m,
+		cfg:      cfg,
+	}
+}
+
+// Request emails a sign-in code. Unknown addresses are accepted silently,
+// and the mailer is expected to queue so they answer as fast, so the
+// endpoint cannot be used to probe emails.
+func (s *Service) Request(ctx context.Context, email string) error {
+	if email == "" {
+		return domain.ErrInvalidRequest
+	}
+
+	c, err := s.credsSvc.GetCredsByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrCredsNotFound) {
+			return nil
+		}
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	n, err := rand.Int(rand.Reader, codeSpace)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+	code := fmt.Sprintf("%0*d", codeDigits, n.Int64())
+
+	emailHash := randtoken.Hash(c.Email)
+
+	err = s.repo.Set(ctx, emailHash, hashCode(emailHash, code), s.cfg.CodeTTL)
+	if err != nil {
+		return fmt.Errorf("email otp repo: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      c.Email,
+		Subject: "Your sign-in code",
+		Body: fmt.Sprintf("Your sign-in code is %s\n\nIt expires in %s. If you did not try to sign in, ignore this email.",
+			code, s.cfg.CodeTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: mailer: %s", domain.ErrInternal, err)
+	}
+
+	return nil
+}
+
+// Verify checks a code and returns the credentials of its owner. After
+// MaxAttempts wrong guesses the code is invalidated and a new one must be
+// requested.
+func (s *Service) Verify(ctx context.Context, email, code string) (domain.Creds, error) {
+	code = strings.TrimSpace(code)
+	if email == "" || len(code) != codeDigits {
+		return domain.Creds{}, domain.ErrInvalidEmailOTP
+	}
+
+	c, err := s.credsSvc.GetCredsByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrCredsNotFound) {
+			return domain.Creds{}, domain.ErrInvalidEmailOTP
+		}
+		return domain.Creds{}, fmt.Errorf("creds service: %w", err)
+	}
+
+	emailHash := randtoken.Hash(c.Email)
+
+	ok, err := s.repo.Check(ctx, emailHash, hashCode(emailHash, code), s.cfg.MaxAttempts)
+	if err != nil {
+		if errors.Is(err, domain.ErrOneTimeTokenNotFound) {
+			return domain.Creds{}, domain.ErrInvalidEmailOTP
+		}
+		return domain.Creds{}, fmt.Errorf("email otp repo: %w", err)
+	}
+
+	if !ok {
+		return domain.Creds{}, domain.ErrInvalidEmailOTP
+	}
+
+	return c, nil
+}
+
+// hashCode binds the code to the address, so equal codes of different users
+// do not hash alike.
+func hashCode(emailHash, code string) string {
+	return randtoken.Hash(emailHash + ":" + code)
+}
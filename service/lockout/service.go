@@ -0,0 +1,110 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/repo/lockout"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultThreshold     = 5
+	defaultBaseDuration  = time.Minute
+	defaultMaxDuration   = 24 * time.Hour
+	defaultFailureWindow = 24 * time.Hour
+)
+
+type Service struct {
+	repo lockout.Repo
+	cfg  config.Lockout
+	// durations is the lock duration of each level in a row.
+	durations []time.Duration
+}
+
+func NewService(repo lockout.Repo, cfg config.Lockout) *Service {
+	if cfg.Threshold == 0 {
+		cfg.Threshold = defaultThreshold
+	}
+	if cfg.BaseDuration == 0 {
+		cfg.BaseDuration = defaultBaseDuration
+	}
+	if cfg.MaxDuration == 0 {
+		cfg.MaxDuration = defaultMaxDuration
+	}
+	if cfg.FailureWindow == 0 {
+		cfg.FailureWindow = defaultFailureWindow
+	}
+
+	return &Service{
+		repo:      repo,
+		cfg:       cfg,
+		durations: lockDurations(cfg),
+	}
+}
+
+// Check returns an *domain.AccountLockedError while the account is locked.
+func (s *Service) Check(ctx context.Context, userID uuid.UUID) error {
+	d, err := s.repo.LockedFor(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("lockout repo: %w", err)
+	}
+
+	if d > 0 {
+		return &domain.AccountLockedError{RetryAfter: d}
+	}
+
+	return nil
+}
+
+// RegisterFailure counts a failed sign-in and locks the account once the
+// threshold is reached. Every lock in a row doubles the lock duration.
+func (s *Service) RegisterFailure(ctx context.Context, userID uuid.UUID) error {
+	n, err := s.repo.IncrFailures(ctx, userID, s.cfg.FailureWindow)
+	if err != nil {
+		return fmt.Errorf("lockout repo: %w", err)
+	}
+
+	if n < s.cfg.Threshold {
+		return nil
+	}
+
+	d, err := s.repo.Lock(ctx, userID, s.cfg.MaxDuration+s.cfg.FailureWindow, s.durations)
+	if err != nil {
+		return fmt.Errorf("lockout repo: %w", err)
+	}
+
+	return &domain.AccountLockedError{RetryAfter: d}
+}
+
+// Reset forgets failures after a successful sign-in.
+func (s *Service) Reset(ctx context.Context, userID uuid.UUID) error {
+	err := s.repo.Reset(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("lockout repo: %w", err)
+	}
+
+	return nil
+}
+
+// Unlock lifts a lock immediately. It is an admin operation.
+func (s *Service) Unlock(ctx context.Context, userID uuid.UUID) error {
+	return s.Reset(ctx, userID)
+}
+
+// lockDurations doubles the lock duration from BaseDuration until it
+// reaches MaxDuration, which then applies to every further level.
+func lockDurations(cfg config.Lockout) []time.Duration {
+	d := min(cfg.BaseDuration, cfg.MaxDuration)
+	durations := []time.Duration{d}
+
+	for d < cfg.MaxDuration {
+		d = min(d*2, cfg.MaxDuration)
+		durations = append(durations, d)
+	}
+
+	return durations
+}
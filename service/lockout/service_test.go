@@ -0,0 +1,35 @@
+package lockout
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+)
+
+func TestLockDurations(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Lockout
+		want []time.Duration
+	}{
+		{"doubles up to max", config.Lockout{BaseDuration: time.Minute, MaxDuration: 8 * time.Minute},
+			[]time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute}},
+		{"last step capped", config.Lockout{BaseDuration: time.Minute, MaxDuration: 5 * time.Minute},
+			[]time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute}},
+		{"base equals max", config.Lockout{BaseDuration: time.Hour, MaxDuration: time.Hour},
+			[]time.Duration{time.Hour}},
+		{"base above max", config.Lockout{BaseDuration: 2 * time.Hour, MaxDuration: time.Hour},
+			[]time.Duration{time.Hour}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lockDurations(tt.cfg)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lockDurations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package magiclink
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/repo/onetime"
+	"github.com/akemoon/crowdfunding-app-auth/service/creds"
+	"github.com/akemoon/crowdfunding-app-auth/tool/mailer"
+	"github.com/akemoon/crowdfunding-app-auth/tool/randtoken"
+	"github.com/google/uuid"
+)
+
+const (
+	purposeMagicLink = "magic_link"
+	defaultTokenTTL  = 15 * time.Minute
+)
+
+type linkPayload struct {
+	UserID    uuid.UUID `json:"userID"`
+	Email     string    `json:"email"`
+	NonceHash string    `json:"nonceHash"`
+}
+
+type Service struct {
+	credsSvc  *creds.Service
+	tokenRepo onetime.Repo
+	mailer    mailer.Mailer
+	cfg       config.MagicLink
+}
+
+func NewService(cs *creds.Service, tr onetime.Repo, m mailer.Mailer, cfg config.MagicLink) *Service {
+	if cfg.TokenTTL == 0 {
+		cfg.TokenTTL = defaultTokenTTL
+	}
+
+	return &Service{
+		credsSvc:  cs,
+		tokenRepo: tr,
+		mailer:    m,
+		cfg:       cfg,
+	}
+}
+
+func (s *Service) TokenTTL() time.Duration {
+	return s.cfg.TokenTTL
+}
+
+// Request emails a sign-in link bound to the returned nonce. A nonce is
+// returned for unknown addresses too, and the mailer is expected to queue,
+// so neither the response nor its timing reveals whether an account exists.
+func (s *Service) Request(ctx context.Context, email string) (string, error) {
+	if email == "" {
+		return "", domain.ErrInvalidRequest
+	}
+
+	nonce, err := randtoken.New()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	c, err := s.credsSvc.GetCredsByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrCredsNotFound) {
+			return nonce, nil
+		}
+		return "", fmt.Errorf("creds service: %w", err)
+	}
+
+	token, err := randtoken.New()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	payload, err := json.Marshal(linkPayload{
+		UserID:    c.UserID,
+		Email:     c.Email,
+		NonceHash: randtoken.Hash(nonce),
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	err = s.tokenRepo.Set(ctx, purposeMagicLink, randtoken.Hash(token), string(payload), s.cfg.TokenTTL)
+	if err != nil {
+		return "", fmt.Errorf("token repo: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      c.Email,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf("Follow the link to sign in:\n\n%s%s\n\nThe link works once, only in the browser you requested it from, and expires in %s. If you did not try to sign in, ignore this email.",
+			s.cfg.LinkURL, token, s.cfg.TokenTTL),
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: mailer: %s", domain.ErrInternal, err)
+	}
+
+	return nonce, nil
+}
+
+// Verify consumes a link opened with the nonce it was issued for and
+// returns the credentials of its owner. A wrong nonce leaves the link
+// usable by the browser that requested it.
+func (s *Service) Verify(ctx context.Context, token, nonce string) (domain.Creds, error) {
+	if token == "" || nonce == "" {
+		return domain.Creds{}, domain.ErrInvalidMagicLink
+	}
+
+	tokenHash := randtoken.Hash(token)
+
+	value, err := s.tokenRepo.Get(ctx, purposeMagicLink, tokenHash)
+	if err != nil {
+		if errors.Is(err, domain.ErrOneTimeTokenNotFound) {
+			return domain.Creds{}, domain.ErrInvalidMagicLink
+		}
+		return domain.Creds{}, fmt.Errorf("token repo: %w", err)
+	}
+
+	var payload linkPayload
+	err = json.Unmarshal([]byte(value), &payload)
+	if err != nil {
+		return domain.Creds{}, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(payload.NonceHash), []byte(randtoken.Hash(nonce))) != 1 {
+		return domain.Creds{}, domain.ErrInvalidMagicLink
+	}
+
+	_, err = s.tokenRepo.Take(ctx, purposeMagicLink, tokenHash)
+	if err != nil {
+		if errors.Is(err, domain.ErrOneTimeTokenNotFound) {
+			return domain.Creds{}, domain.ErrInvalidMagicLink
+		}
+		return domain.Creds{}, fmt.Errorf("token repo: %w", err)
+	}
+
+	c, err := s.credsSvc.GetCredsByUserID(ctx, payload.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrCredsNotFound) {
+			return domain.Creds{}, domain.ErrInvalidMagicLink
+		}
+		return domain.Creds{}, fmt.Errorf("creds service: %w", err)
+	}
+
+	// The link proves control of the address it was sent to, not of a
+	// newer one.
+	if c.Email != payload.Email {
+		return domain.Creds{}, domain.ErrInvalidMagicLink
+	}
+
+	return c, nil
+}
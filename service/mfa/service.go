@@ -0,0 +1,382 @@
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/repo/mfa"
+	"github.com/akemoon/crowdfunding-app-auth/repo/onetime"
+	"github.com/akemoon/crowdfunding-app-auth/service/creds"
+	"github.com/akemoon/crowdfunding-app-auth/service/lockout"
+	"github.com/akemoon/crowdfunding-app-auth/service/passkey"
+	"github.com/akemoon/crowdfunding-app-auth/tool/randtoken"
+	"github.com/akemoon/crowdfunding-app-auth/tool/secretbox"
+	"github.com/akemoon/crowdfunding-app-auth/tool/totp"
+	"github.com/google/uuid"
+)
+
+const (
+	purposeMFAChallenge = "mfa_challenge"
+
+	defaultIssuer        = "Crowdfunding"
+	defaultChallengeTTL  = 5 * time.Minute
+	defaultRecoveryCodes = 10
+
+	// totpSkew is the number of time steps accepted on either side of the
+	// current one to tolerate clock drift.
+	totpSkew = 1
+
+	recoveryCodeBytes = 10
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type challengePayload struct {
+	UserID     uuid.UUID `json:"userID"`
+	SessionID  string    `json:"sessionID"`
+	Restricted bool      `json:"restricted"`
+	Scopes     []string  `json:"scopes"`
+}
+
+type Service struct {
+	repo       mfa.Repo
+	credsSvc   *creds.Service
+	tokenRepo  onetime.Repo
+	lockoutSvc *lockout.Service
+	passkeySvc *passkey.Service
+	box        *secretbox.Box
+	cfg        config.MFA
+}
+
+func NewService(repo mfa.Repo, cs *creds.Service, tr onetime.Repo, ls *lockout.Service, ps *passkey.Service, cfg config.MFA) (*Service, error) {
+	if cfg.Issuer == "" {
+		cfg.Issuer = defaultIssuer
+	}
+	if cfg.ChallengeTTL == 0 {
+		cfg.ChallengeTTL = defaultChallengeTTL
+	}
+	if cfg.RecoveryCodes == 0 {
+		cfg.RecoveryCodes = defaultRecoveryCodes
+	}
+
+	var box *secretbox.Box
+	if len(cfg.EncryptionKey) > 0 {
+		var err error
+		box, err = secretbox.New(cfg.EncryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("mfa encryption key: %w", err)
+		}
+	}
+
+	return &Service{
+		repo:       repo,
+		credsSvc:   cs,
+		tokenRepo:  tr,
+		lockoutSvc: ls,
+		passkeySvc: ps,
+		box:        box,
+		cfg:        cfg,
+	}, nil
+}
+
+// EnrollTOTP generates a new pending secret. It replaces a previous pending
+// secret but never a confirmed one.
+func (s *Service) EnrollTOTP(ctx context.Context, userID uuid.UUID) (domain.TOTPEnrollResponse, error) {
+	if s.box == nil {
+		return domain.TOTPEnrollResponse{}, domain.ErrMFAUnavailable
+	}
+
+	c, err := s.credsSvc.GetCredsByUserID(ctx, userID)
+	if err != nil {
+		return domain.TOTPEnrollResponse{}, fmt.Errorf("creds service: %w", err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return domain.TOTPEnrollResponse{}, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	secretEnc, err := s.box.Seal([]byte(secret), userID[:])
+	if err != nil {
+		return domain.TOTPEnrollResponse{}, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	err = s.repo.UpsertTOTP(ctx, userID, secretEnc)
+	if err != nil {
+		return domain.TOTPEnrollResponse{}, fmt.Errorf("mfa repo: %w", err)
+	}
+
+	return domain.TOTPEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(s.cfg.Issuer, c.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables 2FA once the user proves the authenticator works and
+// returns the recovery codes. They are shown only this once.
+func (s *Service) ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) (domain.RecoveryCodesResponse, error) {
+	t, err := s.repo.GetTOTP(ctx, userID)
+	if err != nil {
+		return domain.RecoveryCodesResponse{}, fmt.Errorf("mfa repo: %w", err)
+	}
+
+	if t.Enabled() {
+		return domain.RecoveryCodesResponse{}, domain.ErrMFAAlreadyEnabled
+	}
+
+	step, err := s.validateCode(t, code)
+	if err != nil {
+		return domain.RecoveryCodesResponse{}, err
+	}
+
+	codes, hashes, err := newRecoveryCodes(s.cfg.RecoveryCodes)
+	if err != nil {
+		return domain.RecoveryCodesResponse{}, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	err = s.repo.ReplaceRecoveryCodes(ctx, userID, hashes)
+	if err != nil {
+		return domain.RecoveryCodesResponse{}, fmt.Errorf("mfa repo: %w", err)
+	}
+
+	err = s.repo.ConfirmTOTP(ctx, userID, step)
+	if err != nil {
+		return domain.RecoveryCodesResponse{}, fmt.Errorf("mfa repo: %w", err)
+	}
+
+	return domain.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Methods returns the second factors the user can sign in with. A
+// registered passkey counts as a second factor on its own.
+func (s *Service) Methods(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	var methods []string
+
+	t, err := s.repo.GetTOTP(ctx, userID)
+	if err != nil && !errors.Is(err, domain.ErrMFANotEnrolled) {
+		return nil, fmt.Errorf("mfa repo: %w", err)
+	}
+	if err == nil && t.Enabled() {
+		methods = append(methods, domain.MFAMethodTOTP)
+	}
+
+	hasPasskeys, err := s.passkeySvc.HasPasskeys(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("passkey service: %w", err)
+	}
+	if hasPasskeys {
+		methods = append(methods, domain.MFAMethodPasskey)
+	}
+
+	return methods, nil
+}
+
+// CreateChallenge parks the claims of a sign-in that still needs a second
+// factor and returns the token that completes it.
+func (s *Service) CreateChallenge(ctx context.Context, tc domain.TokenClaims) (string, error) {
+	token, err := randtoken.New()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	payload, err := json.Marshal(challengePayload{
+		UserID:     tc.UserID,
+		SessionID:  tc.SessionID,
+		Restricted: tc.Restricted,
+		Scopes:     tc.Scopes,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	err = s.tokenRepo.Set(ctx, purposeMFAChallenge, randtoken.Hash(token), string(payload), s.cfg.ChallengeTTL)
+	if err != nil {
+		return "", fmt.Errorf("token repo: %w", err)
+	}
+
+	return token, nil
+}
+
+// PasskeyOptions returns the assertion options for completing the
+// challenge with a passkey.
+func (s *Service) PasskeyOptions(ctx context.Context, mfaToken string) (domain.PasskeyRequestOptions, error) {
+	payload, err := s.challenge(ctx, mfaToken)
+	if err != nil {
+		return domain.PasskeyRequestOptions{}, err
+	}
+
+	opts, err := s.passkeySvc.BeginMFA(ctx, payload.UserID)
+	if err != nil {
+		return domain.PasskeyRequestOptions{}, fmt.Errorf("passkey service: %w", err)
+	}
+
+	return opts, nil
+}
+
+// VerifyChallenge checks a TOTP code, recovery code or passkey assertion
+// against the challenge and returns the parked claims. Wrong codes count
+// towards the account lockout; the challenge stays valid until it expires or
+// succeeds.
+func (s *Service) VerifyChallenge(ctx context.Context, req domain.SignInMFARequest) (domain.TokenClaims, error) {
+	if req.MFAToken == "" || (req.Code == "" && req.RecoveryCode == "" && req.Passkey == nil) {
+		return domain.TokenClaims{}, domain.ErrInvalidRequest
+	}
+
+	payload, err := s.challenge(ctx, req.MFAToken)
+	if err != nil {
+		return domain.TokenClaims{}, err
+	}
+
+	err = s.lockoutSvc.Check(ctx, payload.UserID)
+	if err != nil {
+		return domain.TokenClaims{}, fmt.Errorf("lockout service: %w", err)
+	}
+
+	switch {
+	case req.Passkey != nil:
+		err = s.passkeySvc.FinishMFA(ctx, payload.UserID, *req.Passkey)
+	case req.RecoveryCode != "":
+		err = s.repo.UseRecoveryCode(ctx, payload.UserID, hashRecoveryCode(req.RecoveryCode))
+	default:
+		err = s.useTOTP(ctx, payload.UserID, req.Code)
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidMFACode) || errors.Is(err, domain.ErrInvalidPasskey) {
+			lockErr := s.lockoutSvc.RegisterFailure(ctx, payload.UserID)
+			if errors.Is(lockErr, domain.ErrAccountLocked) {
+				return domain.TokenClaims{}, fmt.Errorf("lockout service: %w", lockErr)
+			}
+			if lockErr != nil {
+				log.Printf("register mfa failure of %s: %s", payload.UserID, lockErr)
+			}
+		}
+		return domain.TokenClaims{}, err
+	}
+
+	_, err = s.tokenRepo.Take(ctx, purposeMFAChallenge, randtoken.Hash(req.MFAToken))
+	if err != nil {
+		if errors.Is(err, domain.ErrOneTimeTokenNotFound) {
+			return domain.TokenClaims{}, domain.ErrInvalidMFAToken
+		}
+		return domain.TokenClaims{}, fmt.Errorf("token repo: %w", err)
+	}
+
+	err = s.lockoutSvc.Reset(ctx, payload.UserID)
+	if err != nil {
+		log.Printf("reset sign-in failures of %s: %s", payload.UserID, err)
+	}
+
+	return domain.TokenClaims{
+		UserID:     payload.UserID,
+		SessionID:  payload.SessionID,
+		Restricted: payload.Restricted,
+		Scopes:     payload.Scopes,
+	}, nil
+}
+
+func (s *Service) challenge(ctx context.Context, mfaToken string) (challengePayload, error) {
+	if mfaToken == "" {
+		return challengePayload{}, domain.ErrInvalidMFAToken
+	}
+
+	value, err := s.tokenRepo.Get(ctx, purposeMFAChallenge, randtoken.Hash(mfaToken))
+	if err != nil {
+		if errors.Is(err, domain.ErrOneTimeTokenNotFound) {
+			return challengePayload{}, domain.ErrInvalidMFAToken
+		}
+		return challengePayload{}, fmt.Errorf("token repo: %w", err)
+	}
+
+	var payload challengePayload
+	err = json.Unmarshal([]byte(value), &payload)
+	if err != nil {
+		return challengePayload{}, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return payload, nil
+}
+
+// useTOTP accepts a code only if its time step is later than the last one
+// used, so an intercepted code cannot be replayed.
+func (s *Service) useTOTP(ctx context.Context, userID uuid.UUID, code string) error {
+	t, err := s.repo.GetTOTP(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("mfa repo: %w", err)
+	}
+
+	if !t.Enabled() {
+		return domain.ErrMFANotEnrolled
+	}
+
+	step, err := s.validateCode(t, code)
+	if err != nil {
+		return err
+	}
+
+	if step <= t.LastUsedStep {
+		return domain.ErrInvalidMFACode
+	}
+
+	err = s.repo.UseTOTPStep(ctx, userID, step)
+	if err != nil {
+		return fmt.Errorf("mfa repo: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) validateCode(t domain.TOTP, code string) (int64, error) {
+	if s.box == nil {
+		return 0, domain.ErrMFAUnavailable
+	}
+
+	secret, err := s.box.Open(t.SecretEnc, t.UserID[:])
+	if err != nil {
+		return 0, fmt.Errorf("%w: decrypt totp secret: %s", domain.ErrInternal, err)
+	}
+
+	step, ok := totp.Validate(string(secret), code, time.Now(), totpSkew)
+	if !ok {
+		return 0, domain.ErrInvalidMFACode
+	}
+
+	return step, nil
+}
+
+func newRecoveryCodes(n int) ([]string, []string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+
+	for range n {
+		b := make([]byte, recoveryCodeBytes)
+		_, err := rand.Read(b)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(recoveryEncoding.EncodeToString(b))
+		code = code[:8] + "-" + code[8:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes, which users tend to get
+// wrong when typing codes from paper.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return randtoken.Hash(code)
+}
@@ -0,0 +1,430 @@
+package passkey
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/repo/onetime"
+	"github.com/akemoon/crowdfunding-app-auth/repo/passkey"
+	"github.com/akemoon/crowdfunding-app-auth/service/creds"
+	"github.com/akemoon/crowdfunding-app-auth/tool/randtoken"
+	"github.com/akemoon/crowdfunding-app-auth/tool/webauthn"
+	"github.com/google/uuid"
+)
+
+const (
+	purposeRegistration = "passkey_registration"
+	purposeLogin        = "passkey_login"
+	purposeMFA          = "passkey_mfa"
+
+	defaultChallengeTTL = 5 * time.Minute
+	maxNameLength       = 64
+	defaultName         = "Passkey"
+
+	credentialType = "public-key"
+)
+
+// challengePayload is stored under the challenge. UserID is nil for
+// passwordless sign-in, where the user is only known from the credential.
+type challengePayload struct {
+	UserID uuid.UUID `json:"userID"`
+}
+
+type Service struct {
+	repo      passkey.Repo
+	credsSvc  *creds.Service
+	tokenRepo onetime.Repo
+	rp        webauthn.RelyingParty
+	cfg       config.WebAuthn
+}
+
+func NewService(repo passkey.Repo, cs *creds.Service, tr onetime.Repo, cfg config.WebAuthn) *Service {
+	if cfg.RPName == "" {
+		cfg.RPName = cfg.RPID
+	}
+	if cfg.ChallengeTTL == 0 {
+		cfg.ChallengeTTL = defaultChallengeTTL
+	}
+
+	return &Service{
+		repo:      repo,
+		credsSvc:  cs,
+		tokenRepo: tr,
+		rp: webauthn.RelyingParty{
+			ID:      cfg.RPID,
+			Origins: cfg.Origins,
+		},
+		cfg: cfg,
+	}
+}
+
+func (s *Service) available() bool {
+	return s.cfg.RPID != "" && len(s.cfg.Origins) > 0
+}
+
+// BeginRegistration returns the options for navigator.credentials.create().
+func (s *Service) BeginRegistration(ctx context.Context, userID uuid.UUID) (domain.PasskeyCreationOptions, error) {
+	if !s.available() {
+		return domain.PasskeyCreationOptions{}, domain.ErrPasskeysUnavailable
+	}
+
+	c, err := s.credsSvc.GetCredsByUserID(ctx, userID)
+	if err != nil {
+		return domain.PasskeyCreationOptions{}, fmt.Errorf("creds service: %w", err)
+	}
+
+	existing, err := s.repo.ListPasskeys(ctx, userID)
+	if err != nil {
+		return domain.PasskeyCreationOptions{}, fmt.Errorf("passkey repo: %w", err)
+	}
+
+	challenge, err := s.newChallenge(ctx, purposeRegistration, userID)
+	if err != nil {
+		return domain.PasskeyCreationOptions{}, err
+	}
+
+	params := make([]domain.PasskeyCredParam, 0, len(webauthn.SupportedAlgs))
+	for _, alg := range webauthn.SupportedAlgs {
+		params = append(params, domain.PasskeyCredParam{Type: credentialType, Alg: alg})
+	}
+
+	return domain.PasskeyCreationOptions{
+		Challenge: challenge,
+		RP: domain.PasskeyRelyingParty{
+			ID:   s.cfg.RPID,
+			Name: s.cfg.RPName,
+		},
+		User: domain.PasskeyUser{
+			ID:          base64.RawURLEncoding.EncodeToString(userID[:]),
+			Name:        c.Email,
+			DisplayName: c.Email,
+		},
+		PubKeyCredParams:   params,
+		Timeout:            s.cfg.ChallengeTTL.Milliseconds(),
+		ExcludeCredentials: descriptors(existing),
+		AuthenticatorSelection: domain.PasskeyAuthenticatorSelection{
+			ResidentKey:      "required",
+			UserVerification: "required",
+		},
+		Attestation: "none",
+	}, nil
+}
+
+// FinishRegistration verifies the response of navigator.credentials.create()
+// and stores the new passkey.
+func (s *Service) FinishRegistration(ctx context.Context, userID uuid.UUID, req domain.PasskeyRegisterRequest) (domain.Passkey, error) {
+	if !s.available() {
+		return domain.Passkey{}, domain.ErrPasskeysUnavailable
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = defaultName
+	}
+	if utf8.RuneCountInString(name) > maxNameLength {
+		return domain.Passkey{}, domain.ErrInvalidRequest
+	}
+
+	if req.Credential.Type != credentialType {
+		return domain.Passkey{}, domain.ErrInvalidPasskey
+	}
+
+	clientDataJSON, err := webauthn.DecodeBase64(req.Credential.Response.ClientDataJSON)
+	if err != nil {
+		return domain.Passkey{}, fmt.Errorf("%w: client data: %s", domain.ErrInvalidPasskey, err)
+	}
+
+	attestationObject, err := webauthn.DecodeBase64(req.Credential.Response.AttestationObject)
+	if err != nil {
+		return domain.Passkey{}, fmt.Errorf("%w: attestation object: %s", domain.ErrInvalidPasskey, err)
+	}
+
+	cd, err := s.rp.ParseClientData(clientDataJSON, webauthn.TypeCreate)
+	if err != nil {
+		return domain.Passkey{}, fmt.Errorf("%w: %s", domain.ErrInvalidPasskey, err)
+	}
+
+	payload, err := s.takeChallenge(ctx, purposeRegistration, cd.Challenge)
+	if err != nil {
+		return domain.Passkey{}, err
+	}
+
+	if payload.UserID != userID {
+		return domain.Passkey{}, domain.ErrInvalidPasskey
+	}
+
+	reg, err := s.rp.VerifyRegistration(attestationObject)
+	if err != nil {
+		return domain.Passkey{}, fmt.Errorf("%w: %s", domain.ErrInvalidPasskey, err)
+	}
+
+	if !reg.UserVerified {
+		return domain.Passkey{}, fmt.Errorf("%w: user not verified", domain.ErrInvalidPasskey)
+	}
+
+	p, err := s.repo.CreatePasskey(ctx, domain.Passkey{
+		UserID:    userID,
+		PublicKey: reg.PublicKey,
+		SignCount: reg.SignCount,
+		Name:      name,
+	}, reg.CredentialID)
+	if err != nil {
+		return domain.Passkey{}, fmt.Errorf("passkey repo: %w", err)
+	}
+
+	return p, nil
+}
+
+func (s *Service) List(ctx context.Context, userID uuid.UUID) ([]domain.Passkey, error) {
+	passkeys, err := s.repo.ListPasskeys(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("passkey repo: %w", err)
+	}
+
+	return passkeys, nil
+}
+
+// Delete removes a passkey of the user. id is the base64url credential id.
+func (s *Service) Delete(ctx context.Context, userID uuid.UUID, id string) error {
+	credentialID, err := webauthn.DecodeBase64(id)
+	if err != nil || len(credentialID) == 0 {
+		return domain.ErrPasskeyNotFound
+	}
+
+	err = s.repo.DeletePasskey(ctx, userID, credentialID)
+	if err != nil {
+		return fmt.Errorf("passkey repo: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAll removes every passkey of the user.
+func (s *Service) DeleteAll(ctx context.Context, userID uuid.UUID) error {
+	err := s.repo.DeleteAllPasskeys(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("passkey repo: %w", err)
+	}
+
+	return nil
+}
+
+// HasPasskeys reports whether a passkey can be used as a second factor.
+func (s *Service) HasPasskeys(ctx context.Context, userID uuid.UUID) (bool, error) {
+	if !s.available() {
+		return false, nil
+	}
+
+	passkeys, err := s.repo.ListPasskeys(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("passkey repo: %w", err)
+	}
+
+	return len(passkeys) > 0, nil
+}
+
+// BeginLogin returns options for a passwordless sign-in. No credentials are
+// listed: the authenticator offers its discoverable passkeys.
+func (s *Service) BeginLogin(ctx context.Context) (domain.PasskeyRequestOptions, error) {
+	if !s.available() {
+		return domain.PasskeyRequestOptions{}, domain.ErrPasskeysUnavailable
+	}
+
+	challenge, err := s.newChallenge(ctx, purposeLogin, uuid.Nil)
+	if err != nil {
+		return domain.PasskeyRequestOptions{}, err
+	}
+
+	return domain.PasskeyRequestOptions{
+		Challenge:        challenge,
+		Timeout:          s.cfg.ChallengeTTL.Milliseconds(),
+		RPID:             s.cfg.RPID,
+		UserVerification: "required",
+	}, nil
+}
+
+// FinishLogin verifies a passwordless assertion and returns its user. User
+// verification is required since the passkey replaces the password.
+func (s *Service) FinishLogin(ctx context.Context, a domain.PasskeyAssertion) (uuid.UUID, error) {
+	if !s.available() {
+		return uuid.Nil, domain.ErrPasskeysUnavailable
+	}
+
+	return s.verifyAssertion(ctx, purposeLogin, a, true)
+}
+
+// BeginMFA returns options for using a passkey of userID as a second factor.
+func (s *Service) BeginMFA(ctx context.Context, userID uuid.UUID) (domain.PasskeyRequestOptions, error) {
+	if !s.available() {
+		return domain.PasskeyRequestOptions{}, domain.ErrPasskeysUnavailable
+	}
+
+	passkeys, err := s.repo.ListPasskeys(ctx, userID)
+	if err != nil {
+		return domain.PasskeyRequestOptions{}, fmt.Errorf("passkey repo: %w", err)
+	}
+
+	if len(passkeys) == 0 {
+		return domain.PasskeyRequestOptions{}, domain.ErrPasskeyNotFound
+	}
+
+	challenge, err := s.newChallenge(ctx, purposeMFA, userID)
+	if err != nil {
+		return domain.PasskeyRequestOptions{}, err
+	}
+
+	return domain.PasskeyRequestOptions{
+		Challenge:        challenge,
+		Timeout:          s.cfg.ChallengeTTL.Milliseconds(),
+		RPID:             s.cfg.RPID,
+		AllowCredentials: descriptors(passkeys),
+		UserVerification: "preferred",
+	}, nil
+}
+
+// FinishMFA verifies an assertion made with a passkey of userID.
+func (s *Service) FinishMFA(ctx context.Context, userID uuid.UUID, a domain.PasskeyAssertion) error {
+	if !s.available() {
+		return domain.ErrPasskeysUnavailable
+	}
+
+	owner, err := s.verifyAssertion(ctx, purposeMFA, a, false)
+	if err != nil {
+		return err
+	}
+
+	if owner != userID {
+		return domain.ErrInvalidPasskey
+	}
+
+	return nil
+}
+
+func (s *Service) verifyAssertion(ctx context.Context, purpose string, a domain.PasskeyAssertion, requireUV bool) (uuid.UUID, error) {
+	if a.Type != credentialType {
+		return uuid.Nil, domain.ErrInvalidPasskey
+	}
+
+	clientDataJSON, err := webauthn.DecodeBase64(a.Response.ClientDataJSON)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: client data: %s", domain.ErrInvalidPasskey, err)
+	}
+
+	authData, err := webauthn.DecodeBase64(a.Response.AuthenticatorData)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: authenticator data: %s", domain.ErrInvalidPasskey, err)
+	}
+
+	sig, err := webauthn.DecodeBase64(a.Response.Signature)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: signature: %s", domain.ErrInvalidPasskey, err)
+	}
+
+	credentialID, err := webauthn.DecodeBase64(a.ID)
+	if err != nil || len(credentialID) == 0 {
+		return uuid.Nil, domain.ErrInvalidPasskey
+	}
+
+	cd, err := s.rp.ParseClientData(clientDataJSON, webauthn.TypeGet)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %s", domain.ErrInvalidPasskey, err)
+	}
+
+	payload, err := s.takeChallenge(ctx, purpose, cd.Challenge)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	p, err := s.repo.GetPasskey(ctx, credentialID)
+	if err != nil {
+		if errors.Is(err, domain.ErrPasskeyNotFound) {
+			return uuid.Nil, domain.ErrInvalidPasskey
+		}
+		return uuid.Nil, fmt.Errorf("passkey repo: %w", err)
+	}
+
+	if payload.UserID != uuid.Nil && payload.UserID != p.UserID {
+		return uuid.Nil, domain.ErrInvalidPasskey
+	}
+
+	if a.Response.UserHandle != "" {
+		userHandle, err := webauthn.DecodeBase64(a.Response.UserHandle)
+		if err != nil || !bytes.Equal(userHandle, p.UserID[:]) {
+			return uuid.Nil, domain.ErrInvalidPasskey
+		}
+	}
+
+	ad, err := s.rp.VerifyAssertion(p.PublicKey, authData, clientDataJSON, sig)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %s", domain.ErrInvalidPasskey, err)
+	}
+
+	if requireUV && !ad.UserVerified() {
+		return uuid.Nil, fmt.Errorf("%w: user not verified", domain.ErrInvalidPasskey)
+	}
+
+	err = s.repo.UsePasskey(ctx, credentialID, ad.SignCount)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("passkey repo: %w", err)
+	}
+
+	return p.UserID, nil
+}
+
+// newChallenge stores a random challenge. The challenge is looked up again
+// by the value the browser echoes in clientDataJSON.
+func (s *Service) newChallenge(ctx context.Context, purpose string, userID uuid.UUID) (string, error) {
+	challenge, err := randtoken.New()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	payload, err := json.Marshal(challengePayload{UserID: userID})
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	err = s.tokenRepo.Set(ctx, purpose, randtoken.Hash(challenge), string(payload), s.cfg.ChallengeTTL)
+	if err != nil {
+		return "", fmt.Errorf("token repo: %w", err)
+	}
+
+	return challenge, nil
+}
+
+// takeChallenge consumes a challenge, so every ceremony can be completed at
+// most once whatever its outcome.
+func (s *Service) takeChallenge(ctx context.Context, purpose, challenge string) (challengePayload, error) {
+	value, err := s.tokenRepo.Take(ctx, purpose, randtoken.Hash(challenge))
+	if err != nil {
+		if errors.Is(err, domain.ErrOneTimeTokenNotFound) {
+			return challengePayload{}, fmt.Errorf("%w: unknown or expired challenge", domain.ErrInvalidPasskey)
+		}
+		return challengePayload{}, fmt.Errorf("token repo: %w", err)
+	}
+
+	var payload challengePayload
+	err = json.Unmarshal([]byte(value), &payload)
+	if err != nil {
+		return challengePayload{}, fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	return payload, nil
+}
+
+func descriptors(passkeys []domain.Passkey) []domain.PasskeyDescriptor {
+	ds := make([]domain.PasskeyDescriptor, 0, len(passkeys))
+	for _, p := range passkeys {
+		ds = append(ds, domain.PasskeyDescriptor{Type: credentialType, ID: p.ID})
+	}
+	return ds
+}
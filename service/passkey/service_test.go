@@ -0,0 +1,467 @@
+package passkey
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/tool/webauthn"
+	"github.com/akemoon/crowdfunding-app-auth/tool/webauthn/webauthntest"
+	"github.com/google/uuid"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://example.com"
+
+	flagsUV       = webauthntest.FlagUserPresent | webauthntest.FlagUserVerified
+	flagsRegister = flagsUV | webauthntest.FlagAttested
+)
+
+// memPasskeyRepo follows the rules of the Postgres repo, including the
+// sign counter check of UsePasskey.
+type memPasskeyRepo struct {
+	mu       sync.Mutex
+	passkeys map[string]domain.Passkey
+}
+
+func (r *memPasskeyRepo) CreatePasskey(ctx context.Context, p domain.Passkey, credentialID []byte) (domain.Passkey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.passkeys[string(credentialID)]; ok {
+		return domain.Passkey{}, domain.ErrPasskeyExists
+	}
+
+	p.ID = base64.RawURLEncoding.EncodeToString(credentialID)
+	p.CreatedAt = time.Now()
+	r.passkeys[string(credentialID)] = p
+
+	return p, nil
+}
+
+func (r *memPasskeyRepo) GetPasskey(ctx context.Context, credentialID []byte) (domain.Passkey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.passkeys[string(credentialID)]
+	if !ok {
+		return domain.Passkey{}, domain.ErrPasskeyNotFound
+	}
+
+	return p, nil
+}
+
+func (r *memPasskeyRepo) ListPasskeys(ctx context.Context, userID uuid.UUID) ([]domain.Passkey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var passkeys []domain.Passkey
+	for _, p := range r.passkeys {
+		if p.UserID == userID {
+			passkeys = append(passkeys, p)
+		}
+	}
+
+	return passkeys, nil
+}
+
+func (r *memPasskeyRepo) UsePasskey(ctx context.Context, credentialID []byte, signCount uint32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.passkeys[string(credentialID)]
+	if !ok || !(p.SignCount < signCount || p.SignCount == 0 && signCount == 0) {
+		return domain.ErrInvalidPasskey
+	}
+
+	p.SignCount = signCount
+	r.passkeys[string(credentialID)] = p
+
+	return nil
+}
+
+func (r *memPasskeyRepo) DeletePasskey(ctx context.Context, userID uuid.UUID, credentialID []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.passkeys[string(credentialID)]
+	if !ok || p.UserID != userID {
+		return domain.ErrPasskeyNotFound
+	}
+
+	delete(r.passkeys, string(credentialID))
+
+	return nil
+}
+
+func (r *memPasskeyRepo) DeleteAllPasskeys(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, p := range r.passkeys {
+		if p.UserID == userID {
+			delete(r.passkeys, id)
+		}
+	}
+
+	return nil
+}
+
+type memTokenRepo struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (r *memTokenRepo) Set(ctx context.Context, purpose, tokenHash, value string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.values[purpose+":"+tokenHash] = value
+
+	return nil
+}
+
+func (r *memTokenRepo) Get(ctx context.Context, purpose, tokenHash string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v, ok := r.values[purpose+":"+tokenHash]
+	if !ok {
+		return "", domain.ErrOneTimeTokenNotFound
+	}
+
+	return v, nil
+}
+
+func (r *memTokenRepo) Take(ctx context.Context, purpose, tokenHash string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v, ok := r.values[purpose+":"+tokenHash]
+	if !ok {
+		return "", domain.ErrOneTimeTokenNotFound
+	}
+	delete(r.values, purpose+":"+tokenHash)
+
+	return v, nil
+}
+
+func (r *memTokenRepo) Delete(ctx context.Context, purpose, tokenHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.values, purpose+":"+tokenHash)
+
+	return nil
+}
+
+func newTestService() (*Service, *memPasskeyRepo) {
+	repo := &memPasskeyRepo{passkeys: make(map[string]domain.Passkey)}
+	tokens := &memTokenRepo{values: make(map[string]string)}
+
+	// the creds service is only needed to begin a registration
+	return NewService(repo, nil, tokens, config.WebAuthn{
+		RPID:    testRPID,
+		Origins: []string{testOrigin},
+	}), repo
+}
+
+func newChallenge(t *testing.T, s *Service, purpose string, userID uuid.UUID) string {
+	t.Helper()
+
+	challenge, err := s.newChallenge(context.Background(), purpose, userID)
+	if err != nil {
+		t.Fatalf("newChallenge() error = %s", err)
+	}
+
+	return challenge
+}
+
+func registerRequest(clientData, attestationObject []byte) domain.PasskeyRegisterRequest {
+	var req domain.PasskeyRegisterRequest
+	req.Name = "Laptop"
+	req.Credential.Type = credentialType
+	req.Credential.Response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(clientData)
+	req.Credential.Response.AttestationObject = base64.RawURLEncoding.EncodeToString(attestationObject)
+	return req
+}
+
+func assertion(t *testing.T, a *webauthntest.Authenticator, clientData, authData []byte, userHandle []byte) domain.PasskeyAssertion {
+	t.Helper()
+
+	var as domain.PasskeyAssertion
+	as.ID = base64.RawURLEncoding.EncodeToString(a.CredentialID)
+	as.Type = credentialType
+	as.Response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(clientData)
+	as.Response.AuthenticatorData = base64.RawURLEncoding.EncodeToString(authData)
+	as.Response.Signature = base64.RawURLEncoding.EncodeToString(a.Sign(t, authData, clientData))
+	if userHandle != nil {
+		as.Response.UserHandle = base64.RawURLEncoding.EncodeToString(userHandle)
+	}
+	return as
+}
+
+func TestFinishRegistration(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name string
+		a    *webauthntest.Authenticator
+		// challengeUser is the user the challenge was issued to
+		challengeUser uuid.UUID
+		purpose       string
+		clientType    string
+		origin        string
+		rpID          string
+		flags         byte
+		credType      string
+		wantErr       bool
+	}{
+		{name: "es256", a: webauthntest.NewES256(t)},
+		{name: "ed25519", a: webauthntest.NewEd25519(t)},
+		{name: "challenge of other user", a: webauthntest.NewES256(t), challengeUser: uuid.New(), wantErr: true},
+		{name: "sign-in challenge", a: webauthntest.NewES256(t), purpose: purposeLogin, wantErr: true},
+		{name: "assertion client data", a: webauthntest.NewES256(t), clientType: webauthn.TypeGet, wantErr: true},
+		{name: "wrong origin", a: webauthntest.NewES256(t), origin: "https://evil.example", wantErr: true},
+		{name: "wrong rp id", a: webauthntest.NewES256(t), rpID: "evil.example", wantErr: true},
+		{name: "user not verified", a: webauthntest.NewES256(t), flags: webauthntest.FlagUserPresent | webauthntest.FlagAttested, wantErr: true},
+		{name: "user not present", a: webauthntest.NewES256(t), flags: webauthntest.FlagUserVerified | webauthntest.FlagAttested, wantErr: true},
+		{name: "wrong credential type", a: webauthntest.NewES256(t), credType: "password", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestService()
+
+			challengeUser := userID
+			if tt.challengeUser != uuid.Nil {
+				challengeUser = tt.challengeUser
+			}
+			purpose := purposeRegistration
+			if tt.purpose != "" {
+				purpose = tt.purpose
+			}
+			clientType := webauthn.TypeCreate
+			if tt.clientType != "" {
+				clientType = tt.clientType
+			}
+			origin := testOrigin
+			if tt.origin != "" {
+				origin = tt.origin
+			}
+			rpID := testRPID
+			if tt.rpID != "" {
+				rpID = tt.rpID
+			}
+			flags := byte(flagsRegister)
+			if tt.flags != 0 {
+				flags = tt.flags
+			}
+
+			challenge := newChallenge(t, s, purpose, challengeUser)
+			req := registerRequest(
+				webauthntest.ClientData(clientType, challenge, origin),
+				webauthntest.AttestationObject(tt.a.AuthData(rpID, flags, 0)),
+			)
+			if tt.credType != "" {
+				req.Credential.Type = tt.credType
+			}
+
+			p, err := s.FinishRegistration(context.Background(), userID, req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FinishRegistration() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, domain.ErrInvalidPasskey) {
+					t.Errorf("FinishRegistration() error = %v, want ErrInvalidPasskey", err)
+				}
+				if len(repo.passkeys) != 0 {
+					t.Errorf("stored %d passkeys, want none", len(repo.passkeys))
+				}
+				return
+			}
+
+			if p.UserID != userID || p.Name != "Laptop" {
+				t.Errorf("FinishRegistration() = %+v", p)
+			}
+
+			stored, err := repo.GetPasskey(context.Background(), tt.a.CredentialID)
+			if err != nil {
+				t.Fatalf("stored passkey: %s", err)
+			}
+			if string(stored.PublicKey) != string(tt.a.PublicKey) {
+				t.Errorf("stored public key = %x, want %x", stored.PublicKey, tt.a.PublicKey)
+			}
+		})
+	}
+}
+
+func TestFinishRegistrationChallengeSingleUse(t *testing.T) {
+	s, _ := newTestService()
+	userID := uuid.New()
+
+	challenge := newChallenge(t, s, purposeRegistration, userID)
+	clientData := webauthntest.ClientData(webauthn.TypeCreate, challenge, testOrigin)
+
+	_, err := s.FinishRegistration(context.Background(), userID, registerRequest(
+		clientData,
+		webauthntest.AttestationObject(webauthntest.NewES256(t).AuthData(testRPID, flagsRegister, 0)),
+	))
+	if err != nil {
+		t.Fatalf("first FinishRegistration() error = %s", err)
+	}
+
+	_, err = s.FinishRegistration(context.Background(), userID, registerRequest(
+		clientData,
+		webauthntest.AttestationObject(webauthntest.NewES256(t).AuthData(testRPID, flagsRegister, 0)),
+	))
+	if !errors.Is(err, domain.ErrInvalidPasskey) {
+		t.Fatalf("second FinishRegistration() error = %v, want ErrInvalidPasskey", err)
+	}
+}
+
+func TestFinishLogin(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		storedCount  uint32
+		count        uint32
+		flags        byte
+		purpose      string
+		origin       string
+		rpID         string
+		userHandle   []byte
+		unregistered bool
+		wantErr      bool
+	}{
+		{name: "ok", storedCount: 4, count: 5},
+		{name: "user handle", count: 1, userHandle: userID[:]},
+		{name: "authenticator without counter", storedCount: 0, count: 0},
+		{name: "counter regression", storedCount: 10, count: 5, wantErr: true},
+		{name: "counter repeated", storedCount: 10, count: 10, wantErr: true},
+		{name: "user not verified", count: 1, flags: webauthntest.FlagUserPresent, wantErr: true},
+		{name: "user not present", count: 1, flags: webauthntest.FlagUserVerified, wantErr: true},
+		{name: "wrong origin", count: 1, origin: "https://evil.example", wantErr: true},
+		{name: "wrong rp id", count: 1, rpID: "evil.example", wantErr: true},
+		{name: "second factor challenge", count: 1, purpose: purposeMFA, wantErr: true},
+		{name: "other user handle", count: 1, userHandle: []byte("someone else"), wantErr: true},
+		{name: "unregistered credential", count: 1, unregistered: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestService()
+			a := webauthntest.NewES256(t)
+
+			if !tt.unregistered {
+				_, err := repo.CreatePasskey(context.Background(), domain.Passkey{
+					UserID:    userID,
+					PublicKey: a.PublicKey,
+					SignCount: tt.storedCount,
+				}, a.CredentialID)
+				if err != nil {
+					t.Fatalf("CreatePasskey() error = %s", err)
+				}
+			}
+
+			purpose := purposeLogin
+			if tt.purpose != "" {
+				purpose = tt.purpose
+			}
+			origin := testOrigin
+			if tt.origin != "" {
+				origin = tt.origin
+			}
+			rpID := testRPID
+			if tt.rpID != "" {
+				rpID = tt.rpID
+			}
+			flags := byte(flagsUV)
+			if tt.flags != 0 {
+				flags = tt.flags
+			}
+
+			challenge := newChallenge(t, s, purpose, uuid.Nil)
+			clientData := webauthntest.ClientData(webauthn.TypeGet, challenge, origin)
+
+			got, err := s.FinishLogin(context.Background(), assertion(t, a, clientData, a.AuthData(rpID, flags, tt.count), tt.userHandle))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FinishLogin() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, domain.ErrInvalidPasskey) {
+					t.Errorf("FinishLogin() error = %v, want ErrInvalidPasskey", err)
+				}
+				return
+			}
+
+			if got != userID {
+				t.Errorf("FinishLogin() = %s, want %s", got, userID)
+			}
+
+			stored, _ := repo.GetPasskey(context.Background(), a.CredentialID)
+			if stored.SignCount != tt.count {
+				t.Errorf("stored sign count = %d, want %d", stored.SignCount, tt.count)
+			}
+		})
+	}
+}
+
+func TestFinishMFA(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name          string
+		challengeUser uuid.UUID
+		owner         uuid.UUID
+		flags         byte
+		wantErr       bool
+	}{
+		{name: "verified", flags: flagsUV},
+		{name: "user verification not required", flags: webauthntest.FlagUserPresent},
+		{name: "passkey of other user", owner: uuid.New(), flags: flagsUV, wantErr: true},
+		{name: "challenge of other user", challengeUser: uuid.New(), flags: flagsUV, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestService()
+			a := webauthntest.NewEd25519(t)
+
+			owner := userID
+			if tt.owner != uuid.Nil {
+				owner = tt.owner
+			}
+			challengeUser := userID
+			if tt.challengeUser != uuid.Nil {
+				challengeUser = tt.challengeUser
+			}
+
+			_, err := repo.CreatePasskey(context.Background(), domain.Passkey{
+				UserID:    owner,
+				PublicKey: a.PublicKey,
+			}, a.CredentialID)
+			if err != nil {
+				t.Fatalf("CreatePasskey() error = %s", err)
+			}
+
+			challenge := newChallenge(t, s, purposeMFA, challengeUser)
+			clientData := webauthntest.ClientData(webauthn.TypeGet, challenge, testOrigin)
+
+			err = s.FinishMFA(context.Background(), userID, assertion(t, a, clientData, a.AuthData(testRPID, tt.flags, 1), nil))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FinishMFA() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, domain.ErrInvalidPasskey) {
+				t.Errorf("FinishMFA() error = %v, want ErrInvalidPasskey", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package password
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/repo/onetime"
+	"github.com/akemoon/crowdfunding-app-auth/service/audit"
+	"github.com/akemoon/crowdfunding-app-auth/service/creds"
+	"github.com/akemoon/crowdfunding-app-auth/service/lockout"
+	"github.com/akemoon/crowdfunding-app-auth/service/token"
+	"github.com/akemoon/crowdfunding-app-auth/tool/mailer"
+	"github.com/akemoon/crowdfunding-app-auth/tool/randtoken"
+	"github.com/google/uuid"
+)
+
+const (
+	purposePasswordReset = "password_reset"
+	defaultResetTokenTTL = 30 * time.Minute
+)
+
+// resetPayload binds a reset token to the password it was issued for, so
+// any later password change invalidates outstanding tokens. A rehash of the
+// same password does not.
+type resetPayload struct {
+	UserID            uuid.UUID `json:"userID"`
+	PasswordChangedAt time.Time `json:"passwordChangedAt"`
+}
+
+type Service struct {
+	credsSvc   *creds.Service
+	tokenSvc   *token.Service
+	lockoutSvc *lockout.Service
+	tokenRepo  onetime.Repo
+	mailer     mailer.Mailer
+	auditSvc   *audit.Service
+	cfg        config.PasswordReset
+}
+
+func NewService(cs *creds.Service, ts *token.Service, ls *lockout.Service, tr onetime.Repo, m mailer.Mailer, as *audit.Service, cfg config.PasswordReset) *Service {
+	if cfg.TokenTTL == 0 {
+		cfg.TokenTTL = defaultResetTokenTTL
+	}
+
+	return &Service{
+		credsSvc:   cs,
+		tokenSvc:   ts,
+		lockoutSvc: ls,
+		tokenRepo:  tr,
+		mailer:     m,
+		auditSvc:   as,
+		cfg:        cfg,
+	}
+}
+
+// Forgot emails a reset link if the address is registered. Unknown
+// addresses are silently ignored; the mailer is expected to queue, so both
+// answer in about the same time.
+func (s *Service) Forgot(ctx context.Context, email string) error {
+	c, err := s.credsSvc.GetCredsByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrCredsNotFound) {
+			return nil
+		}
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	resetToken, err := randtoken.New()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	payload, err := json.Marshal(resetPayload{
+		UserID:            c.UserID,
+		PasswordChangedAt: c.PasswordChangedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	err = s.tokenRepo.Set(ctx, purposePasswordReset, randtoken.Hash(resetToken), string(payload), s.cfg.TokenTTL)
+	if err != nil {
+		return fmt.Errorf("token repo: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      c.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Follow the link to choose a new password:\n\n%s%s\n\nThe link expires in %s. If you did not request a reset, ignore this email.",
+			s.cfg.LinkURL, resetToken, s.cfg.TokenTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: mailer: %s", domain.ErrInternal, err)
+	}
+
+	return nil
+}
+
+// Change replaces the password of a signed-in user. Every other session is
+// revoked, the session that made the change stays signed in.
+func (s *Service) Change(ctx context.Context, tc domain.TokenClaims, req domain.ChangePasswordRequest) error {
+	c, err := s.credsSvc.CheckPassword(ctx, tc.UserID, req.CurrentPassword)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidPassrord) {
+			return domain.ErrInvalidCurrentPassword
+		}
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	err = s.credsSvc.ValidatePassword("newPassword", req.NewPassword, c.Email, req.CurrentPassword)
+	if err != nil {
+		return err
+	}
+
+	err = s.credsSvc.UpdatePassword(ctx, c.UserID, req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	err = s.tokenSvc.RevokeOtherSessions(ctx, c.UserID, tc.SessionID)
+	if err != nil {
+		return fmt.Errorf("token service: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, domain.AuthEvent{
+		Type:   domain.AuthEventPasswordChange,
+		UserID: &c.UserID,
+	})
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      c.Email,
+		Subject: "Your password was changed",
+		Body:    "The password of your account was just changed and all other sessions were signed out.\n\nIf this wasn't you, reset your password immediately.",
+	})
+	if err != nil {
+		log.Printf("password change notification: %s", err)
+	}
+
+	return nil
+}
+
+// Reset sets a new password using a reset token and signs the user out of
+// every session.
+func (s *Service) Reset(ctx context.Context, req domain.ResetPasswordRequest) error {
+	if req.Token == "" {
+		return domain.ErrInvalidResetToken
+	}
+
+	tokenHash := randtoken.Hash(req.Token)
+
+	// the token is only consumed once the new password passes the policy,
+	// so a rejected password does not burn the reset link
+	value, err := s.tokenRepo.Get(ctx, purposePasswordReset, tokenHash)
+	if err != nil {
+		if errors.Is(err, domain.ErrOneTimeTokenNotFound) {
+			return domain.ErrInvalidResetToken
+		}
+		return fmt.Errorf("token repo: %w", err)
+	}
+
+	var payload resetPayload
+	err = json.Unmarshal([]byte(value), &payload)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	c, err := s.credsSvc.GetCredsByUserID(ctx, payload.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrCredsNotFound) {
+			return domain.ErrInvalidResetToken
+		}
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	if !c.PasswordChangedAt.Equal(payload.PasswordChangedAt) {
+		return domain.ErrInvalidResetToken
+	}
+
+	err = s.credsSvc.ValidatePassword("password", req.Password, c.Email)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.tokenRepo.Take(ctx, purposePasswordReset, tokenHash)
+	if err != nil {
+		if errors.Is(err, domain.ErrOneTimeTokenNotFound) {
+			return domain.ErrInvalidResetToken
+		}
+		return fmt.Errorf("token repo: %w", err)
+	}
+
+	err = s.credsSvc.UpdatePassword(ctx, c.UserID, req.Password)
+	if err != nil {
+		return fmt.Errorf("creds service: %w", err)
+	}
+
+	// whoever proved control of the mailbox is no longer locked out by
+	// guesses made against the old password
+	err = s.lockoutSvc.Reset(ctx, c.UserID)
+	if err != nil {
+		log.Printf("reset sign-in failures of %s: %s", c.UserID, err)
+	}
+
+	err = s.tokenSvc.RevokeAllRefreshTokens(ctx, c.UserID)
+	if err != nil {
+		return fmt.Errorf("token service: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, domain.AuthEvent{
+		Type:   domain.AuthEventPasswordReset,
+		UserID: &c.UserID,
+	})
+
+	return nil
+}
@@ -2,11 +2,12 @@ package token
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/pkg/authverify"
 	"github.com/akemoon/crowdfunding-app-auth/repo/token"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -19,21 +20,22 @@ const (
 
 type Service struct {
 	refreshTokenRepo token.RefreshTokenRepo
+	revocationRepo   token.RevocationRepo
 	secret           string
+	verifier         *authverify.Verifier
 }
 
-func NewService(r token.RefreshTokenRepo, s string) *Service {
+func NewService(r token.RefreshTokenRepo, rr token.RevocationRepo, s string) *Service {
 	return &Service{
 		refreshTokenRepo: r,
+		revocationRepo:   rr,
 		secret:           s,
+		verifier:         authverify.NewSecretVerifier(s),
 	}
 }
 
 func (s *Service) GenAccessToken(ctx context.Context, tc domain.TokenClaims) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userID": tc.UserID,
-		"exp":    time.Now().Add(accessTokenLifeTime).Unix(),
-	})
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims(tc, authverify.TokenTypeAccess, accessTokenLifeTime))
 
 	signedToken, err := token.SignedString([]byte(s.secret))
 	if err != nil {
@@ -44,17 +46,13 @@ func (s *Service) GenAccessToken(ctx context.Context, tc domain.TokenClaims) (st
 }
 
 func (s *Service) GenRefreshToken(ctx context.Context, tc domain.TokenClaims) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userID": tc.UserID,
-		"exp":    time.Now().Add(refreshTokenLifeTime).Unix(),
-	})
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims(tc, authverify.TokenTypeRefresh, refreshTokenLifeTime))
 
 	signedToken, err := token.SignedString([]byte(s.secret))
 	if err != nil {
 		return "", fmt.Errorf("%w: %s", domain.ErrInternal, err)
 	}
-	// TODO: user id : token or just token
-	err = s.refreshTokenRepo.Set(ctx, signedToken)
+	err = s.refreshTokenRepo.Set(ctx, tc.UserID, tc.SessionID, signedToken, refreshTokenLifeTime)
 	if err != nil {
 		return "", fmt.Errorf("token repo: %w", err)
 	}
@@ -62,33 +60,56 @@ func (s *Service) GenRefreshToken(ctx context.Context, tc domain.TokenClaims) (s
 	return signedToken, nil
 }
 
-//func (s *Service) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
-//	err := s.tokenRepo.Check(ctx, refreshToken)
-//	if err != nil {
-//		return "", "", err
-//	}
-//
-//	userID, err := s.userIDFromRefreshToken(refreshToken)
-//	if err != nil {
-//		return "", "", err
-//	}
-//
-//	at, err := s.GenAccessToken(ctx, domain.TokenClaims{
-//		UserID: userID,
-//	})
-//	if err != nil {
-//		return "", "", err
-//	}
-//
-//	rt, err := s.GenRefreshToken(ctx, domain.TokenClaims{
-//		UserID: userID,
-//	})
-//	if err != nil {
-//		return "", "", err
-//	}
-//
-//	return at, rt, nil
-//}
+// RefreshTokenOwner returns the user a well-formed refresh token was issued
+// to. It does not check whether the token was revoked.
+func (s *Service) RefreshTokenOwner(ctx context.Context, refreshToken string) (uuid.UUID, error) {
+	claims, err := s.verifier.VerifyToken(ctx, refreshToken)
+	if err != nil || claims.TokenType != authverify.TokenTypeRefresh {
+		return uuid.Nil, domain.ErrInvlaidRefreshToken
+	}
+
+	return claims.UserID, nil
+}
+
+// Refresh rotates a refresh token: the presented token is revoked and a new
+// access/refresh pair is issued for the same user and session. Restricted
+// and Scopes are taken from restriction, which reflects the account as it
+// is now, not from the presented token.
+func (s *Service) Refresh(ctx context.Context, refreshToken string, restriction domain.TokenClaims) (domain.SignInResponse, error) {
+	claims, err := s.verifier.VerifyToken(ctx, refreshToken)
+	if err != nil || claims.TokenType != authverify.TokenTypeRefresh {
+		return domain.SignInResponse{}, domain.ErrInvlaidRefreshToken
+	}
+
+	// consumed atomically: of concurrent refreshes with the same token only
+	// one gets a new pair
+	err = s.refreshTokenRepo.Take(ctx, refreshToken)
+	if err != nil {
+		return domain.SignInResponse{}, fmt.Errorf("token repo: %w", err)
+	}
+
+	tc := domain.TokenClaims{
+		UserID:     claims.UserID,
+		SessionID:  claims.SessionID,
+		Restricted: restriction.Restricted,
+		Scopes:     restriction.Scopes,
+	}
+
+	accessToken, err := s.GenAccessToken(ctx, tc)
+	if err != nil {
+		return domain.SignInResponse{}, err
+	}
+
+	newRefreshToken, err := s.GenRefreshToken(ctx, tc)
+	if err != nil {
+		return domain.SignInResponse{}, err
+	}
+
+	return domain.SignInResponse{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+	}, nil
+}
 
 func (s *Service) DeleteRefreshToken(ctx context.Context, refreshToken string) error {
 	err := s.refreshTokenRepo.Delete(ctx, refreshToken)
@@ -99,41 +120,85 @@ func (s *Service) DeleteRefreshToken(ctx context.Context, refreshToken string) e
 	return nil
 }
 
-func (s *Service) ValidateAccessToken(token string) (uuid.UUID, error) {
-	parts := strings.Fields(token)
-	if len(parts) != 2 || parts[0] != "Bearer" {
-		return uuid.Nil, domain.ErrInvalidAccessToken
+// RevokeAllRefreshTokens signs the user out of every session.
+func (s *Service) RevokeAllRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	err := s.refreshTokenRepo.DeleteAllByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("token repo: %w", err)
 	}
 
-	parser := jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	return nil
+}
 
-	jwtToken, err := parser.Parse(parts[1], func(t *jwt.Token) (interface{}, error) {
-		if t.Method.Alg() != jwt.SigningMethodHS256.Alg() {
-			return nil, fmt.Errorf("%w: unexpected signing method: %s", domain.ErrInvalidAccessToken, t.Header["alg"])
-		}
-		return []byte(s.secret), nil
-	})
+// RevokeOtherSessions signs the user out of every session except sessionID.
+func (s *Service) RevokeOtherSessions(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	err := s.refreshTokenRepo.DeleteOtherSessions(ctx, userID, sessionID)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("parse token: %w", err)
+		return fmt.Errorf("token repo: %w", err)
 	}
-	if !jwtToken.Valid {
-		return uuid.Nil, domain.ErrInvalidAccessToken
+
+	return nil
+}
+
+// RevokeAccessTokens invalidates every access token issued to the user so
+// far. Requests checked by this service see the revocation, and so do
+// services verifying tokens locally with authverify.Config.Revocations set;
+// other services accept the tokens until they expire.
+func (s *Service) RevokeAccessTokens(ctx context.Context, userID uuid.UUID) error {
+	err := s.revocationRepo.RevokeBefore(ctx, userID, time.Now(), accessTokenLifeTime)
+	if err != nil {
+		return fmt.Errorf("revocation repo: %w", err)
 	}
 
-	claims, ok := jwtToken.Claims.(jwt.MapClaims)
-	if !ok {
-		return uuid.Nil, domain.ErrInvalidAccessToken
+	return nil
+}
+
+// ParseAccessToken validates the Authorization header value and returns the
+// claims of its access token.
+func (s *Service) ParseAccessToken(ctx context.Context, token string) (domain.TokenClaims, error) {
+	raw, err := authverify.BearerToken(token)
+	if err != nil {
+		return domain.TokenClaims{}, domain.ErrInvalidAccessToken
 	}
 
-	userIDStr, ok := claims["userID"].(string)
-	if !ok || userIDStr == "" {
-		return uuid.Nil, domain.ErrInvalidAccessToken
+	claims, err := s.verifier.Verify(ctx, raw)
+	if err != nil {
+		if errors.Is(err, authverify.ErrInvalidToken) {
+			return domain.TokenClaims{}, fmt.Errorf("%w: %s", domain.ErrInvalidAccessToken, err)
+		}
+		return domain.TokenClaims{}, fmt.Errorf("%w: %s", domain.ErrInternal, err)
 	}
 
-	userID, err := uuid.Parse(userIDStr)
+	revokedBefore, err := s.revocationRepo.RevokedBefore(ctx, claims.UserID)
 	if err != nil {
-		return uuid.Nil, domain.ErrInvalidAccessToken
+		return domain.TokenClaims{}, fmt.Errorf("revocation repo: %w", err)
 	}
 
-	return userID, nil
+	if authverify.Revoked(claims, revokedBefore) {
+		return domain.TokenClaims{}, fmt.Errorf("%w: revoked", domain.ErrInvalidAccessToken)
+	}
+
+	return domain.TokenClaims{
+		UserID:     claims.UserID,
+		SessionID:  claims.SessionID,
+		Restricted: claims.Restricted,
+		Scopes:     claims.Scopes,
+	}, nil
+}
+
+func newClaims(tc domain.TokenClaims, tokenType string, lifeTime time.Duration) authverify.Claims {
+	now := time.Now()
+
+	return authverify.Claims{
+		UserID:     tc.UserID,
+		TokenType:  tokenType,
+		SessionID:  tc.SessionID,
+		Restricted: tc.Restricted,
+		Scopes:     tc.Scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(lifeTime)),
+		},
+	}
 }
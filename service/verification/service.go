@@ -0,0 +1,156 @@
+package verification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/repo/creds"
+	"github.com/akemoon/crowdfunding-app-auth/repo/onetime"
+	"github.com/akemoon/crowdfunding-app-auth/tool/emailnorm"
+	"github.com/akemoon/crowdfunding-app-auth/tool/mailer"
+	"github.com/akemoon/crowdfunding-app-auth/tool/randtoken"
+	"github.com/google/uuid"
+)
+
+const (
+	purposeEmailVerification = "email_verification"
+	defaultTokenTTL          = 24 * time.Hour
+)
+
+type tokenPayload struct {
+	UserID uuid.UUID `json:"userID"`
+	Email  string    `json:"email"`
+}
+
+type Service struct {
+	credsRepo creds.Repo
+	tokenRepo onetime.Repo
+	mailer    mailer.Mailer
+	cfg       config.EmailVerification
+}
+
+func NewService(cr creds.Repo, tr onetime.Repo, m mailer.Mailer, cfg config.EmailVerification) *Service {
+	if cfg.TokenTTL == 0 {
+		cfg.TokenTTL = defaultTokenTTL
+	}
+	if cfg.Policy == "" {
+		cfg.Policy = config.EmailVerificationOff
+	}
+
+	return &Service{
+		credsRepo: cr,
+		tokenRepo: tr,
+		mailer:    m,
+		cfg:       cfg,
+	}
+}
+
+// SendVerification emails a single-use verification link for email. The
+// token is bound to the address, so it stops working if the email changes.
+func (s *Service) SendVerification(ctx context.Context, userID uuid.UUID, email string) error {
+	token, err := randtoken.New()
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	payload, err := json.Marshal(tokenPayload{
+		UserID: userID,
+		Email:  email,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	err = s.tokenRepo.Set(ctx, purposeEmailVerification, randtoken.Hash(token), string(payload), s.cfg.TokenTTL)
+	if err != nil {
+		return fmt.Errorf("token repo: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Follow the link to confirm your email address:\n\n%s%s\n\nThe link expires in %s.",
+			s.cfg.LinkURL, token, s.cfg.TokenTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: mailer: %s", domain.ErrInternal, err)
+	}
+
+	return nil
+}
+
+func (s *Service) Verify(ctx context.Context, token string) error {
+	if token == "" {
+		return domain.ErrInvalidVerificationToken
+	}
+
+	value, err := s.tokenRepo.Take(ctx, purposeEmailVerification, randtoken.Hash(token))
+	if err != nil {
+		if errors.Is(err, domain.ErrOneTimeTokenNotFound) {
+			return domain.ErrInvalidVerificationToken
+		}
+		return fmt.Errorf("token repo: %w", err)
+	}
+
+	var payload tokenPayload
+	err = json.Unmarshal([]byte(value), &payload)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInternal, err)
+	}
+
+	err = s.credsRepo.SetEmailVerified(ctx, payload.UserID, payload.Email)
+	if err != nil {
+		if errors.Is(err, domain.ErrCredsNotFound) {
+			return domain.ErrInvalidVerificationToken
+		}
+		return fmt.Errorf("creds repo: %w", err)
+	}
+
+	return nil
+}
+
+// Resend sends a new verification link. It reports success for unknown and
+// already verified addresses so the endpoint cannot be used to probe emails.
+func (s *Service) Resend(ctx context.Context, email string) error {
+	email, err := emailnorm.Normalize(email)
+	if err != nil {
+		return nil
+	}
+
+	c, err := s.credsRepo.GetCredsByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrCredsNotFound) {
+			return nil
+		}
+		return fmt.Errorf("creds repo: %w", err)
+	}
+
+	if c.EmailVerified() {
+		return nil
+	}
+
+	return s.SendVerification(ctx, c.UserID, c.Email)
+}
+
+// ApplySignInPolicy enforces the configured policy for a user who has just
+// proven their credentials.
+func (s *Service) ApplySignInPolicy(c domain.Creds, tc *domain.TokenClaims) error {
+	if c.EmailVerified() {
+		return nil
+	}
+
+	switch s.cfg.Policy {
+	case config.EmailVerificationBlock:
+		return domain.ErrEmailNotVerified
+	case config.EmailVerificationRestrict:
+		tc.Restricted = true
+		tc.Scopes = s.cfg.UnverifiedScopes
+	}
+
+	return nil
+}
@@ -0,0 +1,359 @@
+// Package credimport creates accounts in bulk from a partner export. Rows
+// are inserted in batches, each new account gets a profile in the user
+// service, and every row is reported. Progress is checkpointed after each
+// batch so an interrupted import can be continued.
+package credimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/akemoon/crowdfunding-app-auth/cluster/user"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/tool/emailnorm"
+	"github.com/google/uuid"
+)
+
+const defaultBatchSize = 500
+
+// userIDNamespace derives the user id of rows without one from their email,
+// so a repeated run recognizes accounts it created before being stopped.
+var userIDNamespace = uuid.MustParse("6f1c1e4e-8a53-4d1e-9a53-2f0c6b7d9e21")
+
+type Status string
+
+const (
+	StatusCreated Status = "created"
+	StatusExists  Status = "exists"
+	StatusInvalid Status = "invalid"
+	StatusFailed  Status = "failed"
+)
+
+type Store interface {
+	// ImportCreds inserts the credentials that do not collide with an
+	// existing account and returns the user ids it inserted.
+	ImportCreds(ctx context.Context, creds []domain.Creds, verified bool) ([]uuid.UUID, error)
+	GetCredsByEmail(ctx context.Context, email string) (domain.Creds, error)
+	DeleteCredsByUserID(ctx context.Context, userID uuid.UUID) error
+}
+
+type Hasher interface {
+	Hash(password string) (string, error)
+	// Match reports whether hash has a format the service can verify.
+	Match(hash string) bool
+	// Validate reports why a hash of a supported format cannot be verified.
+	Validate(hash string) error
+}
+
+type Config struct {
+	BatchSize int
+	// Verified marks imported emails as verified.
+	Verified bool
+	// Skip is the number of rows processed by an earlier run.
+	Skip int
+}
+
+// Result is the outcome of one row. Row numbers start at 1 and do not
+// count the CSV header or blank lines.
+type Result struct {
+	Row    int    `json:"row"`
+	Email  string `json:"email"`
+	UserID string `json:"userID,omitempty"`
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Summary struct {
+	// Processed counts every row handled so far, including earlier runs.
+	Processed int `json:"processed"`
+	Skipped   int `json:"skipped"`
+	Created   int `json:"created"`
+	Exists    int `json:"exists"`
+	Invalid   int `json:"invalid"`
+	Failed    int `json:"failed"`
+}
+
+type Importer struct {
+	store  Store
+	users  user.Client
+	hasher Hasher
+	cfg    Config
+
+	// created holds the accounts of this run, so a later row with the same
+	// email is not mistaken for an account of an interrupted run.
+	created map[uuid.UUID]struct{}
+}
+
+func NewImporter(store Store, users user.Client, h Hasher, cfg Config) *Importer {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+
+	return &Importer{
+		store:   store,
+		users:   users,
+		hasher:  h,
+		cfg:     cfg,
+		created: make(map[uuid.UUID]struct{}),
+	}
+}
+
+type pending struct {
+	result   Result
+	username string
+	creds    domain.Creds
+}
+
+// Run imports every row of rows after the first cfg.Skip. report receives
+// one Result per row and checkpoint the number of processed rows after
+// each batch. A row may be reported again when a run stops between its
+// report and the next checkpoint; the later result wins.
+//
+// Run stops with an error when the database or the user service is
+// unavailable. The batch it was working on is repeated by the next run.
+func (im *Importer) Run(ctx context.Context, rows Reader, report func(Result) error, checkpoint func(processed int) error) (Summary, error) {
+	summary := Summary{
+		Processed: im.cfg.Skip,
+		Skipped:   im.cfg.Skip,
+	}
+
+	row := 0
+	for ; row < im.cfg.Skip; row++ {
+		_, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			return summary, nil
+		}
+		if err != nil && !errors.Is(err, ErrMalformedRow) {
+			return summary, fmt.Errorf("skip row %d: %w", row+1, err)
+		}
+	}
+
+	for {
+		batch := make([]pending, 0, im.cfg.BatchSize)
+		eof := false
+
+		for len(batch) < im.cfg.BatchSize {
+			r, err := rows.Next()
+			if errors.Is(err, io.EOF) {
+				eof = true
+				break
+			}
+
+			row++
+
+			if err != nil {
+				if !errors.Is(err, ErrMalformedRow) {
+					return summary, fmt.Errorf("read row %d: %w", row, err)
+				}
+				batch = append(batch, pending{result: Result{Row: row, Status: StatusInvalid, Error: err.Error()}})
+				continue
+			}
+
+			batch = append(batch, im.prepare(row, r))
+		}
+
+		err := im.importBatch(ctx, batch, report, &summary)
+		if err != nil {
+			return summary, err
+		}
+
+		summary.Processed += len(batch)
+
+		if len(batch) > 0 {
+			err = checkpoint(summary.Processed)
+			if err != nil {
+				return summary, fmt.Errorf("checkpoint: %w", err)
+			}
+		}
+
+		if eof {
+			return summary, nil
+		}
+	}
+}
+
+// prepare validates a row and hashes plaintext passwords. Invalid rows, and
+// rows whose hash could never be verified, are returned with their result
+// already set.
+func (im *Importer) prepare(row int, r Row) pending {
+	p := pending{
+		result:   Result{Row: row, Email: r.Email},
+		username: strings.TrimSpace(r.Username),
+	}
+
+	invalid := func(msg string) pending {
+		p.result.Status = StatusInvalid
+		p.result.Error = msg
+		return p
+	}
+
+	email, err := emailnorm.Normalize(r.Email)
+	if err != nil {
+		return invalid("invalid email")
+	}
+	p.result.Email = email
+
+	if p.username == "" {
+		return invalid("username is required")
+	}
+
+	userID := uuid.NewSHA1(userIDNamespace, []byte(email))
+	if strings.TrimSpace(r.UserID) != "" {
+		userID, err = uuid.Parse(strings.TrimSpace(r.UserID))
+		if err != nil {
+			return invalid("invalid user id")
+		}
+	}
+
+	var passwordHash string
+	switch {
+	case r.PasswordHash != "":
+		if !im.hasher.Match(r.PasswordHash) {
+			return invalid("unsupported password hash format")
+		}
+		// a stored hash with out-of-range parameters would only fail, or
+		// exhaust the service, at the user's first sign-in
+		err = im.hasher.Validate(r.PasswordHash)
+		if err != nil {
+			p.result.Status = StatusFailed
+			p.result.Error = fmt.Sprintf("invalid password hash: %s", err)
+			return p
+		}
+		passwordHash = r.PasswordHash
+	case r.Password != "":
+		passwordHash, err = im.hasher.Hash(r.Password)
+		if err != nil {
+			return invalid(fmt.Sprintf("hash password: %s", err))
+		}
+	default:
+		return invalid("password or password hash is required")
+	}
+
+	p.creds = domain.Creds{
+		UserID:       userID,
+		Email:        email,
+		PasswordHash: passwordHash,
+	}
+
+	return p
+}
+
+func (im *Importer) importBatch(ctx context.Context, batch []pending, report func(Result) error, summary *Summary) error {
+	var creds []domain.Creds
+	for _, p := range batch {
+		if p.result.Status == "" {
+			creds = append(creds, p.creds)
+		}
+	}
+
+	inserted := make(map[uuid.UUID]struct{}, len(creds))
+	if len(creds) > 0 {
+		ids, err := im.store.ImportCreds(ctx, creds, im.cfg.Verified)
+		if err != nil {
+			return fmt.Errorf("import creds: %w", err)
+		}
+		for _, id := range ids {
+			inserted[id] = struct{}{}
+		}
+	}
+
+	for _, p := range batch {
+		if p.result.Status == "" {
+			err := im.createProfile(ctx, &p, inserted)
+			if err != nil {
+				return err
+			}
+		}
+
+		switch p.result.Status {
+		case StatusCreated:
+			summary.Created++
+		case StatusExists:
+			summary.Exists++
+		case StatusInvalid:
+			summary.Invalid++
+		case StatusFailed:
+			summary.Failed++
+		}
+
+		err := report(p.result)
+		if err != nil {
+			return fmt.Errorf("report row %d: %w", p.result.Row, err)
+		}
+	}
+
+	return nil
+}
+
+// createProfile sets the result of a row that passed validation. It only
+// returns an error when the import has to stop.
+func (im *Importer) createProfile(ctx context.Context, p *pending, inserted map[uuid.UUID]struct{}) error {
+	userID := p.creds.UserID
+	p.result.UserID = userID.String()
+
+	_, isNew := inserted[userID]
+	if !isNew {
+		resumed, err := im.resumed(ctx, p.creds)
+		if err != nil {
+			return err
+		}
+		if !resumed {
+			p.result.UserID = ""
+			p.result.Status = StatusExists
+			return nil
+		}
+	}
+
+	err := im.users.CreateUser(ctx, user.CreateUserReq{
+		UserID:   userID,
+		Username: p.username,
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, user.ErrUsernameExists) && !isNew:
+		// the interrupted run created the profile before stopping
+	case errors.Is(err, user.ErrUsernameExists):
+		p.result.UserID = ""
+		p.result.Status = StatusFailed
+		p.result.Error = err.Error()
+		im.rollback(ctx, userID)
+		return nil
+	default:
+		im.rollback(ctx, userID)
+		return fmt.Errorf("row %d: user client: %w", p.result.Row, err)
+	}
+
+	im.created[userID] = struct{}{}
+	p.result.Status = StatusCreated
+
+	return nil
+}
+
+// resumed reports whether c was inserted by an earlier run that stopped
+// before the profile was created.
+func (im *Importer) resumed(ctx context.Context, c domain.Creds) (bool, error) {
+	if _, ok := im.created[c.UserID]; ok {
+		return false, nil
+	}
+
+	existing, err := im.store.GetCredsByEmail(ctx, c.Email)
+	if err != nil {
+		if errors.Is(err, domain.ErrCredsNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("get creds: %w", err)
+	}
+
+	return existing.UserID == c.UserID, nil
+}
+
+func (im *Importer) rollback(ctx context.Context, userID uuid.UUID) {
+	err := im.store.DeleteCredsByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("roll back imported creds of %s: %s", userID, err)
+	}
+}
@@ -0,0 +1,130 @@
+package credimport
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Row is one account of an import file. Either PasswordHash, in any format
+// the service verifies, or a plaintext Password must be set. UserID is
+// optional.
+type Row struct {
+	Email        string `json:"email"`
+	PasswordHash string `json:"passwordHash"`
+	Password     string `json:"password"`
+	Username     string `json:"username"`
+	UserID       string `json:"userID"`
+}
+
+// ErrMalformedRow is wrapped by Next for a row that cannot be parsed.
+// Reading can continue with the next row.
+var ErrMalformedRow = errors.New("malformed row")
+
+// Reader streams rows. Next returns io.EOF after the last row.
+type Reader interface {
+	Next() (Row, error)
+}
+
+// CSVReader reads a CSV file whose header names the columns email,
+// password_hash, password, username and user_id in any order.
+type CSVReader struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func NewCSVReader(r io.Reader) (*CSVReader, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	if _, ok := columns["email"]; !ok {
+		return nil, errors.New("header has no email column")
+	}
+
+	return &CSVReader{
+		r:       cr,
+		columns: columns,
+	}, nil
+}
+
+func (r *CSVReader) Next() (Row, error) {
+	record, err := r.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return Row{}, fmt.Errorf("%w: %s", ErrMalformedRow, err)
+		}
+		return Row{}, err
+	}
+
+	field := func(name string) string {
+		i, ok := r.columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	return Row{
+		Email:        field("email"),
+		PasswordHash: field("password_hash"),
+		Password:     field("password"),
+		Username:     field("username"),
+		UserID:       field("user_id"),
+	}, nil
+}
+
+// JSONLReader reads one JSON object per line. Blank lines are skipped.
+type JSONLReader struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+func NewJSONLReader(r io.Reader) *JSONLReader {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+
+	return &JSONLReader{
+		sc: sc,
+	}
+}
+
+func (r *JSONLReader) Next() (Row, error) {
+	for r.sc.Scan() {
+		r.line++
+
+		line := strings.TrimSpace(r.sc.Text())
+		if line == "" {
+			continue
+		}
+
+		var row Row
+		err := json.Unmarshal([]byte(line), &row)
+		if err != nil {
+			return Row{}, fmt.Errorf("%w: line %d: %s", ErrMalformedRow, r.line, err)
+		}
+
+		return row, nil
+	}
+
+	err := r.sc.Err()
+	if err != nil {
+		return Row{}, err
+	}
+
+	return Row{}, io.EOF
+}
@@ -0,0 +1,134 @@
+// Package emailaudit finds accounts whose emails collide once normalized.
+// Such accounts cannot be merged automatically; they are flagged so the
+// unique index skips them and reported for a manual merge.
+package emailaudit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/tool/emailnorm"
+	"github.com/google/uuid"
+)
+
+type Store interface {
+	ListEmails(ctx context.Context) ([]domain.EmailRecord, error)
+	RewriteEmail(ctx context.Context, userID uuid.UUID, email string) error
+	SetEmailConflict(ctx context.Context, userID uuid.UUID, conflict bool) error
+}
+
+type Conflict struct {
+	// Email is the normalized address shared by Accounts.
+	Email    string               `json:"email"`
+	Accounts []domain.EmailRecord `json:"accounts"`
+}
+
+type Report struct {
+	Scanned int `json:"scanned"`
+	// Normalized counts emails rewritten to their normalized form.
+	Normalized int `json:"normalized"`
+	// Resolved counts accounts no longer in conflict, e.g. after a merge.
+	Resolved  int                  `json:"resolved"`
+	Conflicts []Conflict           `json:"conflicts"`
+	Invalid   []domain.EmailRecord `json:"invalid"`
+	// Unreachable lists emails that differ from their normalized form by
+	// more than case and surrounding spaces, e.g. in Unicode composition or
+	// an IDN domain. The SQL migration cannot normalize them, so sign-in
+	// does not find these accounts until they are rewritten.
+	Unreachable []domain.EmailRecord `json:"unreachable"`
+	Applied     bool                 `json:"applied"`
+}
+
+// Run audits every account. Without apply it only reports what it would
+// change.
+func Run(ctx context.Context, store Store, apply bool) (Report, error) {
+	records, err := store.ListEmails(ctx)
+	if err != nil {
+		return Report{}, fmt.Errorf("list emails: %w", err)
+	}
+
+	report := Report{
+		Scanned:     len(records),
+		Conflicts:   []Conflict{},
+		Invalid:     []domain.EmailRecord{},
+		Unreachable: []domain.EmailRecord{},
+		Applied:     apply,
+	}
+
+	groups := make(map[string][]domain.EmailRecord)
+	var order []string
+
+	for _, rec := range records {
+		normalized, err := emailnorm.Normalize(rec.Email)
+		if err != nil {
+			report.Invalid = append(report.Invalid, rec)
+			continue
+		}
+
+		// mirrors lower(btrim(email)) in the migration
+		if normalized != strings.ToLower(strings.Trim(rec.Email, " ")) {
+			report.Unreachable = append(report.Unreachable, rec)
+		}
+
+		if _, ok := groups[normalized]; !ok {
+			order = append(order, normalized)
+		}
+		groups[normalized] = append(groups[normalized], rec)
+	}
+
+	// Flag conflicts before rewriting anything, so rewrites never collide
+	// with an account that is still in the unique index.
+	for _, email := range order {
+		group := groups[email]
+		if len(group) < 2 {
+			continue
+		}
+
+		report.Conflicts = append(report.Conflicts, Conflict{Email: email, Accounts: group})
+
+		if !apply {
+			continue
+		}
+		for _, rec := range group {
+			if rec.Conflict {
+				continue
+			}
+			err = store.SetEmailConflict(ctx, rec.UserID, true)
+			if err != nil {
+				return report, fmt.Errorf("flag %s: %w", rec.UserID, err)
+			}
+		}
+	}
+
+	for _, email := range order {
+		group := groups[email]
+		if len(group) != 1 {
+			continue
+		}
+		rec := group[0]
+
+		if rec.Email != email {
+			report.Normalized++
+			if apply {
+				err = store.RewriteEmail(ctx, rec.UserID, email)
+				if err != nil {
+					return report, fmt.Errorf("normalize %s: %w", rec.UserID, err)
+				}
+			}
+		}
+
+		if rec.Conflict {
+			report.Resolved++
+			if apply {
+				err = store.SetEmailConflict(ctx, rec.UserID, false)
+				if err != nil {
+					return report, fmt.Errorf("resolve %s: %w", rec.UserID, err)
+				}
+			}
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,37 @@
+package emailnorm
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
+)
+
+var ErrInvalid = errors.New("invalid email")
+
+// Normalize returns the canonical form of an address used to identify
+// accounts: surrounding space trimmed, Unicode NFC, lower case, and the
+// domain in its ASCII (punycode) form. Lower-casing the local part is
+// stricter than RFC 5321 but matches how every mail provider we see treats
+// addresses.
+func Normalize(email string) (string, error) {
+	email = norm.NFC.String(strings.TrimSpace(email))
+
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 || at == len(email)-1 {
+		return "", ErrInvalid
+	}
+
+	local := strings.ToLower(email[:at])
+	if strings.ContainsAny(local, " \t\r\n") {
+		return "", ErrInvalid
+	}
+
+	domain, err := idna.Lookup.ToASCII(strings.TrimSuffix(email[at+1:], "."))
+	if err != nil || domain == "" {
+		return "", ErrInvalid
+	}
+
+	return local + "@" + strings.ToLower(domain), nil
+}
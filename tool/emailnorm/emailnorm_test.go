@@ -0,0 +1,63 @@
+package emailnorm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		want    string
+		wantErr error
+	}{
+		{"already normalized", "user@example.com", "user@example.com", nil},
+		{"case", "User.Name@Example.COM", "user.name@example.com", nil},
+		{"surrounding space", "  user@example.com\t", "user@example.com", nil},
+		{"trailing dot", "user@example.com.", "user@example.com", nil},
+		{"decomposed local part", "jose\u0301@example.com", "jos\u00e9@example.com", nil},
+		{"composed local part", "Jos\u00c9@example.com", "jos\u00e9@example.com", nil},
+		{"idn domain", "user@b\u00fccher.example", "user@xn--bcher-kva.example", nil},
+		{"idn domain upper case", "user@B\u00dcCHER.example", "user@xn--bcher-kva.example", nil},
+		{"decomposed idn domain", "user@bu\u0308cher.example", "user@xn--bcher-kva.example", nil},
+		{"punycode domain", "user@XN--BCHER-KVA.example", "user@xn--bcher-kva.example", nil},
+		{"at sign in local part", "\"a@b\"@example.com", "\"a@b\"@example.com", nil},
+		{"empty", "", "", ErrInvalid},
+		{"no at sign", "user.example.com", "", ErrInvalid},
+		{"empty local part", "@example.com", "", ErrInvalid},
+		{"empty domain", "user@", "", ErrInvalid},
+		{"only a dot as domain", "user@.", "", ErrInvalid},
+		{"space in local part", "us er@example.com", "", ErrInvalid},
+		{"invalid domain", "user@exa mple.com", "", ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.email)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Normalize(%q) error = %v, want %v", tt.email, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.email, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeIdempotent(t *testing.T) {
+	for _, email := range []string{"User@Example.COM", "jose\u0301@B\u00dcCHER.example."} {
+		once, err := Normalize(email)
+		if err != nil {
+			t.Fatalf("Normalize(%q) error = %v", email, err)
+		}
+
+		twice, err := Normalize(once)
+		if err != nil {
+			t.Fatalf("Normalize(%q) error = %v", once, err)
+		}
+		if twice != once {
+			t.Errorf("Normalize(%q) = %q, want %q", once, twice, once)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package argon2
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	defaultMemory      = 64 * 1024
+	defaultTime        = 3
+	defaultParallelism = 2
+	defaultSaltLen     = 16
+	defaultKeyLen      = 32
+
+	phcPrefix = "$argon2id$"
+
+	// Stored parameters are bounded so a corrupt or hostile hash cannot
+	// make Compare panic or exhaust memory.
+	maxMemory      = 1 << 20 // KiB, 1 GiB
+	maxTime        = 64
+	maxParallelism = 64
+	maxKeyLen      = 256
+)
+
+var errMalformedHash = errors.New("malformed argon2id hash")
+
+type params struct {
+	memory      uint32
+	time        uint32
+	parallelism uint8
+}
+
+// Hasher produces Argon2id hashes in PHC string format:
+// $argon2id$v=19$m=<memory>,t=<time>,p=<parallelism>$<salt>$<key>
+type Hasher struct {
+	params  params
+	saltLen uint32
+	keyLen  uint32
+}
+
+func NewHasher(cfg config.Argon2) *Hasher {
+	h := &Hasher{
+		params: params{
+			memory:      cfg.Memory,
+			time:        cfg.Time,
+			parallelism: cfg.Parallelism,
+		},
+		saltLen: defaultSaltLen,
+		keyLen:  defaultKeyLen,
+	}
+
+	if h.params.memory == 0 {
+		h.params.memory = defaultMemory
+	}
+	if h.params.time == 0 {
+		h.params.time = defaultTime
+	}
+	if h.params.parallelism == 0 {
+		h.params.parallelism = defaultParallelism
+	}
+
+	return h
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.saltLen)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.time, h.params.memory, h.params.parallelism, h.keyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		phcPrefix,
+		argon2.Version,
+		h.params.memory,
+		h.params.time,
+		h.params.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Hasher) Match(hash string) bool {
+	return strings.HasPrefix(hash, phcPrefix)
+}
+
+func (h *Hasher) Validate(hash string) error {
+	_, _, _, err := decode(hash)
+	return err
+}
+
+func (h *Hasher) Compare(password string, hash string) error {
+	p, salt, key, err := decode(hash)
+	if err != nil {
+		return domain.ErrInternal
+	}
+
+	other := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.parallelism, uint32(len(key)))
+
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return domain.ErrInvalidPassrord
+	}
+
+	return nil
+}
+
+func (h *Hasher) NeedsRehash(hash string) bool {
+	p, _, key, err := decode(hash)
+	if err != nil {
+		return true
+	}
+
+	return p.memory < h.params.memory ||
+		p.time < h.params.time ||
+		p.parallelism < h.params.parallelism ||
+		uint32(len(key)) < h.keyLen
+}
+
+func decode(hash string) (params, []byte, []byte, error) {
+	if !strings.HasPrefix(hash, phcPrefix) {
+		return params{}, nil, nil, errMalformedHash
+	}
+
+	parts := strings.Split(strings.TrimPrefix(hash, phcPrefix), "$")
+	if len(parts) != 4 {
+		return params{}, nil, nil, errMalformedHash
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[0], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return params{}, nil, nil, errMalformedHash
+	}
+
+	var p params
+	_, err = fmt.Sscanf(parts[1], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.parallelism)
+	if err != nil ||
+		p.memory < 1 || p.memory > maxMemory ||
+		p.time < 1 || p.time > maxTime ||
+		p.parallelism < 1 || p.parallelism > maxParallelism {
+		return params{}, nil, nil, errMalformedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return params{}, nil, nil, errMalformedHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(key) == 0 || len(key) > maxKeyLen {
+		return params{}, nil, nil, errMalformedHash
+	}
+
+	return p, salt, key, nil
+}
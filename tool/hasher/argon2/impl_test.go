@@ -0,0 +1,110 @@
+package argon2
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+)
+
+// referenceHash is the Argon2id vector of the reference implementation's
+// test suite: password "password", salt "somesalt", t=2, m=2^16, p=1.
+const referenceHash = "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+
+func TestCompare(t *testing.T) {
+	h := NewHasher(config.Argon2{})
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		wantErr  error
+	}{
+		{"reference vector", "password", referenceHash, nil},
+		{"wrong password", "Password", referenceHash, domain.ErrInvalidPassrord},
+		{"other version", "password", strings.Replace(referenceHash, "v=19", "v=16", 1), domain.ErrInternal},
+		{"other variant", "password", strings.Replace(referenceHash, "argon2id", "argon2i", 1), domain.ErrInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := h.Compare(tt.password, tt.hash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Compare() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	h := NewHasher(config.Argon2{Memory: 1024, Time: 1, Parallelism: 1})
+
+	hash, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("Hash() = %q, want the configured parameters", hash)
+	}
+	if err := h.Compare("correct horse", hash); err != nil {
+		t.Errorf("Compare() error = %v, want nil", err)
+	}
+	if h.NeedsRehash(hash) {
+		t.Errorf("NeedsRehash(%q) = true, want false", hash)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	h := NewHasher(config.Argon2{})
+
+	tests := []struct {
+		name    string
+		hash    string
+		wantErr bool
+	}{
+		{"reference vector", referenceHash, false},
+		{"not argon2id", "$2a$10$abcdefghijklmnopqrstuu", true},
+		{"missing key", "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ", true},
+		{"zero memory", "$argon2id$v=19$m=0,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc", true},
+		{"memory too large", "$argon2id$v=19$m=2097152,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc", true},
+		{"too many passes", "$argon2id$v=19$m=65536,t=65,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc", true},
+		{"salt not base64", "$argon2id$v=19$m=65536,t=2,p=1$c29t!XNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc", true},
+		{"empty key", "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := h.Validate(tt.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	h := NewHasher(config.Argon2{Memory: 65536, Time: 2, Parallelism: 1})
+
+	tests := []struct {
+		name string
+		hash string
+		want bool
+	}{
+		{"same parameters", referenceHash, false},
+		{"less memory", strings.Replace(referenceHash, "m=65536", "m=32768", 1), true},
+		{"fewer passes", strings.Replace(referenceHash, "t=2", "t=1", 1), true},
+		{"malformed", "$argon2id$", true},
+		{"other algorithm", "$2a$10$abcdefghijklmnopqrstuu", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
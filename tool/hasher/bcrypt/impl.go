@@ -2,11 +2,18 @@ package bcrypt
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/akemoon/crowdfunding-app-auth/domain"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// maxCost bounds stored hashes: every step doubles the work, and cost 31
+// takes days per attempt.
+const maxCost = 16
+
+var errMalformedHash = errors.New("malformed bcrypt hash")
+
 type Hasher struct {
 	cost int
 }
@@ -26,8 +33,27 @@ func (h *Hasher) Hash(password string) (string, error) {
 	return string(b), nil
 }
 
+func (h *Hasher) Match(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") ||
+		strings.HasPrefix(hash, "$2b$") ||
+		strings.HasPrefix(hash, "$2y$")
+}
+
+func (h *Hasher) Validate(hash string) error {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil || cost > max(maxCost, h.cost) {
+		return errMalformedHash
+	}
+	return nil
+}
+
 func (h *Hasher) Compare(password string, hash string) error {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	err := h.Validate(hash)
+	if err != nil {
+		return domain.ErrInternal
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	if err != nil {
 		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
 			return domain.ErrInvalidPassrord
@@ -36,3 +62,11 @@ func (h *Hasher) Compare(password string, hash string) error {
 	}
 	return nil
 }
+
+func (h *Hasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+	return cost < h.cost
+}
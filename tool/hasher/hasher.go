@@ -3,4 +3,7 @@ package hasher
 type Hasher interface {
 	Hash(str string) (string, error)
 	Compare(str string, hash string) error
+	// NeedsRehash reports whether hash was produced by another algorithm or
+	// with weaker parameters than the hasher currently uses.
+	NeedsRehash(hash string) bool
 }
@@ -0,0 +1,99 @@
+package pbkdf2
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	passlibPrefix = "$pbkdf2-sha256$"
+	djangoPrefix  = "pbkdf2_sha256$"
+
+	maxIterations = 10_000_000
+	// every 32 bytes of key cost another full run of the iterations
+	maxKeyLen = 256
+)
+
+var errMalformedHash = errors.New("malformed pbkdf2 hash")
+
+// Verifier checks PBKDF2-SHA256 hashes in the two formats found in legacy
+// exports:
+//
+//	$pbkdf2-sha256$<iterations>$<salt, adapted base64>$<key, adapted base64>
+//	pbkdf2_sha256$<iterations>$<salt, raw>$<key, base64>
+//
+// It only verifies; new hashes are produced by the configured hasher.
+type Verifier struct{}
+
+func NewVerifier() *Verifier {
+	return &Verifier{}
+}
+
+func (v *Verifier) Match(hash string) bool {
+	return strings.HasPrefix(hash, passlibPrefix) || strings.HasPrefix(hash, djangoPrefix)
+}
+
+func (v *Verifier) Validate(hash string) error {
+	_, _, _, err := decode(hash)
+	return err
+}
+
+func (v *Verifier) Compare(password string, hash string) error {
+	iterations, salt, key, err := decode(hash)
+	if err != nil {
+		return domain.ErrInternal
+	}
+
+	other := pbkdf2.Key([]byte(password), salt, iterations, len(key), sha256.New)
+
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return domain.ErrInvalidPassrord
+	}
+
+	return nil
+}
+
+func decode(hash string) (int, []byte, []byte, error) {
+	passlib := strings.HasPrefix(hash, passlibPrefix)
+
+	rest := strings.TrimPrefix(strings.TrimPrefix(hash, passlibPrefix), djangoPrefix)
+	parts := strings.Split(rest, "$")
+	if len(parts) != 3 {
+		return 0, nil, nil, errMalformedHash
+	}
+
+	iterations, err := strconv.Atoi(parts[0])
+	if err != nil || iterations <= 0 || iterations > maxIterations {
+		return 0, nil, nil, errMalformedHash
+	}
+
+	var salt, key []byte
+
+	if passlib {
+		salt, err = decodeAdaptedB64(parts[1])
+		if err != nil {
+			return 0, nil, nil, errMalformedHash
+		}
+		key, err = decodeAdaptedB64(parts[2])
+	} else {
+		salt = []byte(parts[1])
+		key, err = base64.StdEncoding.DecodeString(parts[2])
+	}
+	if err != nil || len(key) == 0 || len(key) > maxKeyLen {
+		return 0, nil, nil, errMalformedHash
+	}
+
+	return iterations, salt, key, nil
+}
+
+func decodeAdaptedB64(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.ReplaceAll(s, ".", "+"), "=")
+	return base64.RawStdEncoding.DecodeString(s)
+}
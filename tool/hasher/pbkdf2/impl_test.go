@@ -0,0 +1,70 @@
+package pbkdf2
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+)
+
+// The vectors are the PBKDF2-HMAC-SHA256 test vectors of RFC 7914, section
+// 11, in the passlib and Django formats.
+const (
+	passlibVector = "$pbkdf2-sha256$1$c2FsdA$VawEblbjCJ/sFpHCJUS2BflBhSFt3gRl5oudV8INrLxJypzM8Xm2RZkWZLOdd.8xfHG4RbHjC9UJESBB06GXgw"
+	djangoVector  = "pbkdf2_sha256$80000$NaCl$TdzY9guYviGDDO5e8icB+WQaRBjQTAQUrv8Ih2s0q1ah1CWhIlgzVJrbhBtRybMXaicr3ruh0HhHj2Kzl/M8jQ=="
+)
+
+func TestCompare(t *testing.T) {
+	v := NewVerifier()
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		wantErr  error
+	}{
+		{"passlib", "passwd", passlibVector, nil},
+		{"django", "Password", djangoVector, nil},
+		{"passlib wrong password", "password", passlibVector, domain.ErrInvalidPassrord},
+		{"django wrong password", "password", djangoVector, domain.ErrInvalidPassrord},
+		{"wrong iterations", "passwd", strings.Replace(passlibVector, "$1$", "$2$", 1), domain.ErrInvalidPassrord},
+		{"malformed", "passwd", "$pbkdf2-sha256$1$c2FsdA", domain.ErrInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Compare(tt.password, tt.hash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Compare() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	v := NewVerifier()
+
+	tests := []struct {
+		name    string
+		hash    string
+		wantErr bool
+	}{
+		{"passlib", passlibVector, false},
+		{"django", djangoVector, false},
+		{"zero iterations", "$pbkdf2-sha256$0$c2FsdA$VawEblbjCJ", true},
+		{"too many iterations", "$pbkdf2-sha256$10000001$c2FsdA$VawEblbjCJ", true},
+		{"iterations not a number", "pbkdf2_sha256$many$NaCl$TdzY9g==", true},
+		{"django key not base64", "pbkdf2_sha256$80000$NaCl$Tdz!", true},
+		{"empty key", "$pbkdf2-sha256$1$c2FsdA$", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
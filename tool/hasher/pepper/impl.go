@@ -0,0 +1,165 @@
+package pepper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"github.com/akemoon/crowdfunding-app-auth/tool/hasher"
+)
+
+const (
+	prefix = "$pepper$v="
+
+	// MinKeyLen is the shortest accepted key, the size of the HMAC output.
+	MinKeyLen = sha256.Size
+)
+
+// Hasher peppers passwords before handing them to another hasher: the
+// password is replaced by its HMAC-SHA256 under a secret key that is kept
+// out of the database, so a leaked credentials table cannot be attacked
+// offline without the key as well. Hashes are stored as
+// $pepper$v=<key version>$<inner hash>
+// Hashes of other versions keep verifying as long as their key is
+// configured, and hashes without a pepper are still accepted; both report
+// NeedsRehash so they are upgraded on the next login.
+type Hasher struct {
+	inner   hasher.Hasher
+	keys    map[int][]byte
+	current int
+}
+
+func NewHasher(inner hasher.Hasher, cfg config.Pepper) (*Hasher, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, errors.New("no pepper keys")
+	}
+
+	for v, key := range cfg.Keys {
+		if v <= 0 {
+			return nil, fmt.Errorf("invalid pepper key version %d", v)
+		}
+		if len(key) < MinKeyLen {
+			return nil, fmt.Errorf("pepper key %d is shorter than %d bytes", v, MinKeyLen)
+		}
+	}
+
+	current := cfg.Current
+	if current == 0 {
+		for v := range cfg.Keys {
+			current = max(current, v)
+		}
+	}
+	if _, ok := cfg.Keys[current]; !ok {
+		return nil, fmt.Errorf("no pepper key for current version %d", current)
+	}
+
+	return &Hasher{
+		inner:   inner,
+		keys:    cfg.Keys,
+		current: current,
+	}, nil
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	hash, err := h.inner.Hash(h.pepper(h.keys[h.current], password))
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + strconv.Itoa(h.current) + "$" + hash, nil
+}
+
+func (h *Hasher) Compare(password string, hash string) error {
+	version, inner, ok := split(hash)
+	if !ok {
+		return h.inner.Compare(password, hash)
+	}
+
+	key, ok := h.keys[version]
+	if !ok {
+		return fmt.Errorf("%w: unknown pepper version %d", domain.ErrInternal, version)
+	}
+
+	return h.inner.Compare(h.pepper(key, password), inner)
+}
+
+func (h *Hasher) NeedsRehash(hash string) bool {
+	version, inner, ok := split(hash)
+	if !ok || version != h.current {
+		return true
+	}
+
+	return h.inner.NeedsRehash(inner)
+}
+
+// Match reports whether hash can be verified: peppered hashes need a known
+// key version, and the inner hash, like hashes without a pepper, must be
+// recognized by the inner hasher if it is a hasher.Verifier.
+func (h *Hasher) Match(hash string) bool {
+	version, inner, ok := split(hash)
+	if ok {
+		if _, known := h.keys[version]; !known {
+			return false
+		}
+		hash = inner
+	}
+
+	v, isVerifier := h.inner.(hasher.Verifier)
+	if !isVerifier {
+		return true
+	}
+
+	return v.Match(hash)
+}
+
+// Validate checks that a peppered hash has a known key version and that
+// the inner hash, like hashes without a pepper, is valid for the inner
+// hasher if it is a hasher.Verifier.
+func (h *Hasher) Validate(hash string) error {
+	version, inner, ok := split(hash)
+	if ok {
+		if _, known := h.keys[version]; !known {
+			return fmt.Errorf("unknown pepper version %d", version)
+		}
+		hash = inner
+	}
+
+	v, isVerifier := h.inner.(hasher.Verifier)
+	if !isVerifier {
+		return nil
+	}
+
+	return v.Validate(hash)
+}
+
+// pepper encodes the MAC so inner hashers that stop at a NUL byte or limit
+// the input length (bcrypt takes 72 bytes) see all of it.
+func (h *Hasher) pepper(key []byte, password string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(password))
+	return base64.RawStdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func split(hash string) (int, string, bool) {
+	if !strings.HasPrefix(hash, prefix) {
+		return 0, "", false
+	}
+
+	version, inner, found := strings.Cut(strings.TrimPrefix(hash, prefix), "$")
+	if !found {
+		return 0, "", false
+	}
+
+	v, err := strconv.Atoi(version)
+	if err != nil || v <= 0 {
+		return 0, "", false
+	}
+
+	return v, inner, true
+}
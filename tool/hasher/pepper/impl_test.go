@@ -0,0 +1,173 @@
+package pepper
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+)
+
+// plainHasher stores its input as is, so tests see exactly what the pepper
+// hands to the inner hasher.
+type plainHasher struct{}
+
+func (plainHasher) Hash(str string) (string, error) {
+	return "plain:" + str, nil
+}
+
+func (plainHasher) Compare(str string, hash string) error {
+	if hash != "plain:"+str {
+		return domain.ErrInvalidPassrord
+	}
+	return nil
+}
+
+func (plainHasher) NeedsRehash(hash string) bool {
+	return false
+}
+
+var (
+	key1 = bytes.Repeat([]byte{1}, MinKeyLen)
+	key2 = bytes.Repeat([]byte{2}, MinKeyLen)
+)
+
+func newHasher(t *testing.T, cfg config.Pepper) *Hasher {
+	t.Helper()
+
+	h, err := NewHasher(plainHasher{}, cfg)
+	if err != nil {
+		t.Fatalf("NewHasher() error = %v", err)
+	}
+	return h
+}
+
+// TestHashKnownAnswer uses test case 6 of RFC 4231, the HMAC-SHA256 of a
+// key longer than the hash block size.
+func TestHashKnownAnswer(t *testing.T) {
+	key := bytes.Repeat([]byte{0xaa}, 131)
+	mac, _ := hex.DecodeString("60e431591ee0b67f0d8a26aacbf5b77f8e0bc6213728c5140546040f0ee37f54")
+
+	h := newHasher(t, config.Pepper{Keys: map[int][]byte{3: key}})
+
+	got, err := h.Hash("Test Using Larger Than Block-Size Key - Hash Key First")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	want := "$pepper$v=3$plain:" + base64.RawStdEncoding.EncodeToString(mac)
+	if got != want {
+		t.Errorf("Hash() = %q, want %q", got, want)
+	}
+}
+
+func TestNewHasher(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.Pepper
+		wantCurrent int
+		wantErr     bool
+	}{
+		{"highest version is current", config.Pepper{Keys: map[int][]byte{1: key1, 2: key2}}, 2, false},
+		{"explicit current", config.Pepper{Keys: map[int][]byte{1: key1, 2: key2}, Current: 1}, 1, false},
+		{"no keys", config.Pepper{}, 0, true},
+		{"short key", config.Pepper{Keys: map[int][]byte{1: key1[:MinKeyLen-1]}}, 0, true},
+		{"zero version", config.Pepper{Keys: map[int][]byte{0: key1}}, 0, true},
+		{"current without key", config.Pepper{Keys: map[int][]byte{1: key1}, Current: 2}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := NewHasher(plainHasher{}, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewHasher() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err == nil && h.current != tt.wantCurrent {
+				t.Errorf("NewHasher() current = %d, want %d", h.current, tt.wantCurrent)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	h := newHasher(t, config.Pepper{Keys: map[int][]byte{1: key1}})
+
+	hash, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	if !strings.HasPrefix(hash, "$pepper$v=1$plain:") {
+		t.Errorf("Hash() = %q, want a version 1 pepper", hash)
+	}
+	if strings.Contains(hash, "correct horse") {
+		t.Errorf("Hash() = %q passes the password to the inner hasher", hash)
+	}
+	if err := h.Compare("correct horse", hash); err != nil {
+		t.Errorf("Compare() error = %v, want nil", err)
+	}
+	if err := h.Compare("wrong horse", hash); !errors.Is(err, domain.ErrInvalidPassrord) {
+		t.Errorf("Compare() error = %v, want %v", err, domain.ErrInvalidPassrord)
+	}
+	if h.NeedsRehash(hash) {
+		t.Errorf("NeedsRehash() = true, want false")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	old := newHasher(t, config.Pepper{Keys: map[int][]byte{1: key1}})
+
+	v1Hash, err := old.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	rotated := newHasher(t, config.Pepper{Keys: map[int][]byte{1: key1, 2: key2}})
+
+	v2Hash, err := rotated.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if !strings.HasPrefix(v2Hash, "$pepper$v=2$") {
+		t.Errorf("Hash() = %q, want a version 2 pepper", v2Hash)
+	}
+
+	retired := newHasher(t, config.Pepper{Keys: map[int][]byte{2: key2}})
+
+	tests := []struct {
+		name            string
+		h               *Hasher
+		hash            string
+		wantErr         error
+		wantNeedsRehash bool
+		wantMatch       bool
+	}{
+		{"old version after rotation", rotated, v1Hash, nil, true, true},
+		{"current version after rotation", rotated, v2Hash, nil, false, true},
+		{"without pepper", rotated, "plain:correct horse", nil, true, true},
+		{"old version after its key is retired", retired, v1Hash, domain.ErrInternal, true, false},
+		{"malformed version", rotated, "$pepper$v=x$plain:correct horse", domain.ErrInvalidPassrord, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.h.Compare("correct horse", tt.hash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Compare() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := tt.h.NeedsRehash(tt.hash); got != tt.wantNeedsRehash {
+				t.Errorf("NeedsRehash() = %t, want %t", got, tt.wantNeedsRehash)
+			}
+			if got := tt.h.Match(tt.hash); got != tt.wantMatch {
+				t.Errorf("Match() = %t, want %t", got, tt.wantMatch)
+			}
+			if err := tt.h.Validate(tt.hash); (err == nil) != tt.wantMatch {
+				t.Errorf("Validate() error = %v, want error %t", err, !tt.wantMatch)
+			}
+		})
+	}
+}
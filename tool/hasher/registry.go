@@ -0,0 +1,96 @@
+package hasher
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+)
+
+var errUnsupportedFormat = errors.New("unsupported hash format")
+
+// Verifier checks passwords against hashes of one format.
+type Verifier interface {
+	// Match reports whether hash has the format handled by the verifier.
+	Match(hash string) bool
+	// Validate reports why a matching hash cannot be verified, such as
+	// malformed fields or cost parameters out of the accepted range.
+	Validate(hash string) error
+	Compare(str string, hash string) error
+}
+
+// Algorithm is a Hasher that can also recognize its own hashes.
+type Algorithm interface {
+	Hasher
+	Verifier
+}
+
+// Registry hashes with the current algorithm and dispatches Compare to the
+// verifier matching the hash format, so imported and older hashes keep
+// working until they are upgraded on the next login.
+type Registry struct {
+	current   Algorithm
+	verifiers []Verifier
+}
+
+func NewRegistry(current Algorithm, verifiers ...Verifier) *Registry {
+	return &Registry{
+		current:   current,
+		verifiers: verifiers,
+	}
+}
+
+func (r *Registry) Hash(str string) (string, error) {
+	return r.current.Hash(str)
+}
+
+func (r *Registry) Compare(str string, hash string) error {
+	if r.current.Match(hash) {
+		return r.current.Compare(str, hash)
+	}
+
+	for _, v := range r.verifiers {
+		if v.Match(hash) {
+			return v.Compare(str, hash)
+		}
+	}
+
+	return fmt.Errorf("%w: %s", domain.ErrInternal, errUnsupportedFormat)
+}
+
+// Match reports whether hash has a format the registry can verify.
+func (r *Registry) Match(hash string) bool {
+	if r.current.Match(hash) {
+		return true
+	}
+
+	for _, v := range r.verifiers {
+		if v.Match(hash) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks hash with the verifier matching its format.
+func (r *Registry) Validate(hash string) error {
+	if r.current.Match(hash) {
+		return r.current.Validate(hash)
+	}
+
+	for _, v := range r.verifiers {
+		if v.Match(hash) {
+			return v.Validate(hash)
+		}
+	}
+
+	return errUnsupportedFormat
+}
+
+func (r *Registry) NeedsRehash(hash string) bool {
+	if !r.current.Match(hash) {
+		return true
+	}
+	return r.current.NeedsRehash(hash)
+}
@@ -0,0 +1,101 @@
+package scrypt
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	prefix = "$scrypt$"
+
+	// Stored parameters are bounded so a corrupt or hostile hash cannot
+	// exhaust memory: scrypt needs 128*r*N bytes, and an allocation
+	// failure kills the process rather than the request.
+	maxLogN   = 20
+	maxRP     = 1 << 10
+	maxMemory = 1 << 30
+	maxKeyLen = 256
+)
+
+var errMalformedHash = errors.New("malformed scrypt hash")
+
+type params struct {
+	ln, r, p int
+}
+
+// Verifier checks scrypt hashes in the passlib format:
+// $scrypt$ln=<log2 N>,r=<r>,p=<p>$<salt>$<key>
+// It only verifies; new hashes are produced by the configured hasher.
+type Verifier struct{}
+
+func NewVerifier() *Verifier {
+	return &Verifier{}
+}
+
+func (v *Verifier) Match(hash string) bool {
+	return strings.HasPrefix(hash, prefix)
+}
+
+func (v *Verifier) Validate(hash string) error {
+	_, _, _, err := decode(hash)
+	return err
+}
+
+func (v *Verifier) Compare(password string, hash string) error {
+	p, salt, key, err := decode(hash)
+	if err != nil {
+		return domain.ErrInternal
+	}
+
+	other, err := scrypt.Key([]byte(password), salt, 1<<p.ln, p.r, p.p, len(key))
+	if err != nil {
+		return domain.ErrInternal
+	}
+
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return domain.ErrInvalidPassrord
+	}
+
+	return nil
+}
+
+func decode(hash string) (params, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(hash, prefix), "$")
+	if len(parts) != 3 {
+		return params{}, nil, nil, errMalformedHash
+	}
+
+	var p params
+	_, err := fmt.Sscanf(parts[0], "ln=%d,r=%d,p=%d", &p.ln, &p.r, &p.p)
+	if err != nil ||
+		p.ln < 1 || p.ln > maxLogN ||
+		p.r < 1 || p.p < 1 || p.r*p.p > maxRP ||
+		128*p.r<<p.ln > maxMemory {
+		return params{}, nil, nil, errMalformedHash
+	}
+
+	salt, err := decodeB64(parts[1])
+	if err != nil {
+		return params{}, nil, nil, errMalformedHash
+	}
+
+	key, err := decodeB64(parts[2])
+	if err != nil || len(key) == 0 || len(key) > maxKeyLen {
+		return params{}, nil, nil, errMalformedHash
+	}
+
+	return p, salt, key, nil
+}
+
+// decodeB64 accepts standard and passlib "adapted" base64, with or without
+// padding.
+func decodeB64(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.ReplaceAll(s, ".", "+"), "=")
+	return base64.RawStdEncoding.DecodeString(s)
+}
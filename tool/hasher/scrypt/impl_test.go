@@ -0,0 +1,71 @@
+package scrypt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+)
+
+// The vectors are the scrypt test vectors of RFC 7914, section 12, in the
+// passlib format.
+const (
+	emptyVector = "$scrypt$ln=4,r=1,p=1$$d9ZXYjhleyA7GcpCwYoEl/FrSETjB0ro39/6P+3iFEL80Aad7QlI+DJqdToPyB8X6NPg+y4NNijPNeIMONGJBg"
+	naclVector  = "$scrypt$ln=10,r=8,p=16$TmFDbA$/bq+HJ00cgB4VucZDQHp/nxq18vII3gw53N2Y0s3MWIurzDZLiKjiG/xCSedmDDaxyevuUqD7m2DYMvfoswGQA"
+)
+
+func TestCompare(t *testing.T) {
+	v := NewVerifier()
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		wantErr  error
+	}{
+		{"empty password and salt", "", emptyVector, nil},
+		{"password with salt", "password", naclVector, nil},
+		{"adapted base64", "password", strings.ReplaceAll(naclVector, "+", "."), nil},
+		{"padded base64", "password", strings.Replace(naclVector, "$TmFDbA$", "$TmFDbA==$", 1), nil},
+		{"wrong password", "Password", naclVector, domain.ErrInvalidPassrord},
+		{"wrong parameters", "password", strings.Replace(naclVector, "p=16", "p=8", 1), domain.ErrInvalidPassrord},
+		{"malformed", "password", "$scrypt$ln=10$TmFDbA", domain.ErrInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Compare(tt.password, tt.hash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Compare() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	v := NewVerifier()
+
+	tests := []struct {
+		name    string
+		hash    string
+		wantErr bool
+	}{
+		{"rfc vector", naclVector, false},
+		{"missing key", "$scrypt$ln=10,r=8,p=16$TmFDbA", true},
+		{"zero log n", "$scrypt$ln=0,r=8,p=16$TmFDbA$/bq+HJ00cgB4", true},
+		{"log n too large", "$scrypt$ln=21,r=1,p=1$TmFDbA$/bq+HJ00cgB4", true},
+		{"memory too large", "$scrypt$ln=20,r=16,p=1$TmFDbA$/bq+HJ00cgB4", true},
+		{"r times p too large", "$scrypt$ln=4,r=64,p=32$TmFDbA$/bq+HJ00cgB4", true},
+		{"empty key", "$scrypt$ln=10,r=8,p=16$TmFDbA$", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package sha1
+
+import (
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+)
+
+const prefix = "sha1$"
+
+var errMalformedHash = errors.New("malformed sha1 hash")
+
+// Verifier checks salted SHA-1 hashes from the legacy platform, stored as
+// sha1$<salt>$<hex of sha1(salt + password)>. SHA-1 is far too fast for
+// passwords, so this verifier must only be enabled explicitly while
+// imported accounts are being upgraded.
+type Verifier struct{}
+
+func NewVerifier() *Verifier {
+	return &Verifier{}
+}
+
+func (v *Verifier) Match(hash string) bool {
+	return strings.HasPrefix(hash, prefix)
+}
+
+func (v *Verifier) Validate(hash string) error {
+	_, _, err := decode(hash)
+	return err
+}
+
+func (v *Verifier) Compare(password string, hash string) error {
+	salt, want, err := decode(hash)
+	if err != nil {
+		return domain.ErrInternal
+	}
+
+	got := sha1.Sum([]byte(salt + password))
+
+	if subtle.ConstantTimeCompare(want, got[:]) != 1 {
+		return domain.ErrInvalidPassrord
+	}
+
+	return nil
+}
+
+func decode(hash string) (string, []byte, error) {
+	salt, digest, ok := strings.Cut(strings.TrimPrefix(hash, prefix), "$")
+	if !ok {
+		return "", nil, errMalformedHash
+	}
+
+	want, err := hex.DecodeString(digest)
+	if err != nil || len(want) != sha1.Size {
+		return "", nil, errMalformedHash
+	}
+
+	return salt, want, nil
+}
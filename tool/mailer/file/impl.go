@@ -0,0 +1,47 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/tool/mailer"
+)
+
+// Mailer is meant for local development: messages are appended to a file,
+// or written to the log when no path is configured.
+type Mailer struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewMailer(path string) *Mailer {
+	return &Mailer{path: path}
+}
+
+func (m *Mailer) Send(ctx context.Context, msg mailer.Message) error {
+	if m.path == "" {
+		log.Printf("mail to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open mail file: %w", err)
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC1123Z), msg.To, msg.Subject, msg.Body)
+	if err != nil {
+		return fmt.Errorf("write mail file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package queue
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/tool/mailer"
+)
+
+const (
+	defaultSize = 1024
+
+	sendTimeout = 30 * time.Second
+	// shutdownTimeout bounds sending what is left when the queue stops.
+	shutdownTimeout = 10 * time.Second
+)
+
+// Mailer sends through another mailer in the background. Send returns as
+// soon as the message is queued, so a request answers in the same time
+// whether or not it sent an email, and a slow mail server does not hold
+// it up. Delivery errors are only logged.
+type Mailer struct {
+	next     mailer.Mailer
+	messages chan mailer.Message
+}
+
+func NewMailer(next mailer.Mailer, size int) *Mailer {
+	if size == 0 {
+		size = defaultSize
+	}
+
+	return &Mailer{
+		next:     next,
+		messages: make(chan mailer.Message, size),
+	}
+}
+
+// Send queues msg. It never blocks: when the queue is full the message is
+// logged and dropped.
+func (m *Mailer) Send(ctx context.Context, msg mailer.Message) error {
+	select {
+	case m.messages <- msg:
+	default:
+		log.Printf("mail queue full, dropped %q to %s", msg.Subject, msg.To)
+	}
+	return nil
+}
+
+// Run sends queued messages until ctx is done, then sends what is left.
+func (m *Mailer) Run(ctx context.Context) {
+	for {
+		select {
+		case msg := <-m.messages:
+			m.send(ctx, msg)
+		case <-ctx.Done():
+			drainCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+
+			for {
+				select {
+				case msg := <-m.messages:
+					m.send(drainCtx, msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (m *Mailer) send(ctx context.Context, msg mailer.Message) {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	err := m.next.Send(ctx, msg)
+	if err != nil {
+		log.Printf("send %q to %s: %s", msg.Subject, msg.To, err)
+	}
+}
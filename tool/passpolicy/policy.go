@@ -0,0 +1,223 @@
+package passpolicy
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+)
+
+const (
+	CodeTooShort   = "too_short"
+	CodeTooLong    = "too_long"
+	CodeTooCommon  = "too_common"
+	CodeTooSimilar = "too_similar"
+	CodeTooWeak    = "too_weak"
+)
+
+const (
+	defaultMinLength  = 8
+	defaultMaxLength  = 128
+	defaultMaxBytes   = 72 // bcrypt ignores everything after 72 bytes
+	defaultMinEntropy = 35
+
+	minSimilarInputLen = 4
+)
+
+// commonPasswords is used in addition to the configured denylist file.
+var commonPasswords = []string{
+	"password", "password1", "password123", "123456", "12345678",
+	"123456789", "1234567890", "qwerty", "qwerty123", "qwertyuiop",
+	"111111", "000000", "abc123", "iloveyou", "letmein", "welcome",
+	"admin", "monkey", "dragon", "football", "baseball", "sunshine",
+	"princess", "trustno1", "passw0rd", "1q2w3e4r", "zaq12wsx",
+}
+
+type Policy struct {
+	cfg      config.PasswordPolicy
+	denylist map[string]struct{}
+}
+
+// NewPolicy builds a policy from cfg. If cfg.DenylistPath is set, the file
+// is read as one password per line; lines starting with # are ignored.
+func NewPolicy(cfg config.PasswordPolicy) (*Policy, error) {
+	if cfg.MinLength == 0 {
+		cfg.MinLength = defaultMinLength
+	}
+	if cfg.MaxLength == 0 {
+		cfg.MaxLength = defaultMaxLength
+	}
+	if cfg.MaxBytes == 0 {
+		cfg.MaxBytes = defaultMaxBytes
+	}
+	if cfg.MinEntropyBits == 0 {
+		cfg.MinEntropyBits = defaultMinEntropy
+	}
+
+	p := &Policy{
+		cfg:      cfg,
+		denylist: make(map[string]struct{}, len(commonPasswords)),
+	}
+
+	for _, pw := range commonPasswords {
+		p.denylist[pw] = struct{}{}
+	}
+
+	if cfg.DenylistPath != "" {
+		err := p.loadDenylist(cfg.DenylistPath)
+		if err != nil {
+			return nil, fmt.Errorf("load denylist: %w", err)
+		}
+	}
+
+	return p, nil
+}
+
+func (p *Policy) loadDenylist(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.denylist[strings.ToLower(line)] = struct{}{}
+	}
+
+	return sc.Err()
+}
+
+// Validate checks password against every rule and returns a
+// *domain.ValidationError listing all violations for field. userInputs are
+// values the password must not resemble, such as email or username.
+func (p *Policy) Validate(field, password string, userInputs ...string) error {
+	var violations []domain.FieldError
+
+	add := func(code, msg string) {
+		violations = append(violations, domain.FieldError{
+			Field:   field,
+			Code:    code,
+			Message: msg,
+		})
+	}
+
+	length := utf8.RuneCountInString(password)
+
+	if length < p.cfg.MinLength {
+		add(CodeTooShort, fmt.Sprintf("must be at least %d characters long", p.cfg.MinLength))
+	}
+	if length > p.cfg.MaxLength {
+		add(CodeTooLong, fmt.Sprintf("must be at most %d characters long", p.cfg.MaxLength))
+	} else if len(password) > p.cfg.MaxBytes {
+		add(CodeTooLong, fmt.Sprintf("must be at most %d bytes long", p.cfg.MaxBytes))
+	}
+
+	lower := strings.ToLower(password)
+
+	if _, ok := p.denylist[lower]; ok {
+		add(CodeTooCommon, "is too common or was found in a data breach")
+	}
+
+	if similar(lower, userInputs) {
+		add(CodeTooSimilar, "is too similar to your email or username")
+	}
+
+	if length >= p.cfg.MinLength && Entropy(password) < p.cfg.MinEntropyBits {
+		add(CodeTooWeak, "is too easy to guess, use a longer mix of words, digits and symbols")
+	}
+
+	if len(violations) > 0 {
+		return &domain.ValidationError{Fields: violations}
+	}
+
+	return nil
+}
+
+func similar(lowerPassword string, userInputs []string) bool {
+	for _, input := range userInputs {
+		input = strings.ToLower(strings.TrimSpace(input))
+
+		// compare against the local part of an email too
+		candidates := []string{input}
+		if at := strings.IndexByte(input, '@'); at > 0 {
+			candidates = append(candidates, input[:at])
+		}
+
+		for _, c := range candidates {
+			if len(c) < minSimilarInputLen {
+				continue
+			}
+			if strings.Contains(lowerPassword, c) || strings.Contains(c, lowerPassword) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Entropy estimates password strength in bits from the character classes in
+// use. Repeated characters and runs like "abc" or "321" add no entropy.
+func Entropy(password string) float64 {
+	var lower, upper, digit, symbol, other bool
+
+	runes := []rune(password)
+	effective := 0
+
+	for i, r := range runes {
+		switch {
+		case unicode.IsLower(r) && r < unicode.MaxASCII:
+			lower = true
+		case unicode.IsUpper(r) && r < unicode.MaxASCII:
+			upper = true
+		case unicode.IsDigit(r) && r < unicode.MaxASCII:
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+
+		if i > 0 {
+			d := r - runes[i-1]
+			if d == 0 || d == 1 || d == -1 {
+				continue
+			}
+		}
+		effective++
+	}
+
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if symbol {
+		pool += 33
+	}
+	if other {
+		pool += 100
+	}
+
+	if pool == 0 {
+		return 0
+	}
+
+	return float64(effective) * math.Log2(float64(pool))
+}
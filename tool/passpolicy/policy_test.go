@@ -0,0 +1,101 @@
+package passpolicy
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/akemoon/crowdfunding-app-auth/config"
+	"github.com/akemoon/crowdfunding-app-auth/domain"
+)
+
+func TestEntropy(t *testing.T) {
+	tests := []struct {
+		password string
+		want     float64
+	}{
+		{"", 0},
+		{"aaaaaaaa", math.Log2(26)},
+		{"abcdefgh", math.Log2(26)},
+		{"hgfedcba", math.Log2(26)},
+		{"ab12", 2 * math.Log2(36)},
+		{"aZ9!", 4 * math.Log2(95)},
+		{"жук", 3 * math.Log2(100)},
+	}
+
+	for _, tt := range tests {
+		got := Entropy(tt.password)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Entropy(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	denylist := filepath.Join(t.TempDir(), "denylist.txt")
+	err := os.WriteFile(denylist, []byte("# leaked\n\n  Summer-Breeze-2024!  \n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defaults := config.PasswordPolicy{}
+
+	tests := []struct {
+		name       string
+		cfg        config.PasswordPolicy
+		password   string
+		userInputs []string
+		want       []string
+	}{
+		{"strong", defaults, "correct horse battery staple", []string{"user@example.com"}, nil},
+		{"too short", defaults, "aB3$", nil, []string{CodeTooShort}},
+		{"common and weak", defaults, "password", nil, []string{CodeTooCommon, CodeTooWeak}},
+		{"common in other case", defaults, "PassWord123", nil, []string{CodeTooCommon}},
+		{"too many bytes", defaults, strings.Repeat("aB3$", 19), nil, []string{CodeTooLong}},
+		{"too many characters", defaults, strings.Repeat("aB3$", 33), nil, []string{CodeTooLong}},
+		{"contains email local part", defaults, "alice-Secret-2024!", []string{"Alice@example.com"}, []string{CodeTooSimilar}},
+		{"short inputs are ignored", defaults, "bobs-Secret-99!", []string{"bob@x.io"}, nil},
+		{"denylist file", config.PasswordPolicy{DenylistPath: denylist}, "summer-breeze-2024!", nil, []string{CodeTooCommon}},
+		{"custom minimum length", config.PasswordPolicy{MinLength: 12}, "aB3$xY7!qW", nil, []string{CodeTooShort}},
+		{"custom entropy", config.PasswordPolicy{MinEntropyBits: 100}, "aB3$xY7!qW", nil, []string{CodeTooWeak}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPolicy(tt.cfg)
+			if err != nil {
+				t.Fatalf("NewPolicy() error = %v", err)
+			}
+
+			err = p.Validate("password", tt.password, tt.userInputs...)
+
+			var got []string
+			var verr *domain.ValidationError
+			if errors.As(err, &verr) {
+				for _, f := range verr.Fields {
+					if f.Field != "password" {
+						t.Errorf("Validate() field = %q, want %q", f.Field, "password")
+					}
+					got = append(got, f.Code)
+				}
+			} else if err != nil {
+				t.Fatalf("Validate() error = %v, want a *domain.ValidationError", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() codes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPolicyMissingDenylist(t *testing.T) {
+	_, err := NewPolicy(config.PasswordPolicy{DenylistPath: filepath.Join(t.TempDir(), "missing.txt")})
+	if err == nil {
+		t.Errorf("NewPolicy() error = nil, want an error")
+	}
+}
@@ -0,0 +1,21 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter is the time until the oldest counted request leaves the
+	// window.
+	ResetAfter time.Duration
+}
+
+type Limiter interface {
+	// Allow counts a request for key and reports whether it fits in limit
+	// requests per sliding window.
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (Result, error)
+}
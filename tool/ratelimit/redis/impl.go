@@ -0,0 +1,63 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/akemoon/crowdfunding-app-auth/tool/ratelimit"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindow keeps one sorted set entry per request scored by its time.
+// Redis time is used so replicas with skewed clocks share one window.
+var slidingWindow = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], 0, now - window)
+
+local count = redis.call('ZCARD', KEYS[1])
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[3])
+	redis.call('PEXPIRE', KEYS[1], window)
+	count = count + 1
+	local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+	return {1, limit - count, tonumber(oldest[2]) + window - now}
+end
+
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+return {0, 0, tonumber(oldest[2]) + window - now}
+`)
+
+type Limiter struct {
+	redisClient *redis.Client
+}
+
+func NewLimiter(rc *redis.Client) *Limiter {
+	return &Limiter{
+		redisClient: rc,
+	}
+}
+
+func (l *Limiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (ratelimit.Result, error) {
+	res, err := slidingWindow.Run(ctx, l.redisClient,
+		[]string{"ratelimit:" + key},
+		window.Milliseconds(),
+		limit,
+		uuid.NewString(),
+	).Int64Slice()
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("rate limit script: %w", err)
+	}
+
+	return ratelimit.Result{
+		Allowed:    res[0] == 1,
+		Limit:      limit,
+		Remaining:  int(res[1]),
+		ResetAfter: time.Duration(res[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,85 @@
+package useragent
+
+import (
+	"strings"
+)
+
+const unknown = "Unknown device"
+
+// Order matters: most browsers also claim to be the ones listed after them.
+var browsers = []struct {
+	token string
+	name  string
+}{
+	{"Edg/", "Edge"},
+	{"EdgA/", "Edge"},
+	{"EdgiOS/", "Edge"},
+	{"OPR/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"YaBrowser/", "Yandex Browser"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+}
+
+var systems = []struct {
+	token string
+	name  string
+}{
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Android", "Android"},
+	{"CrOS", "ChromeOS"},
+	{"Windows", "Windows"},
+	{"Macintosh", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// Describe turns a User-Agent header into a short description such as
+// "Chrome on Windows". It names the browser family and operating system
+// only, which is enough for a user to recognize their devices without
+// exposing version details. Non-browser clients are named by their
+// product token.
+func Describe(ua string) string {
+	ua = strings.TrimSpace(ua)
+	if ua == "" {
+		return unknown
+	}
+
+	var browser, system string
+
+	if strings.HasPrefix(ua, "Mozilla/") {
+		for _, b := range browsers {
+			if strings.Contains(ua, b.token) {
+				browser = b.name
+				break
+			}
+		}
+	}
+	for _, s := range systems {
+		if strings.Contains(ua, s.token) {
+			system = s.name
+			break
+		}
+	}
+
+	if browser == "" && system == "" {
+		product, _, _ := strings.Cut(ua, "/")
+		product, _, _ = strings.Cut(product, " ")
+		if product == "" || product == "Mozilla" {
+			return unknown
+		}
+		return product
+	}
+
+	switch {
+	case browser == "":
+		return system
+	case system == "":
+		return browser
+	default:
+		return browser + " on " + system
+	}
+}
@@ -0,0 +1,145 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// This is a minimal CBOR decoder covering what authenticators send in
+// attestation objects and COSE keys: integers, byte and text strings,
+// arrays, maps and simple values. Indefinite lengths are rejected.
+
+const maxCBORDepth = 16
+
+var errCBORTruncated = errors.New("cbor: truncated input")
+
+// decodeCBOR decodes one item and returns it with the remaining input.
+// Maps decode to map[any]any with int64 or string keys.
+func decodeCBOR(b []byte) (any, []byte, error) {
+	return decodeItem(b, 0)
+}
+
+func decodeItem(b []byte, depth int) (any, []byte, error) {
+	if depth > maxCBORDepth {
+		return nil, nil, errors.New("cbor: nesting too deep")
+	}
+	if len(b) == 0 {
+		return nil, nil, errCBORTruncated
+	}
+
+	major := b[0] >> 5
+	info := b[0] & 0x1f
+
+	if major == 7 {
+		return decodeSimple(b, info)
+	}
+
+	n, rest, err := decodeLength(b[1:], info)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if n > math.MaxInt64 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return int64(n), rest, nil
+	case 1:
+		if n > math.MaxInt64 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return -1 - int64(n), rest, nil
+	case 2, 3:
+		if uint64(len(rest)) < n {
+			return nil, nil, errCBORTruncated
+		}
+		if major == 2 {
+			return append([]byte(nil), rest[:n]...), rest[n:], nil
+		}
+		return string(rest[:n]), rest[n:], nil
+	case 4:
+		if n > uint64(len(rest)) {
+			return nil, nil, errCBORTruncated
+		}
+		items := make([]any, 0, n)
+		for range n {
+			var item any
+			item, rest, err = decodeItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, rest, nil
+	case 5:
+		if n > uint64(len(rest)) {
+			return nil, nil, errCBORTruncated
+		}
+		m := make(map[any]any, n)
+		for range n {
+			var k, v any
+			k, rest, err = decodeItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch k.(type) {
+			case int64, string:
+			default:
+				return nil, nil, fmt.Errorf("cbor: unsupported map key %T", k)
+			}
+			v, rest, err = decodeItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			m[k] = v
+		}
+		return m, rest, nil
+	default:
+		return nil, nil, fmt.Errorf("cbor: unsupported major type %d", major)
+	}
+}
+
+func decodeLength(b []byte, info byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), b, nil
+	case info == 24:
+		if len(b) < 1 {
+			return 0, nil, errCBORTruncated
+		}
+		return uint64(b[0]), b[1:], nil
+	case info == 25:
+		if len(b) < 2 {
+			return 0, nil, errCBORTruncated
+		}
+		return uint64(binary.BigEndian.Uint16(b)), b[2:], nil
+	case info == 26:
+		if len(b) < 4 {
+			return 0, nil, errCBORTruncated
+		}
+		return uint64(binary.BigEndian.Uint32(b)), b[4:], nil
+	case info == 27:
+		if len(b) < 8 {
+			return 0, nil, errCBORTruncated
+		}
+		return binary.BigEndian.Uint64(b), b[8:], nil
+	default:
+		return 0, nil, errors.New("cbor: indefinite length not supported")
+	}
+}
+
+func decodeSimple(b []byte, info byte) (any, []byte, error) {
+	switch info {
+	case 20:
+		return false, b[1:], nil
+	case 21:
+		return true, b[1:], nil
+	case 22, 23:
+		return nil, b[1:], nil
+	default:
+		return nil, nil, fmt.Errorf("cbor: unsupported simple value %d", info)
+	}
+}
@@ -0,0 +1,91 @@
+package webauthn
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/akemoon/crowdfunding-app-auth/tool/webauthn/webauthntest"
+)
+
+func TestDecodeCBOR(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want any
+		rest []byte
+	}{
+		{"small int", []byte{0x17}, int64(23), nil},
+		{"one byte int", []byte{0x18, 0xff}, int64(255), nil},
+		{"eight byte int", webauthntest.EncodeCBOR(int64(1) << 40), int64(1) << 40, nil},
+		{"negative int", []byte{0x26}, int64(-7), nil},
+		{"two byte negative int", []byte{0x39, 0x01, 0x00}, int64(-257), nil},
+		{"bytes", []byte{0x43, 1, 2, 3}, []byte{1, 2, 3}, nil},
+		{"text", webauthntest.EncodeCBOR("none"), "none", nil},
+		{"array", webauthntest.EncodeCBOR([]any{1, "a", true}), []any{int64(1), "a", true}, nil},
+		{"map", webauthntest.EncodeCBOR(webauthntest.Map{{1, 2}, {"k", []byte{9}}}), map[any]any{int64(1): int64(2), "k": []byte{9}}, nil},
+		{"false", []byte{0xf4}, false, nil},
+		{"null", []byte{0xf6}, nil, nil},
+		{"trailing input", []byte{0x01, 0x02}, int64(1), []byte{0x02}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rest, err := decodeCBOR(tt.in)
+			if err != nil {
+				t.Fatalf("decodeCBOR() error = %s", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeCBOR() = %#v, want %#v", got, tt.want)
+			}
+			if !bytes.Equal(rest, tt.rest) {
+				t.Errorf("decodeCBOR() rest = %x, want %x", rest, tt.rest)
+			}
+		})
+	}
+}
+
+func TestDecodeCBORRejects(t *testing.T) {
+	nested := func(depth int) []byte {
+		b := bytes.Repeat([]byte{0x81}, depth) // array of one item
+		return append(b, 0x00)
+	}
+
+	tests := []struct {
+		name string
+		in   []byte
+	}{
+		{"empty", nil},
+		{"truncated length", []byte{0x19, 0x01}},
+		{"truncated bytes", []byte{0x45, 1, 2}},
+		{"truncated text", []byte{0x63, 'a'}},
+		{"truncated array", []byte{0x82, 0x01}},
+		{"truncated map", []byte{0xa1, 0x01}},
+		{"array longer than input", []byte{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{"map longer than input", []byte{0xba, 0xff, 0xff, 0xff, 0xff}},
+		{"bytes longer than input", []byte{0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{"integer overflow", []byte{0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{"negative integer overflow", []byte{0x3b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{"indefinite length", []byte{0x5f, 0x41, 0x00, 0xff}},
+		{"tag", []byte{0xc0, 0x00}},
+		{"float", []byte{0xf9, 0x00, 0x00}},
+		{"bytes map key", []byte{0xa1, 0x41, 0x00, 0x00}},
+		{"too deep", nested(maxCBORDepth + 2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := decodeCBOR(tt.in)
+			if err == nil {
+				t.Fatal("decodeCBOR() error = nil, want error")
+			}
+		})
+	}
+
+	t.Run("deepest allowed", func(t *testing.T) {
+		_, _, err := decodeCBOR(nested(maxCBORDepth))
+		if err != nil {
+			t.Fatalf("decodeCBOR() error = %s", err)
+		}
+	})
+}
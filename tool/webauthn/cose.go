@@ -0,0 +1,153 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers accepted for credentials, in order of
+// preference.
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+var SupportedAlgs = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+const (
+	coseKty = 1
+	coseAlg = 3
+
+	ktyOKP = 1
+	ktyEC2 = 2
+	ktyRSA = 3
+
+	crvP256    = 1
+	crvEd25519 = 6
+)
+
+var errUnsupportedKey = errors.New("unsupported public key")
+
+// VerifySignature checks sig over data with a COSE encoded public key.
+func VerifySignature(coseKey, data, sig []byte) error {
+	v, _, err := decodeCBOR(coseKey)
+	if err != nil {
+		return err
+	}
+
+	m, ok := v.(map[any]any)
+	if !ok {
+		return errUnsupportedKey
+	}
+
+	kty, _ := m[int64(coseKty)].(int64)
+	alg, _ := m[int64(coseAlg)].(int64)
+
+	switch {
+	case kty == ktyEC2 && alg == AlgES256:
+		pub, err := ec2Key(m)
+		if err != nil {
+			return err
+		}
+		digest := sha256.Sum256(data)
+		if !ecdsa.VerifyASN1(pub, digest[:], sig) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case kty == ktyOKP && alg == AlgEdDSA:
+		crv, _ := m[int64(-1)].(int64)
+		x, _ := m[int64(-2)].([]byte)
+		if crv != crvEd25519 || len(x) != ed25519.PublicKeySize {
+			return errUnsupportedKey
+		}
+		if !ed25519.Verify(ed25519.PublicKey(x), data, sig) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case kty == ktyRSA && alg == AlgRS256:
+		pub, err := rsaKey(m)
+		if err != nil {
+			return err
+		}
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig)
+	default:
+		return fmt.Errorf("%w: kty %d alg %d", errUnsupportedKey, kty, alg)
+	}
+}
+
+// checkPublicKey makes sure a key received at registration can be used
+// later.
+func checkPublicKey(coseKey []byte) error {
+	v, _, err := decodeCBOR(coseKey)
+	if err != nil {
+		return err
+	}
+
+	m, ok := v.(map[any]any)
+	if !ok {
+		return errUnsupportedKey
+	}
+
+	kty, _ := m[int64(coseKty)].(int64)
+	alg, _ := m[int64(coseAlg)].(int64)
+
+	switch {
+	case kty == ktyEC2 && alg == AlgES256:
+		_, err = ec2Key(m)
+		return err
+	case kty == ktyOKP && alg == AlgEdDSA:
+		crv, _ := m[int64(-1)].(int64)
+		x, _ := m[int64(-2)].([]byte)
+		if crv != crvEd25519 || len(x) != ed25519.PublicKeySize {
+			return errUnsupportedKey
+		}
+		return nil
+	case kty == ktyRSA && alg == AlgRS256:
+		_, err = rsaKey(m)
+		return err
+	default:
+		return fmt.Errorf("%w: kty %d alg %d", errUnsupportedKey, kty, alg)
+	}
+}
+
+func ec2Key(m map[any]any) (*ecdsa.PublicKey, error) {
+	crv, _ := m[int64(-1)].(int64)
+	x, _ := m[int64(-2)].([]byte)
+	y, _ := m[int64(-3)].([]byte)
+	if crv != crvP256 || len(x) != 32 || len(y) != 32 {
+		return nil, errUnsupportedKey
+	}
+
+	// ParseUncompressedPublicKey rejects points that are not on the curve.
+	point := append([]byte{4}, append(x, y...)...)
+	pub, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errUnsupportedKey, err)
+	}
+
+	return pub, nil
+}
+
+func rsaKey(m map[any]any) (*rsa.PublicKey, error) {
+	n, _ := m[int64(-1)].([]byte)
+	e, _ := m[int64(-2)].([]byte)
+	if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+		return nil, errUnsupportedKey
+	}
+
+	exp := new(big.Int).SetBytes(e)
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exp.Int64()),
+	}, nil
+}
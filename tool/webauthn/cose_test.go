@@ -0,0 +1,74 @@
+package webauthn
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/akemoon/crowdfunding-app-auth/tool/webauthn/webauthntest"
+)
+
+func TestVerifySignature(t *testing.T) {
+	es256 := webauthntest.NewES256(t)
+	ed25519 := webauthntest.NewEd25519(t)
+	data := []byte("signed data")
+
+	tests := []struct {
+		name    string
+		a       *webauthntest.Authenticator
+		key     []byte
+		data    []byte
+		wantErr bool
+	}{
+		{"es256", es256, es256.PublicKey, data, false},
+		{"ed25519", ed25519, ed25519.PublicKey, data, false},
+		{"es256 other data", es256, es256.PublicKey, []byte("other data"), true},
+		{"ed25519 other data", ed25519, ed25519.PublicKey, []byte("other data"), true},
+		{"es256 other key", es256, webauthntest.NewES256(t).PublicKey, data, true},
+		{"ed25519 other key", ed25519, webauthntest.NewEd25519(t).PublicKey, data, true},
+		{"key of other algorithm", es256, ed25519.PublicKey, data, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig := tt.a.Sign(t, data, nil)
+			signed := append(append([]byte(nil), tt.data...), clientDataHash(nil)...)
+
+			err := VerifySignature(tt.key, signed, sig)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifySignature() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckPublicKey(t *testing.T) {
+	es256 := webauthntest.NewES256(t)
+
+	x := bytes.Repeat([]byte{1}, 32)
+
+	tests := []struct {
+		name    string
+		key     []byte
+		wantErr bool
+	}{
+		{"es256", es256.PublicKey, false},
+		{"ed25519", webauthntest.NewEd25519(t).PublicKey, false},
+		{"not cbor", []byte{0xff}, true},
+		{"not a map", webauthntest.EncodeCBOR([]any{1, 2}), true},
+		{"unsupported algorithm", webauthntest.EncodeCBOR(webauthntest.Map{{1, 2}, {3, -35}, {-1, 2}, {-2, x}, {-3, x}}), true},
+		{"ec2 point not on curve", webauthntest.EncodeCBOR(webauthntest.Map{{1, 2}, {3, -7}, {-1, 1}, {-2, x}, {-3, x}}), true},
+		{"ec2 short coordinate", webauthntest.EncodeCBOR(webauthntest.Map{{1, 2}, {3, -7}, {-1, 1}, {-2, x[:31]}, {-3, x}}), true},
+		{"okp wrong curve", webauthntest.EncodeCBOR(webauthntest.Map{{1, 1}, {3, -8}, {-1, 4}, {-2, x}}), true},
+		{"okp short key", webauthntest.EncodeCBOR(webauthntest.Map{{1, 1}, {3, -8}, {-1, 6}, {-2, x[:16]}}), true},
+		{"rsa short modulus", webauthntest.EncodeCBOR(webauthntest.Map{{1, 3}, {3, -257}, {-1, x}, {-2, []byte{1, 0, 1}}}), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPublicKey(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkPublicKey() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/akemoon/crowdfunding-app-auth/tool/webauthn/webauthntest"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://example.com"
+)
+
+var testRP = RelyingParty{
+	ID:      testRPID,
+	Origins: []string{testOrigin},
+}
+
+func clientDataHash(clientDataJSON []byte) []byte {
+	h := sha256.Sum256(clientDataJSON)
+	return h[:]
+}
+
+func TestParseClientData(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       []byte
+		wantType string
+		wantErr  bool
+	}{
+		{"create", webauthntest.ClientData(TypeCreate, "c", testOrigin), TypeCreate, false},
+		{"get", webauthntest.ClientData(TypeGet, "c", testOrigin), TypeGet, false},
+		{"wrong type", webauthntest.ClientData(TypeGet, "c", testOrigin), TypeCreate, true},
+		{"wrong origin", webauthntest.ClientData(TypeGet, "c", "https://evil.example"), TypeGet, true},
+		{"subdomain origin", webauthntest.ClientData(TypeGet, "c", "https://login.example.com"), TypeGet, true},
+		{"empty challenge", webauthntest.ClientData(TypeGet, "", testOrigin), TypeGet, true},
+		{"not json", []byte("{"), TypeGet, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cd, err := testRP.ParseClientData(tt.in, tt.wantType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseClientData() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidResponse) {
+				t.Errorf("ParseClientData() error = %v, want ErrInvalidResponse", err)
+			}
+			if err == nil && cd.Challenge != "c" {
+				t.Errorf("ParseClientData() challenge = %q, want %q", cd.Challenge, "c")
+			}
+		})
+	}
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	es256 := webauthntest.NewES256(t)
+	ed25519 := webauthntest.NewEd25519(t)
+
+	const flags = webauthntest.FlagUserPresent | webauthntest.FlagUserVerified | webauthntest.FlagAttested
+
+	notAttested := es256.AuthData(testRPID, webauthntest.FlagUserPresent|webauthntest.FlagUserVerified, 0)
+	attested := es256.AuthData(testRPID, flags, 0)
+
+	tests := []struct {
+		name    string
+		a       *webauthntest.Authenticator
+		in      []byte
+		wantUV  bool
+		wantErr bool
+	}{
+		{"es256", es256, webauthntest.AttestationObject(es256.AuthData(testRPID, flags, 0)), true, false},
+		{"ed25519", ed25519, webauthntest.AttestationObject(ed25519.AuthData(testRPID, flags, 7)), true, false},
+		{"user not verified", es256, webauthntest.AttestationObject(es256.AuthData(testRPID, flags&^webauthntest.FlagUserVerified, 0)), false, false},
+		{"user not present", es256, webauthntest.AttestationObject(es256.AuthData(testRPID, flags&^webauthntest.FlagUserPresent, 0)), false, true},
+		{"other rp id", es256, webauthntest.AttestationObject(es256.AuthData("evil.example", flags, 0)), false, true},
+		{"no attested credential", es256, webauthntest.AttestationObject(notAttested), false, true},
+		{"short auth data", es256, webauthntest.AttestationObject(attested[:authDataMinLen-1]), false, true},
+		{"truncated credential id", es256, webauthntest.AttestationObject(attested[:authDataMinLen+20]), false, true},
+		{"truncated public key", es256, webauthntest.AttestationObject(attested[:len(attested)-1]), false, true},
+		{"missing auth data", es256, webauthntest.EncodeCBOR(webauthntest.Map{{"fmt", "none"}}), false, true},
+		{"not a map", es256, webauthntest.EncodeCBOR([]any{attested}), false, true},
+		{"truncated attestation object", es256, webauthntest.AttestationObject(attested)[:40], false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg, err := testRP.VerifyRegistration(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyRegistration() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidResponse) {
+					t.Errorf("VerifyRegistration() error = %v, want ErrInvalidResponse", err)
+				}
+				return
+			}
+
+			if !bytes.Equal(reg.CredentialID, tt.a.CredentialID) {
+				t.Errorf("CredentialID = %x, want %x", reg.CredentialID, tt.a.CredentialID)
+			}
+			if !bytes.Equal(reg.PublicKey, tt.a.PublicKey) {
+				t.Errorf("PublicKey = %x, want %x", reg.PublicKey, tt.a.PublicKey)
+			}
+			if reg.UserVerified != tt.wantUV {
+				t.Errorf("UserVerified = %t, want %t", reg.UserVerified, tt.wantUV)
+			}
+		})
+	}
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	es256 := webauthntest.NewES256(t)
+	ed25519 := webauthntest.NewEd25519(t)
+
+	const flags = webauthntest.FlagUserPresent | webauthntest.FlagUserVerified
+
+	clientData := webauthntest.ClientData(TypeGet, "c", testOrigin)
+
+	tests := []struct {
+		name string
+		a    *webauthntest.Authenticator
+		// key defaults to the public key of a
+		key            []byte
+		authData       []byte
+		signedAuthData []byte
+		clientData     []byte
+		wantCount      uint32
+		wantUV         bool
+		wantErr        bool
+	}{
+		{name: "es256", a: es256, authData: es256.AuthData(testRPID, flags, 5), wantCount: 5, wantUV: true},
+		{name: "ed25519", a: ed25519, authData: ed25519.AuthData(testRPID, flags, 9), wantCount: 9, wantUV: true},
+		{name: "user not verified", a: es256, authData: es256.AuthData(testRPID, webauthntest.FlagUserPresent, 1), wantCount: 1},
+		{name: "user not present", a: es256, authData: es256.AuthData(testRPID, webauthntest.FlagUserVerified, 1), wantErr: true},
+		{name: "other rp id", a: es256, authData: es256.AuthData("evil.example", flags, 1), wantErr: true},
+		{name: "short auth data", a: es256, authData: es256.AuthData(testRPID, flags, 1)[:authDataMinLen-1], wantErr: true},
+		{name: "other key", a: es256, key: webauthntest.NewES256(t).PublicKey, authData: es256.AuthData(testRPID, flags, 1), wantErr: true},
+		{
+			name:           "tampered counter",
+			a:              ed25519,
+			authData:       ed25519.AuthData(testRPID, flags, 100),
+			signedAuthData: ed25519.AuthData(testRPID, flags, 1),
+			wantErr:        true,
+		},
+		{
+			name:       "other client data",
+			a:          es256,
+			authData:   es256.AuthData(testRPID, flags, 1),
+			clientData: webauthntest.ClientData(TypeGet, "c", "https://evil.example"),
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := tt.key
+			if key == nil {
+				key = tt.a.PublicKey
+			}
+			signedAuthData := tt.signedAuthData
+			if signedAuthData == nil {
+				signedAuthData = tt.authData
+			}
+			presented := tt.clientData
+			if presented == nil {
+				presented = clientData
+			}
+
+			sig := tt.a.Sign(t, signedAuthData, clientData)
+
+			ad, err := testRP.VerifyAssertion(key, tt.authData, presented, sig)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyAssertion() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidResponse) {
+					t.Errorf("VerifyAssertion() error = %v, want ErrInvalidResponse", err)
+				}
+				return
+			}
+
+			if ad.SignCount != tt.wantCount {
+				t.Errorf("SignCount = %d, want %d", ad.SignCount, tt.wantCount)
+			}
+			if ad.UserVerified() != tt.wantUV {
+				t.Errorf("UserVerified() = %t, want %t", ad.UserVerified(), tt.wantUV)
+			}
+		})
+	}
+}
+
+func TestDecodeBase64(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []byte
+		wantErr bool
+	}{
+		{"AQI", []byte{1, 2}, false},
+		{"AQI=", []byte{1, 2}, false},
+		{"-_8", []byte{0xfb, 0xff}, false},
+		{"+/8", nil, true},
+		{"A", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := DecodeBase64(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeBase64() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("DecodeBase64() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
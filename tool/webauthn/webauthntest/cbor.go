@@ -0,0 +1,67 @@
+package webauthntest
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Map is a CBOR map of key/value pairs that keeps its entries in order, so
+// encodings are reproducible.
+type Map [][2]any
+
+// EncodeCBOR encodes int, int64, []byte, string, bool, nil, []any and Map
+// values. It panics on other types.
+func EncodeCBOR(v any) []byte {
+	switch v := v.(type) {
+	case int:
+		return EncodeCBOR(int64(v))
+	case int64:
+		if v < 0 {
+			return head(1, uint64(-1-v))
+		}
+		return head(0, uint64(v))
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case []any:
+		b := head(4, uint64(len(v)))
+		for _, item := range v {
+			b = append(b, EncodeCBOR(item)...)
+		}
+		return b
+	case Map:
+		b := head(5, uint64(len(v)))
+		for _, p := range v {
+			b = append(b, EncodeCBOR(p[0])...)
+			b = append(b, EncodeCBOR(p[1])...)
+		}
+		return b
+	case bool:
+		if v {
+			return []byte{0xf5}
+		}
+		return []byte{0xf4}
+	case nil:
+		return []byte{0xf6}
+	default:
+		panic(fmt.Sprintf("webauthntest: cannot encode %T", v))
+	}
+}
+
+func head(major byte, n uint64) []byte {
+	m := major << 5
+
+	switch {
+	case n < 24:
+		return []byte{m | byte(n)}
+	case n <= 0xff:
+		return []byte{m | 24, byte(n)}
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{m | 25}, uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32([]byte{m | 26}, uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64([]byte{m | 27}, n)
+	}
+}
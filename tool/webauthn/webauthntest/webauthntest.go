@@ -0,0 +1,144 @@
+// Package webauthntest builds the responses of a software authenticator,
+// so registration and assertion checks can be tested without a browser.
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+const (
+	FlagUserPresent  = 0x01
+	FlagUserVerified = 0x04
+	FlagAttested     = 0x40
+)
+
+// Authenticator holds one credential.
+type Authenticator struct {
+	CredentialID []byte
+	// PublicKey is COSE encoded.
+	PublicKey []byte
+	sign      func(data []byte) ([]byte, error)
+}
+
+// NewES256 returns an authenticator with a P-256 key.
+func NewES256(t testing.TB) *Authenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate es256 key: %s", err)
+	}
+
+	point, err := key.PublicKey.Bytes()
+	if err != nil {
+		t.Fatalf("encode es256 key: %s", err)
+	}
+
+	return &Authenticator{
+		CredentialID: credentialID(t),
+		PublicKey: EncodeCBOR(Map{
+			{1, 2},  // kty: EC2
+			{3, -7}, // alg: ES256
+			{-1, 1}, // crv: P-256
+			{-2, point[1:33]},
+			{-3, point[33:]},
+		}),
+		sign: func(data []byte) ([]byte, error) {
+			digest := sha256.Sum256(data)
+			return ecdsa.SignASN1(rand.Reader, key, digest[:])
+		},
+	}
+}
+
+// NewEd25519 returns an authenticator with an Ed25519 key.
+func NewEd25519(t testing.TB) *Authenticator {
+	t.Helper()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ed25519 key: %s", err)
+	}
+
+	return &Authenticator{
+		CredentialID: credentialID(t),
+		PublicKey: EncodeCBOR(Map{
+			{1, 1},  // kty: OKP
+			{3, -8}, // alg: EdDSA
+			{-1, 6}, // crv: Ed25519
+			{-2, []byte(pub)},
+		}),
+		sign: func(data []byte) ([]byte, error) {
+			return ed25519.Sign(key, data), nil
+		},
+	}
+}
+
+// AuthData returns authenticator data for rpID. With FlagAttested the
+// credential id and public key are appended, as on registration.
+func (a *Authenticator) AuthData(rpID string, flags byte, signCount uint32) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+
+	b := append([]byte(nil), rpIDHash[:]...)
+	b = append(b, flags)
+	b = binary.BigEndian.AppendUint32(b, signCount)
+
+	if flags&FlagAttested != 0 {
+		b = append(b, make([]byte, 16)...) // aaguid
+		b = binary.BigEndian.AppendUint16(b, uint16(len(a.CredentialID)))
+		b = append(b, a.CredentialID...)
+		b = append(b, a.PublicKey...)
+	}
+
+	return b
+}
+
+// Sign returns the assertion signature over authData and clientDataJSON.
+func (a *Authenticator) Sign(t testing.TB, authData, clientDataJSON []byte) []byte {
+	t.Helper()
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+
+	sig, err := a.sign(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	if err != nil {
+		t.Fatalf("sign assertion: %s", err)
+	}
+
+	return sig
+}
+
+// AttestationObject wraps authData in a "none" attestation.
+func AttestationObject(authData []byte) []byte {
+	return EncodeCBOR(Map{
+		{"fmt", "none"},
+		{"attStmt", Map{}},
+		{"authData", authData},
+	})
+}
+
+// ClientData returns clientDataJSON as a browser sends it.
+func ClientData(typ, challenge, origin string) []byte {
+	b, _ := json.Marshal(map[string]string{
+		"type":      typ,
+		"challenge": challenge,
+		"origin":    origin,
+	})
+	return b
+}
+
+func credentialID(t testing.TB) []byte {
+	t.Helper()
+
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		t.Fatalf("generate credential id: %s", err)
+	}
+	return id
+}